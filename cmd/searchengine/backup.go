@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// backupCommand writes every database of the index into one archive. A running
+// server holds the databases, back it up with /admin/backup instead.
+func backupCommand(args []string) error {
+	flags, settings, err := newFlagSet("backup", args)
+	if err != nil {
+		return err
+	}
+	output := flags.String("o", "searchengine-"+time.Now().Format("20060102-150405")+".tar.gz", "archive to write")
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+
+	indexes, err := Indexer.Open(settings.Index)
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	manifest, err := indexes.Backup(out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(*output)
+		return err
+	}
+	fmt.Printf("Backed up %d databases to %s\n", len(manifest.Databases), *output)
+	return nil
+}
+
+// restoreCommand restores an archive written by backup into a new index directory
+func restoreCommand(args []string) error {
+	flags, settings, err := newFlagSet("restore", args)
+	if err != nil {
+		return err
+	}
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: searchengine restore [-index DIR] ARCHIVE")
+	}
+
+	in, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	manifest, err := Indexer.Restore(in, settings.Index)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %d databases backed up on %s into %s\n", len(manifest.Databases), manifest.Created.Format(time.RFC1123), settings.Index)
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/davi1972/comp4321-search-engine/config"
+)
+
+// configCommand prints the settings after the file and the environment are
+// applied, or with "env" the environment variables that override them
+func configCommand(args []string) error {
+	if len(args) == 0 || (args[0] != "print" && args[0] != "env") {
+		return fmt.Errorf("usage: searchengine config print|env [-config FILE]")
+	}
+	flags, settings, err := newFlagSet("config "+args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	if err := parseFlags(flags, settings, args[1:]); err != nil {
+		return err
+	}
+	if args[0] == "env" {
+		fmt.Println(strings.Join(config.EnvNames(), "\n"))
+		return nil
+	}
+	fmt.Print(settings)
+	return nil
+}
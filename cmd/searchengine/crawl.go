@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/davi1972/comp4321-search-engine/config"
+	Crawler "github.com/davi1972/comp4321-search-engine/crawler"
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+func crawlCommand(args []string) error {
+	flags, settings, err := newFlagSet("crawl", args)
+	if err != nil {
+		return err
+	}
+	flags.StringVar(&settings.Crawl.Root, "root", settings.Crawl.Root, "URL to start crawling from")
+	flags.IntVar(&settings.Crawl.MaxDepth, "depth", settings.Crawl.MaxDepth, "links followed from the root page")
+	flags.StringVar(&settings.Crawl.Base, "base", settings.Crawl.Base, "URL the files given by -dir are served from")
+	flags.IntVar(&settings.Crawl.Fetchers, "fetchers", settings.Crawl.Fetchers, "pages fetched at once")
+	flags.IntVar(&settings.Crawl.LinkCheckers, "link-checkers", settings.Crawl.LinkCheckers, "links of the pages checked at once")
+	flags.IntVar(&settings.Crawl.Workers, "workers", settings.Crawl.Workers, "pages analysed at once, 0 for one per CPU")
+	flags.IntVar(&settings.Crawl.BatchSize, "batch", settings.Crawl.BatchSize, "pages whose postings are written together")
+	dirFlag := flags.String("dir", "", "crawl the HTML files in this directory instead of the web")
+	sitemapFlag := flags.String("sitemap", "", "crawl the local pages listed in this sitemap.xml instead of the web")
+	warcFlag := flags.String("warc", "", "crawl the responses archived in this WARC or WARC.gz file instead of the web")
+	analyzersFlag := flags.String("analyzers", "", "analyzer of each field, e.g. title=simple,url=url, unlisted fields keep their configured one")
+	scopeFlag := flags.String("scope", "", "JSON file with the crawl scope rules, replacing the configured scope")
+	pageRankFlags(flags, settings)
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+	if err := settings.Analyzers.Set(*analyzersFlag); err != nil {
+		return err
+	}
+	if *scopeFlag != "" {
+		if settings.Crawl.Scope, err = Crawler.LoadScope(*scopeFlag); err != nil {
+			return err
+		}
+	}
+
+	options, err := settings.CrawlOptions()
+	if err != nil {
+		return err
+	}
+	options.Corpus, err = Crawler.LoadCorpus(*dirFlag, *sitemapFlag, *warcFlag, settings.Crawl.Base)
+	if err != nil {
+		return err
+	}
+	if options.Corpus != nil && !flagSet(flags, "root") {
+		options.Root = options.Corpus.Root()
+	}
+
+	indexes, err := Indexer.Open(settings.Index)
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+	_, err = Crawler.Crawl(indexes, options)
+	return err
+}
+
+// pageRankFlags adds the flags of the PageRank computation
+func pageRankFlags(flags *flag.FlagSet, settings *config.Config) {
+	options := &settings.Ranking.PageRank
+	flags.Float64Var(&options.Damping, "damping", options.Damping, "PageRank damping factor")
+	flags.Float64Var(&options.Tolerance, "tolerance", options.Tolerance, "PageRank stops when the ranks change by less than this")
+	flags.IntVar(&options.MaxIterations, "iterations", options.MaxIterations, "maximum PageRank iterations")
+	flags.StringVar(&settings.Ranking.Topics, "topics", settings.Ranking.Topics, "JSON file with the topics to compute topic sensitive PageRank for")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// crawlsCommand lists the recorded crawl runs, or shows one run with its failures
+func crawlsCommand(args []string) error {
+	flags, settings, err := newFlagSet("crawls", args)
+	if err != nil {
+		return err
+	}
+	id := flags.Uint64("id", 0, "show the crawl run with this ID, 0 lists all runs")
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+
+	indexes, err := Indexer.Open(settings.Index)
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+
+	if *id == 0 {
+		reports, err := indexes.CrawlReportIndexer.All()
+		if err != nil {
+			return err
+		}
+		fmt.Println("ID\tStart\tDuration\tFetched\tIndexed\tUnchanged\tSkipped\tFailed")
+		for _, report := range reports {
+			fmt.Printf("%d\t%s\t%s\t%d\t%d\t%d\t%d\t%d\n", report.ID, report.Start.Format(time.RFC1123),
+				duration(report), report.Fetched, report.Indexed, report.Unchanged, report.Skipped, len(report.Failures))
+		}
+		return nil
+	}
+
+	report, err := indexes.CrawlReportIndexer.GetReportFromKey(*id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Crawl run %d from %s\n", report.ID, report.Root)
+	fmt.Printf("Started %s, took %s\n", report.Start.Format(time.RFC1123), duration(report))
+	fmt.Printf("%d fetched, %d indexed, %d unchanged, %d skipped\n", report.Fetched, report.Indexed, report.Unchanged, report.Skipped)
+	fmt.Printf("%d failures:\n", len(report.Failures))
+	for _, failure := range report.Failures {
+		status := fmt.Sprint(failure.StatusCode)
+		if failure.Error != "" {
+			status += " " + failure.Error
+		}
+		fmt.Printf("%s (%s)\n", failure.URL, status)
+		if failure.Referrer != "" {
+			fmt.Printf("    linked from %s\n", failure.Referrer)
+		}
+		for i, hop := range failure.RedirectChain {
+			fmt.Printf("    redirect %d: %s\n", i, hop)
+		}
+	}
+	return nil
+}
+
+func duration(report Indexer.CrawlReport) string {
+	if !report.Finished {
+		return "unfinished"
+	}
+	return report.End.Sub(report.Start).Round(time.Second).String()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// dumpCommand writes every indexed page with its keywords and links, like
+// spider_result.txt, or prints the raw contents of the database given by -db
+func dumpCommand(args []string) error {
+	flags, settings, err := newFlagSet("dump", args)
+	if err != nil {
+		return err
+	}
+	output := flags.String("o", "", "file to write the pages to, standard output if empty")
+	database := flags.String("db", "", "print this database instead, e.g. documentIndex")
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+
+	indexes, err := Indexer.Open(settings.Index)
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+
+	if *database != "" {
+		return dumpDatabase(indexes, *database)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	w := bufio.NewWriter(out)
+	count, err := dumpPages(indexes, w)
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Printf("Wrote %d urls.\n", count)
+	}
+	return nil
+}
+
+func dumpPages(indexes *Indexer.Indexes, w io.Writer) (int, error) {
+	pages, err := indexes.PagePropertiesIndexer.All()
+	if err != nil {
+		return 0, err
+	}
+	for _, page := range pages {
+		output := strconv.FormatUint(page.GetId(), 10) + "\n"
+		output += page.GetTitle() + "\n"
+		output += page.GetUrl() + "\n"
+		output += page.GetDateString() + ", " + strconv.Itoa(page.GetSize()) + " B\n"
+
+		termFreq, _ := indexes.DocumentWordForwardIndexer.GetWordFrequencyListFromKey(page.GetId())
+		for _, tf := range termFreq {
+			word, _ := indexes.ReverseWordIndexer.GetValueFromKey(tf.GetID())
+			output += word + " " + strconv.FormatUint(tf.GetFrequency(), 10) + ", "
+		}
+		output += "\nChildren:\n"
+
+		children, _ := indexes.ParentChildDocumentForwardIndexer.GetIdListFromKey(page.GetId())
+		for _, child := range children {
+			childURL, _ := indexes.ReverseDocumentIndexer.GetValueFromKey(child)
+			output += childURL + "\n"
+		}
+		output += "------------------------------------------------------------------------\n"
+		if _, err := io.WriteString(w, output); err != nil {
+			return 0, err
+		}
+	}
+	return len(pages), nil
+}
+
+func dumpDatabase(indexes *Indexer.Indexes, name string) error {
+	for _, database := range indexes.Databases() {
+		if database.Name != name {
+			continue
+		}
+		switch db := database.Database.(type) {
+		case interface{ Iterate() error }:
+			return db.Iterate()
+		case interface{ Iterate() }:
+			db.Iterate()
+			return nil
+		case *Indexer.CrawlReportIndexer:
+			reports, err := db.All()
+			if err != nil {
+				return err
+			}
+			for _, report := range reports {
+				fmt.Printf("key=%d, value=%+v\n", report.ID, report)
+			}
+			return nil
+		}
+		return fmt.Errorf("%s cannot be printed", name)
+	}
+	return fmt.Errorf("unknown database %s", name)
+}
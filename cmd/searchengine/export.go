@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+	"github.com/davi1972/comp4321-search-engine/linkGraph"
+)
+
+// indexFormat exports the whole index as JSON Lines instead of the link graph
+const indexFormat = "index"
+
+// exportCommand exports the crawled link graph, or the part of it selected by
+// the flags, or with -format index the whole index for import
+func exportCommand(args []string) error {
+	flags, settings, err := newFlagSet("export", args)
+	if err != nil {
+		return err
+	}
+	format := flags.String("format", linkGraph.FormatGraphML, "output format: "+strings.Join(linkGraph.Formats, ", ")+", or "+indexFormat+" for the whole index as JSON Lines")
+	output := flags.String("o", "", "file to write to, standard output if empty")
+	hosts := flags.String("host", "", "only pages on these comma separated hosts")
+	minPageRank := flags.Float64("min-pagerank", 0, "only pages with at least this PageRank")
+	roots := flags.String("root", "", "only pages reachable from these comma separated URLs or page IDs")
+	depth := flags.Int("depth", 2, "clicks to follow from the root pages")
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+
+	indexes, err := Indexer.Open(settings.Index)
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	if *format == indexFormat {
+		for _, name := range []string{"host", "min-pagerank", "root", "depth"} {
+			if flagSet(flags, name) {
+				return fmt.Errorf("-%s only applies to link graph exports", name)
+			}
+		}
+		summary, err := indexes.Export(out)
+		if err != nil {
+			return err
+		}
+		if *output != "" {
+			fmt.Printf("Exported %d documents, %d words and %d crawl runs to %s\n", summary.Documents, summary.Words, summary.Crawls, *output)
+		}
+		return nil
+	}
+
+	filter := linkGraph.Filter{MinPageRank: *minPageRank, Depth: *depth}
+	if *hosts != "" {
+		filter.Hosts = strings.Split(*hosts, ",")
+	}
+	if *roots != "" {
+		for _, root := range strings.Split(*roots, ",") {
+			id, err := linkGraph.PageID(indexes.DocumentIndexer, root)
+			if err != nil {
+				return err
+			}
+			filter.Roots = append(filter.Roots, id)
+		}
+	}
+
+	graph, err := linkGraph.Load(indexes.DocumentIndexer, indexes.ParentChildDocumentForwardIndexer)
+	if err != nil {
+		return err
+	}
+	export := linkGraph.Build(graph, linkGraph.NodeInfo(indexes.PagePropertiesIndexer, indexes.ReverseDocumentIndexer, indexes.PageRankIndexer), filter)
+
+	if err := export.Write(out, *format); err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Printf("Exported %d pages and %d links to %s\n", len(export.Nodes), len(export.Edges), *output)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// importCommand rebuilds an index from a dump written by export -format index
+func importCommand(args []string) error {
+	flags, settings, err := newFlagSet("import", args)
+	if err != nil {
+		return err
+	}
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: searchengine import [-index DIR] DUMP")
+	}
+
+	in, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	summary, err := Indexer.Import(in, settings.Index)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d documents, %d words and %d crawl runs into %s\n", summary.Documents, summary.Words, summary.Crawls, settings.Index)
+	return nil
+}
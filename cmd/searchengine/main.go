@@ -0,0 +1,115 @@
+// Command searchengine crawls, indexes and serves the search engine.
+//
+//	searchengine <command> [flags]
+//
+// Every command takes -config, the settings file (default searchengine.yaml if
+// it exists), and -index, the directory of the indexes. Flags override the
+// settings of the file and of the environment.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/davi1972/comp4321-search-engine/config"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"crawl":    {"crawl the web or local documents into the index", crawlCommand},
+	"serve":    {"serve the search API", serveCommand},
+	"search":   {"search the index from the command line", searchCommand},
+	"dump":     {"print the indexed pages, or the contents of one database", dumpCommand},
+	"stats":    {"print the size of the index", statsCommand},
+	"config":   {"print the effective settings", configCommand},
+	"pagerank": {"compute PageRank and HITS again without crawling", pageRankCommand},
+	"export":   {"export the link graph, or the whole index as JSON Lines", exportCommand},
+	"import":   {"rebuild an index from a JSON Lines export", importCommand},
+	"verify":   {"check the indexes are consistent with each other", verifyCommand},
+	"crawls":   {"list the crawl runs, or show one", crawlsCommand},
+	"backup":   {"back up the index into one archive", backupCommand},
+	"restore":  {"restore a backup into a new index directory", restoreCommand},
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]].run == nil {
+		usage()
+		os.Exit(2)
+	}
+	if err := commands[os.Args[1]].run(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: searchengine <command> [flags]\n\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun searchengine <command> -h for the flags of a command.")
+}
+
+// newFlagSet makes the flag set of a command with the -config and -index
+// flags every command shares. The settings are loaded before the flags are
+// defined, so flags bound to them default to their values.
+func newFlagSet(name string, args []string) (*flag.FlagSet, *config.Config, error) {
+	settings, err := config.Load(configPath(args))
+	if err != nil {
+		return nil, nil, err
+	}
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.String("config", "", "YAML settings file, "+config.DefaultPath+" if it exists")
+	flags.StringVar(&settings.Index, "index", settings.Index, "directory of the indexes")
+	return flags, settings, nil
+}
+
+// parseFlags parses the flags of a command and validates the settings they changed
+func parseFlags(flags *flag.FlagSet, settings *config.Config, args []string) error {
+	flags.Parse(args)
+	return settings.Validate()
+}
+
+// configPath finds the -config flag before the flags are parsed, falling
+// back to the SEARCHENGINE_CONFIG environment variable
+func configPath(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if !strings.HasPrefix(arg, "-") || !strings.HasPrefix(name, "config") {
+			continue
+		}
+		if value := strings.TrimPrefix(name, "config="); value != name {
+			return value
+		}
+		if name == "config" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return os.Getenv(config.EnvPrefix + "_CONFIG")
+}
+
+// flagSet reports whether a flag was given on the command line
+func flagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
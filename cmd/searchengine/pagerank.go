@@ -0,0 +1,31 @@
+package main
+
+import (
+	Crawler "github.com/davi1972/comp4321-search-engine/crawler"
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// pageRankCommand computes the link scores of the crawled pages again, e.g.
+// with another damping factor or new topics
+func pageRankCommand(args []string) error {
+	flags, settings, err := newFlagSet("pagerank", args)
+	if err != nil {
+		return err
+	}
+	pageRankFlags(flags, settings)
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+
+	topics, err := settings.LoadTopics()
+	if err != nil {
+		return err
+	}
+	indexes, err := Indexer.Open(settings.Index)
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+	Crawler.Rank(indexes, settings.Ranking.PageRank, topics)
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+	"github.com/davi1972/comp4321-search-engine/server"
+)
+
+// searchCommand searches for the query given as arguments, or for every line
+// read from the standard input if there is none
+func searchCommand(args []string) error {
+	flags, settings, err := newFlagSet("search", args)
+	if err != nil {
+		return err
+	}
+	flags.StringVar(&settings.Server.Synonyms, "synonyms", settings.Server.Synonyms, "file with the synonym rules applied to queries")
+	langFlag := flags.String("lang", "", "language of the query, detected if empty")
+	signalFlag := flags.String("signal", "", "link signal: pagerank, hub, authority, query_hub or query_authority")
+	topicFlag := flags.String("topic", "", "rank with the PageRank of this topic")
+	expandFlag := flags.Bool("expand", false, "expand the query with pseudo relevance feedback")
+	resultsFlag := flags.Int("n", 10, "number of results shown")
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+
+	indexes, err := Indexer.Open(settings.Index)
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+	s := server.New(indexes, settings)
+	options := server.SearchOptions{Language: *langFlag, Signal: *signalFlag, Topic: *topicFlag, Expand: *expandFlag}
+
+	search := func(query string) error {
+		resp, err := s.Search(query, options)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d results for %s\n", len(resp.List), query)
+		for i, doc := range resp.List {
+			if i == *resultsFlag {
+				break
+			}
+			fmt.Printf("%2d. %.4f %s\n    %s\n", i+1, doc.Score, doc.Title, doc.URL)
+		}
+		if len(resp.ExpandedTerms) > 0 {
+			terms := make([]string, 0, len(resp.ExpandedTerms))
+			for _, term := range resp.ExpandedTerms {
+				terms = append(terms, term.Term)
+			}
+			fmt.Printf("Also searched for: %s\n", strings.Join(terms, ", "))
+		}
+		return nil
+	}
+
+	if flags.NArg() > 0 {
+		return search(strings.Join(flags.Args(), " "))
+	}
+	fmt.Println("Search: (enter keywords and press enter)")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if err := search(scanner.Text()); err != nil {
+			fmt.Println(err)
+		}
+		fmt.Println("\nSearch: (enter keywords and press enter)")
+	}
+	return scanner.Err()
+}
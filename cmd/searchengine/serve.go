@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+	"github.com/davi1972/comp4321-search-engine/server"
+)
+
+func serveCommand(args []string) error {
+	flags, settings, err := newFlagSet("serve", args)
+	if err != nil {
+		return err
+	}
+	flags.StringVar(&settings.Server.Addr, "addr", settings.Server.Addr, "address to listen on")
+	flags.StringVar(&settings.Server.Synonyms, "synonyms", settings.Server.Synonyms, "file with the synonym rules applied to queries, reloaded on SIGHUP")
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+
+	indexes, err := Indexer.Open(settings.Index)
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+
+	// Close the databases cleanly when interrupted
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		indexes.Close()
+		os.Exit(1)
+	}()
+
+	s := server.New(indexes, settings)
+	fmt.Printf("Serving %s on %s\n", settings.Index, settings.Server.Addr)
+	return s.ListenAndServe(settings.Server.Addr)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// statsCommand prints the size and the contents of the index
+func statsCommand(args []string) error {
+	flags, settings, err := newFlagSet("stats", args)
+	if err != nil {
+		return err
+	}
+	topFlag := flags.Int("top", 10, "most frequent words listed")
+	jsonFlag := flags.Bool("json", false, "print the stats as JSON, like /admin/stats")
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+
+	indexes, err := Indexer.Open(settings.Index)
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+
+	stats, err := indexes.Stats(*topFlag)
+	if err != nil {
+		return err
+	}
+	if *jsonFlag {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	fmt.Printf("Schema version:\t\t%d\n", stats.SchemaVersion)
+	fmt.Printf("Documents:\t\t%d\n", stats.Documents)
+	fmt.Printf("Indexed pages:\t\t%d\n", stats.IndexedPages)
+	fmt.Printf("Vocabulary:\t\t%d\n", stats.Vocabulary)
+	fmt.Printf("Postings:\t\t%d\n", stats.Postings)
+	fmt.Printf("Average length:\t\t%.1f words\n", stats.AverageDocumentLength)
+	fmt.Printf("Links:\t\t\t%d\n", stats.Links)
+	fmt.Printf("PageRank:\t\tmin %.6f, median %.6f, mean %.6f, max %.6f over %d pages\n",
+		stats.PageRank.Min, stats.PageRank.Median, stats.PageRank.Mean, stats.PageRank.Max, stats.PageRank.Pages)
+	fmt.Printf("Crawl runs:\t\t%d\n", stats.Crawls)
+	if stats.LastCrawl != nil {
+		fmt.Printf("Last crawl:\t\t%s\n", stats.LastCrawl.Format(time.RFC1123))
+	}
+
+	fmt.Println("\nMost frequent words:")
+	for _, term := range stats.TopTerms {
+		fmt.Printf("  %-20s %8d times in %d documents\n", term.Word, term.Frequency, term.Documents)
+	}
+	fmt.Printf("\nSize on disk:\t\t%s\n", formatBytes(stats.Size))
+	for _, database := range stats.Databases {
+		fmt.Printf("  %-32s %10s\n", database.Name, formatBytes(database.LSM+database.ValueLog))
+	}
+	return nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// verifyCommand prints the inconsistencies between the indexes by class,
+// failing if there are any. With -repair it fixes them and checks again.
+func verifyCommand(args []string) error {
+	flags, settings, err := newFlagSet("verify", args)
+	if err != nil {
+		return err
+	}
+	repairFlag := flags.Bool("repair", false, "rebuild the derived databases from the authoritative ones")
+	examplesFlag := flags.Int("examples", 5, "problems listed for each class")
+	if err := parseFlags(flags, settings, args); err != nil {
+		return err
+	}
+
+	indexes, err := Indexer.Open(settings.Index)
+	if err != nil {
+		return err
+	}
+	defer indexes.Close()
+
+	var problems []Indexer.Problem
+	if *repairFlag {
+		problems, err = indexes.Repair()
+	} else {
+		problems, err = indexes.Verify()
+	}
+	if err != nil {
+		return err
+	}
+	printProblems(problems, *examplesFlag)
+	if len(problems) == 0 {
+		fmt.Println("No problems found")
+		return nil
+	}
+	if !*repairFlag {
+		return fmt.Errorf("%d problems found, run verify -repair to fix them", len(problems))
+	}
+
+	remaining, err := indexes.Verify()
+	if err != nil {
+		return err
+	}
+	if len(remaining) > 0 {
+		fmt.Println("\nAfter repairing:")
+		printProblems(remaining, *examplesFlag)
+		return fmt.Errorf("%d problems repaired, %d remain", len(problems), len(remaining))
+	}
+	fmt.Printf("%d problems repaired\n", len(problems))
+	return nil
+}
+
+func printProblems(problems []Indexer.Problem, examples int) {
+	for _, class := range Indexer.Summarize(problems, examples) {
+		fmt.Printf("%s: %d\n", class.Class, class.Count)
+		for _, problem := range class.Examples {
+			fmt.Printf("  %s\n", problem)
+		}
+	}
+}
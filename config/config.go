@@ -0,0 +1,425 @@
+// Package config holds the settings of the crawler, the ranking and the
+// server. They are read from a YAML file (see searchengine.example.yaml) and
+// can be overridden by environment variables named after their YAML keys,
+// e.g. SEARCHENGINE_RANKING_LINK_WEIGHT=0.5 or SEARCHENGINE_SERVER_ADDR=:80.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	Crawler "github.com/davi1972/comp4321-search-engine/crawler"
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+	"github.com/davi1972/comp4321-search-engine/pageRank"
+	"github.com/davi1972/comp4321-search-engine/tokenizer"
+)
+
+// DefaultPath is the configuration file read when none is given, if it exists
+const DefaultPath = "searchengine.yaml"
+
+// EnvPrefix starts the names of the environment variables overriding settings
+const EnvPrefix = "SEARCHENGINE"
+
+type Config struct {
+	// Directory of the indexes
+	Index     string                   `yaml:"index"`
+	Crawl     Crawl                    `yaml:"crawl"`
+	Analyzers tokenizer.FieldAnalyzers `yaml:"analyzers"`
+	Ranking   Ranking                  `yaml:"ranking"`
+	Server    Server                   `yaml:"server"`
+}
+
+type Crawl struct {
+	// URL to start crawling from
+	Root string `yaml:"root"`
+	// Links followed from the root page
+	MaxDepth int `yaml:"max_depth"`
+	// URL the files of an offline crawl of a directory are served from
+	Base string `yaml:"base"`
+	// Which URLs are crawled, only the host of the root page if empty
+	Scope *Crawler.Scope `yaml:"scope,omitempty"`
+	// Pages fetched at once
+	Fetchers int `yaml:"fetchers"`
+	// Links of the pages checked at once
+	LinkCheckers int `yaml:"link_checkers"`
+	// Pages analysed at once, 0 for one per CPU
+	Workers int `yaml:"workers"`
+	// Pages whose postings are written together
+	BatchSize int `yaml:"batch_size"`
+}
+
+type Ranking struct {
+	// Share of the link score in the score of a result, the rest is the text score
+	LinkWeight float64 `yaml:"link_weight"`
+	// Boosts of the fields and of results containing a quoted phrase
+	TitleBoost       float64 `yaml:"title_boost"`
+	HeadingBoost     float64 `yaml:"heading_boost"`
+	DescriptionBoost float64 `yaml:"description_boost"`
+	KeywordsBoost    float64 `yaml:"keywords_boost"`
+	AltBoost         float64 `yaml:"alt_boost"`
+	URLBoost         float64 `yaml:"url_boost"`
+	PhraseBoost      float64 `yaml:"phrase_boost"`
+	// Weight of all the terms of a synonym, relative to a query term
+	SynonymWeight float64 `yaml:"synonym_weight"`
+	// Share of the co-citation score in the similarity of pages
+	CocitationWeight float64          `yaml:"cocitation_weight"`
+	PageRank         pageRank.Options `yaml:"pagerank"`
+	// JSON file with the topics to compute topic sensitive PageRank for
+	Topics string `yaml:"topics"`
+}
+
+type Server struct {
+	// Address to listen on
+	Addr string `yaml:"addr"`
+	// File with the synonym rules applied to queries
+	Synonyms string `yaml:"synonyms"`
+	// Query results cached, 0 disables the cache
+	CacheSize int `yaml:"cache_size"`
+	// How long a result stays cached, 0 until evicted or the index changes
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// Memory of the caches of the index tables read by queries
+	IndexCache IndexCache `yaml:"index_cache"`
+	// Token the requests starting a crawl, ranking or repair must bear. If
+	// empty, only requests from the machine of the server may start them.
+	AdminToken string `yaml:"admin_token"`
+}
+
+// IndexCache are the megabytes cached of each hot table, 0 leaves it uncached
+type IndexCache struct {
+	// Posting lists of each field
+	PostingsMB int `yaml:"postings_mb"`
+	PagesMB    int `yaml:"pages_mb"`
+	// Word lists of the bodies, and of the titles
+	WordListsMB int `yaml:"word_lists_mb"`
+}
+
+// Budgets returns the cache budgets in bytes
+func (cache IndexCache) Budgets() Indexer.CacheBudgets {
+	return Indexer.CacheBudgets{
+		Postings:  int64(cache.PostingsMB) << 20,
+		Pages:     int64(cache.PagesMB) << 20,
+		WordLists: int64(cache.WordListsMB) << 20,
+	}
+}
+
+// CrawlOptions returns the options of a crawl of the web with these settings.
+// Each crawl gets its own copy of the scope, which counts the pages it admits.
+func (config *Config) CrawlOptions() (Crawler.Options, error) {
+	options := Crawler.Options{
+		Root:         config.Crawl.Root,
+		MaxDepth:     config.Crawl.MaxDepth,
+		Analyzers:    config.Analyzers,
+		PageRank:     config.Ranking.PageRank,
+		Fetchers:     config.Crawl.Fetchers,
+		LinkCheckers: config.Crawl.LinkCheckers,
+		Workers:      config.Crawl.Workers,
+		BatchSize:    config.Crawl.BatchSize,
+	}
+	if config.Crawl.Scope != nil {
+		scope, err := config.Crawl.Scope.Clone()
+		if err != nil {
+			return options, fmt.Errorf("Invalid config: crawl scope: %s", err)
+		}
+		options.Scope = scope
+	}
+	topics, err := config.LoadTopics()
+	if err != nil {
+		return options, err
+	}
+	options.Topics = topics
+	return options, nil
+}
+
+// LoadTopics reads the topics of the topics file, none if there is no file
+func (config *Config) LoadTopics() ([]pageRank.Topic, error) {
+	if config.Ranking.Topics == "" {
+		return nil, nil
+	}
+	return pageRank.LoadTopics(config.Ranking.Topics)
+}
+
+// Default returns the settings used when there is no configuration
+func Default() *Config {
+	return &Config{
+		Index: Indexer.DefaultDirectory,
+		Crawl: Crawl{
+			Root:         Crawler.DefaultOptions.Root,
+			MaxDepth:     Crawler.DefaultOptions.MaxDepth,
+			Base:         "https://apartemen.win/comp4321/",
+			Fetchers:     Crawler.DefaultOptions.Fetchers,
+			LinkCheckers: Crawler.DefaultOptions.LinkCheckers,
+			BatchSize:    Crawler.DefaultOptions.BatchSize,
+		},
+		Analyzers: tokenizer.DefaultFieldAnalyzers(),
+		Ranking: Ranking{
+			LinkWeight:       0.8,
+			TitleBoost:       1.5,
+			HeadingBoost:     1.3,
+			DescriptionBoost: 1.2,
+			KeywordsBoost:    1.2,
+			AltBoost:         0.8,
+			URLBoost:         1.0,
+			PhraseBoost:      1.5,
+			SynonymWeight:    0.5,
+			CocitationWeight: 0.3,
+			PageRank:         pageRank.DefaultOptions,
+		},
+		Server: Server{
+			Addr:      "localhost:8000",
+			Synonyms:  "synonyms.txt",
+			CacheSize: 1000,
+			CacheTTL:  10 * time.Minute,
+			IndexCache: IndexCache{
+				PostingsMB:  16,
+				PagesMB:     16,
+				WordListsMB: 16,
+			},
+		},
+	}
+}
+
+// Load reads the settings of a file over the defaults, then applies the
+// environment overrides and validates the result. An empty path reads
+// DefaultPath if it exists.
+func Load(path string) (*Config, error) {
+	config := Default()
+	// Strict unmarshalling rejects map keys that are already set
+	config.Analyzers = nil
+	if path == "" {
+		if _, err := os.Stat(DefaultPath); err == nil {
+			path = DefaultPath
+		}
+	}
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Error while loading config: %s", err)
+		}
+		if err := yaml.UnmarshalStrict(data, config); err != nil {
+			return nil, fmt.Errorf("Error while parsing config %s: %s", path, err)
+		}
+	}
+	// Fields left out of the file keep their default analyzer
+	analyzers := tokenizer.DefaultFieldAnalyzers()
+	for field, name := range config.Analyzers {
+		analyzers[field] = name
+	}
+	config.Analyzers = analyzers
+
+	if err := applyEnv(reflect.ValueOf(config).Elem(), EnvPrefix, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate checks the settings make sense together
+func (config *Config) Validate() error {
+	if config.Index == "" {
+		return fmt.Errorf("Invalid config: index directory is empty")
+	}
+	if u, err := url.Parse(config.Crawl.Root); err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("Invalid config: crawl root %q is not an absolute URL", config.Crawl.Root)
+	}
+	if config.Crawl.MaxDepth < 1 {
+		return fmt.Errorf("Invalid config: crawl max_depth must be at least 1, not %d", config.Crawl.MaxDepth)
+	}
+	if config.Crawl.Fetchers < 1 || config.Crawl.LinkCheckers < 1 || config.Crawl.Workers < 0 || config.Crawl.BatchSize < 1 {
+		return fmt.Errorf("Invalid config: crawl fetchers, link_checkers and batch_size must be at least 1 and workers not negative")
+	}
+	if config.Crawl.Scope != nil {
+		if err := config.Crawl.Scope.Compile(); err != nil {
+			return fmt.Errorf("Invalid config: crawl scope: %s", err)
+		}
+	}
+	if err := config.Analyzers.Validate(); err != nil {
+		return fmt.Errorf("Invalid config: analyzers: %s", err)
+	}
+
+	ranking := config.Ranking
+	for name, weight := range map[string]float64{
+		"link_weight":       ranking.LinkWeight,
+		"cocitation_weight": ranking.CocitationWeight,
+	} {
+		if weight < 0 || weight > 1 {
+			return fmt.Errorf("Invalid config: ranking %s must be between 0 and 1, not %g", name, weight)
+		}
+	}
+	for name, boost := range map[string]float64{
+		"title_boost":       ranking.TitleBoost,
+		"heading_boost":     ranking.HeadingBoost,
+		"description_boost": ranking.DescriptionBoost,
+		"keywords_boost":    ranking.KeywordsBoost,
+		"alt_boost":         ranking.AltBoost,
+		"url_boost":         ranking.URLBoost,
+		"phrase_boost":      ranking.PhraseBoost,
+		"synonym_weight":    ranking.SynonymWeight,
+	} {
+		if boost < 0 {
+			return fmt.Errorf("Invalid config: ranking %s must not be negative, not %g", name, boost)
+		}
+	}
+	if err := ranking.PageRank.Validate(); err != nil {
+		return fmt.Errorf("Invalid config: %s", err)
+	}
+
+	if _, _, err := net.SplitHostPort(config.Server.Addr); err != nil {
+		return fmt.Errorf("Invalid config: server addr %q: %s", config.Server.Addr, err)
+	}
+	if config.Server.CacheSize < 0 {
+		return fmt.Errorf("Invalid config: server cache_size must not be negative, not %d", config.Server.CacheSize)
+	}
+	if config.Server.CacheTTL < 0 {
+		return fmt.Errorf("Invalid config: server cache_ttl must not be negative, not %s", config.Server.CacheTTL)
+	}
+	for name, size := range map[string]int{
+		"postings_mb":   config.Server.IndexCache.PostingsMB,
+		"pages_mb":      config.Server.IndexCache.PagesMB,
+		"word_lists_mb": config.Server.IndexCache.WordListsMB,
+	} {
+		if size < 0 {
+			return fmt.Errorf("Invalid config: server index_cache %s must not be negative, not %d", name, size)
+		}
+	}
+	return nil
+}
+
+// String returns the settings as YAML
+func (config *Config) String() string {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// EnvNames lists the environment variables that override settings
+func EnvNames() []string {
+	names := make([]string, 0)
+	applyEnv(reflect.ValueOf(Default()).Elem(), EnvPrefix, func(name string) (string, bool) {
+		names = append(names, name)
+		return "", false
+	})
+	return names
+}
+
+// applyEnv sets every field that has an environment variable, named after the
+// YAML keys leading to it. Lists are comma separated and maps are lists of
+// key=value pairs. The crawl scope is only overridden if the config has one.
+func applyEnv(value reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		key := strings.Split(value.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if key == "" || !field.CanSet() {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(key)
+
+		switch field.Kind() {
+		case reflect.Struct:
+			if err := applyEnv(field, name, lookup); err != nil {
+				return err
+			}
+			continue
+		case reflect.Ptr:
+			if !field.IsNil() && field.Elem().Kind() == reflect.Struct {
+				if err := applyEnv(field.Elem(), name, lookup); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		env, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, env); err != nil {
+			return fmt.Errorf("Invalid environment variable %s=%q: %s", name, env, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, env string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(env)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(env)
+	case reflect.Int:
+		n, err := strconv.Atoi(env)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(env, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		list := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range splitList(env) {
+			element := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(element, item); err != nil {
+				return err
+			}
+			list = reflect.Append(list, element)
+		}
+		field.Set(list)
+	case reflect.Map:
+		// Entries are added to the map, replacing those with the same key
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		}
+		for _, pair := range splitList(env) {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("expected key=value, not %q", pair)
+			}
+			element := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(element, strings.TrimSpace(parts[1])); err != nil {
+				return err
+			}
+			field.SetMapIndex(reflect.ValueOf(strings.TrimSpace(parts[0])).Convert(field.Type().Key()), element)
+		}
+	default:
+		return fmt.Errorf("cannot be set from the environment")
+	}
+	return nil
+}
+
+func splitList(env string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(env, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
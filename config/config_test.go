@@ -0,0 +1,204 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	Crawler "github.com/davi1972/comp4321-search-engine/crawler"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "searchengine.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeConfig(t, `
+index: /var/lib/searchengine
+crawl:
+  root: https://www.ust.hk/
+  scope:
+    allowed_hosts: [ust.hk]
+    max_pages: 100
+analyzers:
+  title: simple
+ranking:
+  link_weight: 0.5
+  pagerank:
+    damping: 0.9
+`)
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Index != "/var/lib/searchengine" || config.Crawl.Root != "https://www.ust.hk/" || config.Crawl.Scope.MaxPages != 100 {
+		t.Errorf("file settings not loaded: %+v", config)
+	}
+	if config.Analyzers["title"] != "simple" || config.Analyzers["url"] != "url" {
+		t.Errorf("analyzers are %v", config.Analyzers)
+	}
+	// Settings left out of the file keep their default
+	if config.Ranking.LinkWeight != 0.5 || config.Ranking.PageRank.Damping != 0.9 || config.Ranking.PageRank.MaxIterations != 100 || config.Ranking.TitleBoost != 1.5 {
+		t.Errorf("ranking is %+v", config.Ranking)
+	}
+	if config.Crawl.MaxDepth != 2 || config.Server.Addr != "localhost:8000" {
+		t.Errorf("defaults lost: %+v", config)
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	path := writeConfig(t, "ranking:\n  link_weight: 0.5\n")
+	os.Setenv("SEARCHENGINE_RANKING_LINK_WEIGHT", "0.7")
+	os.Setenv("SEARCHENGINE_RANKING_PAGERANK_MAX_ITERATIONS", "20")
+	os.Setenv("SEARCHENGINE_SERVER_ADDR", ":9000")
+	os.Setenv("SEARCHENGINE_ANALYZERS", "body=simple")
+	os.Setenv("SEARCHENGINE_SERVER_CACHE_TTL", "90s")
+	defer func() {
+		for _, name := range []string{"SEARCHENGINE_RANKING_LINK_WEIGHT", "SEARCHENGINE_RANKING_PAGERANK_MAX_ITERATIONS", "SEARCHENGINE_SERVER_ADDR", "SEARCHENGINE_ANALYZERS", "SEARCHENGINE_SERVER_CACHE_TTL"} {
+			os.Unsetenv(name)
+		}
+	}()
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Ranking.LinkWeight != 0.7 || config.Ranking.PageRank.MaxIterations != 20 || config.Server.Addr != ":9000" || config.Server.CacheTTL != 90*time.Second {
+		t.Errorf("environment not applied: %+v", config)
+	}
+	if config.Analyzers["body"] != "simple" || config.Analyzers["title"] != "standard" {
+		t.Errorf("analyzers are %v", config.Analyzers)
+	}
+
+	os.Setenv("SEARCHENGINE_CRAWL_MAX_DEPTH", "two")
+	defer os.Unsetenv("SEARCHENGINE_CRAWL_MAX_DEPTH")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "SEARCHENGINE_CRAWL_MAX_DEPTH") {
+		t.Errorf("invalid environment variable gave %v", err)
+	}
+}
+
+// validate applies a change to the default config and validates it
+func validate(change func(config *Config)) error {
+	config := Default()
+	change(config)
+	return config.Validate()
+}
+
+func TestValidateRankingWeights(t *testing.T) {
+	// Blending weights are fractions, both ends included
+	for _, weight := range []float64{0, 1} {
+		if err := validate(func(c *Config) { c.Ranking.LinkWeight = weight }); err != nil {
+			t.Errorf("link_weight %g refused: %s", weight, err)
+		}
+	}
+	for _, weight := range []float64{-0.1, 1.5} {
+		if err := validate(func(c *Config) { c.Ranking.CocitationWeight = weight }); err == nil {
+			t.Errorf("cocitation_weight %g accepted", weight)
+		}
+	}
+	// A zero boost ignores a field, a negative one would rank matches last
+	if err := validate(func(c *Config) { c.Ranking.AltBoost = 0 }); err != nil {
+		t.Errorf("zero alt_boost refused: %s", err)
+	}
+	if err := validate(func(c *Config) { c.Ranking.AltBoost = -1 }); err == nil || !strings.Contains(err.Error(), "alt_boost") {
+		t.Errorf("negative alt_boost gave %v", err)
+	}
+	// A damping of 1 never teleports and does not converge on dangling pages
+	if err := validate(func(c *Config) { c.Ranking.PageRank.Damping = 1 }); err == nil {
+		t.Error("damping 1 accepted")
+	}
+}
+
+func TestValidateCrawl(t *testing.T) {
+	if err := validate(func(c *Config) { c.Crawl.Root = "/relative" }); err == nil || !strings.Contains(err.Error(), "/relative") {
+		t.Errorf("relative root gave %v", err)
+	}
+	if err := validate(func(c *Config) { c.Crawl.MaxDepth = 1 }); err != nil {
+		t.Errorf("depth 1 refused: %s", err)
+	}
+	if err := validate(func(c *Config) { c.Crawl.MaxDepth = 0 }); err == nil {
+		t.Error("depth 0 accepted")
+	}
+	// No workers means one per CPU, but a batch needs at least one page
+	if err := validate(func(c *Config) { c.Crawl.Workers = 0 }); err != nil {
+		t.Errorf("default workers refused: %s", err)
+	}
+	if err := validate(func(c *Config) { c.Crawl.BatchSize = 0 }); err == nil {
+		t.Error("empty batches accepted")
+	}
+	if err := validate(func(c *Config) { c.Crawl.LinkCheckers = 0 }); err == nil {
+		t.Error("crawl without link checkers accepted")
+	}
+	if err := validate(func(c *Config) { c.Crawl.Scope = &Crawler.Scope{Include: []string{"("}} }); err == nil {
+		t.Error("invalid scope pattern accepted")
+	}
+}
+
+func TestValidateAnalyzers(t *testing.T) {
+	if err := validate(func(c *Config) { c.Analyzers["title"] = "shouty" }); err == nil {
+		t.Error("unknown analyzer accepted")
+	}
+	if err := validate(func(c *Config) { c.Analyzers["title"] = "standard/1" }); err == nil {
+		t.Error("old analyzer version accepted")
+	}
+}
+
+func TestValidateServer(t *testing.T) {
+	if err := validate(func(c *Config) { c.Server.Addr = "localhost" }); err == nil {
+		t.Error("address without a port accepted")
+	}
+	// Zero disables a cache
+	err := validate(func(c *Config) {
+		c.Server.CacheSize = 0
+		c.Server.CacheTTL = 0
+		c.Server.IndexCache = IndexCache{}
+	})
+	if err != nil {
+		t.Errorf("disabled caches refused: %s", err)
+	}
+	if err := validate(func(c *Config) { c.Server.CacheTTL = -time.Minute }); err == nil {
+		t.Error("negative cache_ttl accepted")
+	}
+	if err := validate(func(c *Config) { c.Server.IndexCache.PagesMB = -1 }); err == nil || !strings.Contains(err.Error(), "pages_mb") {
+		t.Errorf("negative pages_mb gave %v", err)
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	// A misspelt key would otherwise silently keep its default
+	if _, err := Load(writeConfig(t, "ranking:\n  typo_boost: 2\n")); err == nil || !strings.Contains(err.Error(), "typo_boost") {
+		t.Errorf("unknown key gave %v", err)
+	}
+}
+
+func TestEnvNames(t *testing.T) {
+	names := strings.Join(EnvNames(), " ")
+	for _, name := range []string{"SEARCHENGINE_INDEX", "SEARCHENGINE_CRAWL_ROOT", "SEARCHENGINE_RANKING_PAGERANK_DAMPING", "SEARCHENGINE_SERVER_SYNONYMS"} {
+		if !strings.Contains(names, name) {
+			t.Errorf("%s missing from %s", name, names)
+		}
+	}
+}
+
+func TestExampleIsValid(t *testing.T) {
+	config, err := Load("../searchengine.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Crawl.Scope == nil || config.Crawl.Scope.MaxPages != 300 {
+		t.Errorf("example scope not loaded: %+v", config.Crawl.Scope)
+	}
+}
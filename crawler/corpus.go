@@ -0,0 +1,357 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Corpus is a set of documents stored locally, keyed by the URL they were
+// (or would be) served from. It implements http.RoundTripper, so a collector
+// or http.Client using it as transport crawls the local documents exactly as
+// it would crawl the live site.
+type Corpus struct {
+	documents map[string]*storedResponse
+	urls      []string
+}
+
+type storedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+func NewCorpus() *Corpus {
+	return &Corpus{documents: make(map[string]*storedResponse)}
+}
+
+// Add stores a response for the given URL. Later additions for the same URL are ignored.
+func (corpus *Corpus) Add(rawURL string, statusCode int, header http.Header, body []byte) {
+	key := normalizeURL(rawURL)
+	if _, ok := corpus.documents[key]; ok {
+		return
+	}
+	if header == nil {
+		header = http.Header{}
+	}
+	corpus.documents[key] = &storedResponse{statusCode, header, body}
+	corpus.urls = append(corpus.urls, key)
+}
+
+// URLs returns the stored URLs in the order they were added
+func (corpus *Corpus) URLs() []string {
+	return corpus.urls
+}
+
+func (corpus *Corpus) Len() int {
+	return len(corpus.documents)
+}
+
+// Root returns the URL a crawl of the corpus should start from
+func (corpus *Corpus) Root() string {
+	if len(corpus.urls) == 0 {
+		return ""
+	}
+	return corpus.urls[0]
+}
+
+// RoundTrip serves a request from the corpus, answering 404 for unknown URLs
+func (corpus *Corpus) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := &http.Response{
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Request:    req,
+		Header:     http.Header{},
+	}
+	stored, ok := corpus.documents[normalizeURL(req.URL.String())]
+	if !ok {
+		resp.StatusCode = http.StatusNotFound
+		resp.Status = "404 Not Found"
+		resp.Body = ioutil.NopCloser(bytes.NewReader(nil))
+		return resp, nil
+	}
+	resp.StatusCode = stored.statusCode
+	resp.Status = strconv.Itoa(stored.statusCode) + " " + http.StatusText(stored.statusCode)
+	for k, v := range stored.header {
+		resp.Header[k] = append([]string(nil), v...)
+	}
+	resp.ContentLength = int64(len(stored.body))
+	if req.Method == http.MethodHead {
+		resp.Body = ioutil.NopCloser(bytes.NewReader(nil))
+	} else {
+		resp.Body = ioutil.NopCloser(bytes.NewReader(stored.body))
+	}
+	return resp, nil
+}
+
+// Drop the fragment, which is never sent to a server
+func normalizeURL(rawURL string) string {
+	if i := strings.Index(rawURL, "#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	return rawURL
+}
+
+// fileResponse builds the response a static file server would give for a file
+func fileResponse(path string) (http.Header, []byte, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	header.Set("Last-Modified", info.ModTime().UTC().Format(http.TimeFormat))
+	return header, body, nil
+}
+
+// LoadDirectory adds every HTML file below dir to the corpus. A file at
+// dir/a/b.html is served from baseURL + "a/b.html", and index.html also
+// answers for its directory URL.
+func (corpus *Corpus) LoadDirectory(dir string, baseURL string) error {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	paths := make([]string, 0)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if !info.IsDir() && (ext == ".html" || ext == ".htm") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Error while loading directory: %s", err)
+	}
+	// Walk order is lexical already, sort anyway so the root is stable
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		header, body, err := fileResponse(path)
+		if err != nil {
+			return fmt.Errorf("Error while loading directory: %s", err)
+		}
+		corpus.Add(baseURL+rel, http.StatusOK, header, body)
+		if strings.ToLower(filepath.Base(rel)) == "index.html" {
+			corpus.Add(baseURL+strings.TrimSuffix(rel, filepath.Base(rel)), http.StatusOK, header, body)
+		}
+	}
+	return nil
+}
+
+type sitemapFile struct {
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// LoadSitemap adds the pages listed in a sitemap.xml (or sitemap index) to the
+// corpus. The page for a <loc> is read from the directory of the sitemap, at
+// the path of the URL relative to the sitemap's own location or to the site root.
+func (corpus *Corpus) LoadSitemap(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Error while loading sitemap: %s", err)
+	}
+	sitemap := sitemapFile{}
+	if err := xml.Unmarshal(data, &sitemap); err != nil {
+		return fmt.Errorf("Error while parsing sitemap %s: %s", path, err)
+	}
+	dir := filepath.Dir(path)
+
+	for _, loc := range sitemap.Sitemaps {
+		u, err := url.Parse(strings.TrimSpace(loc))
+		if err != nil {
+			continue
+		}
+		if err := corpus.LoadSitemap(filepath.Join(dir, filepath.Base(u.Path))); err != nil {
+			return err
+		}
+	}
+
+	for _, loc := range sitemap.URLs {
+		loc = strings.TrimSpace(loc)
+		u, err := url.Parse(loc)
+		if err != nil {
+			fmt.Println("Skipping sitemap entry " + loc + ": " + err.Error())
+			continue
+		}
+		filePath := sitemapFilePath(dir, u.Path)
+		if filePath == "" {
+			fmt.Println("Skipping sitemap entry " + loc + ": no local file")
+			continue
+		}
+		header, body, err := fileResponse(filePath)
+		if err != nil {
+			return fmt.Errorf("Error while loading sitemap: %s", err)
+		}
+		corpus.Add(loc, http.StatusOK, header, body)
+	}
+	return nil
+}
+
+// sitemapFilePath finds the local file for a URL path, trying ever shorter
+// suffixes of the path so sitemaps of sites hosted below the root also work
+func sitemapFilePath(dir string, urlPath string) string {
+	if urlPath == "" || strings.HasSuffix(urlPath, "/") {
+		urlPath += "index.html"
+	}
+	parts := strings.Split(strings.TrimPrefix(urlPath, "/"), "/")
+	for i := range parts {
+		candidate := filepath.Join(append([]string{dir}, parts[i:]...)...)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// LoadWARC adds every response record of a WARC file (optionally gzipped) to the corpus
+func (corpus *Corpus) LoadWARC(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Error while loading WARC: %s", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("Error while loading WARC: %s", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return corpus.ReadWARC(r)
+}
+
+// ReadWARC adds every response record read from r to the corpus
+func (corpus *Corpus) ReadWARC(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	for {
+		header, block, err := readWARCRecord(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Error while reading WARC: %s", err)
+		}
+		if header.Get("WARC-Type") != "response" {
+			continue
+		}
+		target := strings.Trim(header.Get("WARC-Target-URI"), "<>")
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), nil)
+		if err != nil {
+			fmt.Println("Skipping WARC record for " + target + ": " + err.Error())
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			fmt.Println("Skipping WARC record for " + target + ": " + err.Error())
+			continue
+		}
+		// The body is stored decoded, so the length must describe it
+		resp.Header.Del("Transfer-Encoding")
+		resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		corpus.Add(target, resp.StatusCode, resp.Header, body)
+	}
+}
+
+// readWARCRecord reads the header and content block of the next WARC record
+func readWARCRecord(reader *bufio.Reader) (http.Header, []byte, error) {
+	// Skip blank lines between records
+	var version string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(line) == "" {
+				return nil, nil, io.EOF
+			}
+			return nil, nil, err
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			version = line
+			break
+		}
+	}
+	if !strings.HasPrefix(version, "WARC/") {
+		return nil, nil, fmt.Errorf("invalid record version line %q", version)
+	}
+
+	header := http.Header{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, nil, fmt.Errorf("invalid record header line %q", line)
+		}
+		header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid record Content-Length: %s", err)
+	}
+	block := make([]byte, length)
+	if _, err := io.ReadFull(reader, block); err != nil {
+		return nil, nil, err
+	}
+	return header, block, nil
+}
+
+// LoadCorpus reads the local documents to crawl offline from a directory
+// served from baseURL, a sitemap and a WARC file, or returns nil for a web crawl
+func LoadCorpus(dir string, sitemap string, warc string, baseURL string) (*Corpus, error) {
+	if dir == "" && sitemap == "" && warc == "" {
+		return nil, nil
+	}
+	corpus := NewCorpus()
+	if dir != "" {
+		if err := corpus.LoadDirectory(dir, baseURL); err != nil {
+			return nil, err
+		}
+	}
+	if sitemap != "" {
+		if err := corpus.LoadSitemap(sitemap); err != nil {
+			return nil, err
+		}
+	}
+	if warc != "" {
+		if err := corpus.LoadWARC(warc); err != nil {
+			return nil, err
+		}
+	}
+	if corpus.Len() == 0 {
+		return nil, fmt.Errorf("no documents found to crawl offline")
+	}
+	return corpus, nil
+}
@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLoadDirectoryCorpus(t *testing.T) {
+	corpus := NewCorpus()
+	err := corpus.LoadDirectory("../pages", "https://apartemen.win/comp4321")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if corpus.Len() != 4 || corpus.Root() != "https://apartemen.win/comp4321/page1.html" {
+		t.Fatalf("unexpected corpus: %v", corpus.URLs())
+	}
+
+	client := &http.Client{Transport: corpus}
+	resp, err := client.Get("https://apartemen.win/comp4321/page2.html")
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("page2 not served: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "page1.html") || resp.Header.Get("Last-Modified") == "" {
+		t.Fail()
+	}
+
+	resp, err = client.Get("https://apartemen.win/comp4321/missing.html")
+	if err != nil || resp.StatusCode != 404 {
+		t.Fail()
+	}
+}
+
+func TestReadWARCCorpus(t *testing.T) {
+	httpResponse := "HTTP/1.1 200 OK\r\n" +
+		"Content-Type: text/html\r\n" +
+		"Last-Modified: Mon, 01 Apr 2019 10:00:00 GMT\r\n" +
+		"\r\n" +
+		"<html><title>Archived</title></html>"
+	warc := "WARC/1.0\r\n" +
+		"WARC-Type: warcinfo\r\n" +
+		"Content-Length: 4\r\n" +
+		"\r\n" +
+		"info\r\n\r\n" +
+		"WARC/1.0\r\n" +
+		"WARC-Type: response\r\n" +
+		"WARC-Target-URI: <https://example.com/archived.html>\r\n" +
+		"Content-Length: " + strconv.Itoa(len(httpResponse)) + "\r\n" +
+		"\r\n" +
+		httpResponse + "\r\n\r\n"
+
+	corpus := NewCorpus()
+	if err := corpus.ReadWARC(strings.NewReader(warc)); err != nil {
+		t.Fatal(err)
+	}
+	if corpus.Len() != 1 || corpus.Root() != "https://example.com/archived.html" {
+		t.Fatalf("unexpected corpus: %v", corpus.URLs())
+	}
+
+	resp, _ := (&http.Client{Transport: corpus}).Get("https://example.com/archived.html")
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != "<html><title>Archived</title></html>" || resp.Header.Get("Last-Modified") != "Mon, 01 Apr 2019 10:00:00 GMT" {
+		t.Fail()
+	}
+}
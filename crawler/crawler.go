@@ -1,4 +1,423 @@
 package crawler
 
-type crawler struct {
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly"
+	"github.com/gocolly/colly/debug"
+
+	"github.com/davi1972/comp4321-search-engine/concurrentMap"
+	"github.com/davi1972/comp4321-search-engine/hits"
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+	"github.com/davi1972/comp4321-search-engine/pageRank"
+	"github.com/davi1972/comp4321-search-engine/tokenizer"
+)
+
+// Options of a crawl
+type Options struct {
+	// URL to start crawling from
+	Root string
+	// Links followed from the root page
+	MaxDepth int
+	// Local documents to crawl instead of the web, if not nil
+	Corpus *Corpus
+	// Which URLs are crawled, only the host of the root page if nil
+	Scope *Scope
+	// Analyzer of each field
+	Analyzers tokenizer.FieldAnalyzers
+	// PageRank computed after the crawl, and the topics to compute it for
+	PageRank pageRank.Options
+	Topics   []pageRank.Topic
+	// Pages fetched at once, links checked at once, pages analysed at once,
+	// and pages written per batch
+	Fetchers     int
+	LinkCheckers int
+	Workers      int
+	BatchSize    int
+}
+
+// DefaultOptions crawls the department site
+var DefaultOptions = Options{
+	Root:         "https://www.cse.ust.hk",
+	MaxDepth:     2,
+	Analyzers:    tokenizer.DefaultFieldAnalyzers(),
+	PageRank:     pageRank.DefaultOptions,
+	Fetchers:     2,
+	LinkCheckers: 8,
+	Workers:      runtime.NumCPU(),
+	BatchSize:    100,
+}
+
+type pageMap struct {
+	id       uint64
+	children concurrentMap.ConcurrentMap
+}
+
+// linkCheck is a link found on a page, checked before it is followed
+type linkCheck struct {
+	url string
+	// Page the link is on, its children found so far, and its links left to check
+	page    *colly.HTMLElement
+	pageMap *pageMap
+	done    *sync.WaitGroup
+}
+
+// Crawl fetches the pages reachable from the root page, indexes them and
+// computes the link scores. The run is recorded in the crawl reports.
+//
+// Pages go through a pipeline: the fetchers of the collector parse them, a
+// pool of checkers checks their links, a pool of workers analyses them, and
+// one writer collects their postings and writes them in batches. The crawl
+// ends with an error if a batch could not be written.
+func Crawl(indexes *Indexer.Indexes, options Options) (*Indexer.CrawlReport, error) {
+	// The links between pages are only complete at the end, a backup taken
+	// during the crawl would not agree with itself
+	indexes.BeginWrite()
+	defer indexes.EndWrite()
+
+	rootPage := options.Root
+	scope := options.Scope
+	if scope == nil {
+		scope = DefaultScope(rootPage)
+	}
+	corpus := options.Corpus
+
+	if options.Fetchers < 1 {
+		options.Fetchers = DefaultOptions.Fetchers
+	}
+	if options.LinkCheckers < 1 {
+		options.LinkCheckers = DefaultOptions.LinkCheckers
+	}
+	if options.Workers < 1 {
+		options.Workers = DefaultOptions.Workers
+	}
+	if options.BatchSize < 1 {
+		options.BatchSize = DefaultOptions.BatchSize
+	}
+
+	documents := newIDAssigner(indexes.DocumentIndexer, indexes.ReverseDocumentIndexer)
+	words := newIDAssigner(indexes.WordIndexer, indexes.ReverseWordIndexer)
+	pagePropertiesIndexer := indexes.PagePropertiesIndexer
+
+	// The analyzers are recorded so queries are analysed the same way
+	if err := indexes.MetadataIndexer.RecordFieldAnalyzers(options.Analyzers.IDs()); err != nil {
+		return nil, fmt.Errorf("Cannot crawl into this index: %s", err)
+	}
+	analyzers := make(map[string]*tokenizer.Analyzer)
+	for field := range options.Analyzers {
+		analyzers[field] = options.Analyzers.Analyzer(field)
+	}
+
+	// Record the run so its progress and failures can be looked at later
+	report, reportErr := indexes.CrawlReportIndexer.NewReport(rootPage)
+	if reportErr != nil {
+		fmt.Println(reportErr)
+		report = &Indexer.CrawlReport{Root: rootPage, Start: time.Now()}
+	}
+	recorder := NewRecorder(report)
+	fmt.Printf("Starting crawl run %d\n", report.ID)
+
+	// Make a map of pages to store get pages, appended to by the fetchers
+	pages := make([]*pageMap, 0)
+	var pagesMutex sync.Mutex
+	collector := colly.NewCollector(
+		colly.MaxDepth(options.MaxDepth),
+		colly.Debugger(&debug.LogDebugger{}),
+		colly.Async(true),
+	)
+
+	// Limit the maximum parallelism to the number of fetchers
+	// This is necessary if the goroutines are dynamically
+	// created to control the limit of simultaneous requests.
+	collector.Limit(&colly.LimitRule{DomainGlob: "*", Parallelism: options.Fetchers})
+
+	// Stop downloading just past the limit, the page is rejected when parsed
+	if scope.MaxDocumentSize > 0 {
+		collector.MaxBodySize = scope.MaxDocumentSize + 1
+	}
+
+	// Offline crawls serve every request, link checks included, from the
+	// local corpus so the indexes match those of a crawl of the live site
+	linkChecker := &http.Client{CheckRedirect: recorder.CheckRedirect}
+	collector.SetRedirectHandler(recorder.CheckRedirect)
+	if corpus != nil {
+		collector.WithTransport(corpus)
+		linkChecker.Transport = corpus
+		fmt.Printf("Crawling %d local documents starting from %s\n", corpus.Len(), rootPage)
+	}
+
+	collector.OnResponse(func(r *colly.Response) {
+		recorder.Fetched()
+		fmt.Println("Visited", r.Request.URL)
+		fmt.Println("")
+	})
+
+	// Modified pages are analysed by the workers, then batched by the writer
+	parsed := make(chan parsedPage, 2*options.Workers)
+	analysed := make(chan *analysedPage, 2*options.Workers)
+	analysers := &sync.WaitGroup{}
+	for i := 0; i < options.Workers; i++ {
+		analysers.Add(1)
+		go func() {
+			defer analysers.Done()
+			for page := range parsed {
+				result, err := analyse(page, analyzers, words)
+				if err != nil {
+					fmt.Println("Failed to analyse", page.page.GetUrl(), err)
+					recorder.Failed(page.page.GetUrl(), "", 0, err)
+					continue
+				}
+				analysed <- result
+			}
+		}()
+	}
+	var writeErr error
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		writeErr = writeBatches(analysed, newIndexBatch(indexes), options.BatchSize, recorder)
+	}()
+
+	// Links are checked by a pool of checkers, each followed if it is in the
+	// scope and answers 200
+	checks := make(chan linkCheck)
+	checkers := &sync.WaitGroup{}
+	for i := 0; i < options.LinkCheckers; i++ {
+		checkers.Add(1)
+		go func() {
+			defer checkers.Done()
+			for check := range checks {
+				checkLink(check, scope, linkChecker, documents, recorder)
+				check.done.Done()
+			}
+		}()
+	}
+
+	collector.OnHTML("html", func(e *colly.HTMLElement) {
+
+		doc := ExtractDocument(e)
+		title := doc.Title
+		url := e.Request.URL.String()
+
+		size, _ := strconv.Atoi(e.Response.Headers.Get("Content-Length"))
+
+		if size == 0 {
+			size = len(e.Text)
+		}
+
+		// Content-Length can be missing, so also check what was downloaded
+		checkedSize := size
+		if len(e.Response.Body) > checkedSize {
+			checkedSize = len(e.Response.Body)
+		}
+		if rule, ok := scope.CheckSize(checkedSize); !ok {
+			fmt.Println("Rejected", url, "by rule", rule)
+			recorder.Skipped(url)
+			return
+		}
+
+		date := e.Response.Headers.Get("Last-Modified")
+		dateTime := time.Time{}
+		if len(date) != 0 {
+			dateTime, _ = time.Parse(time.RFC1123, date)
+		} else {
+			dateTime = time.Now()
+		}
+
+		// Store Document id and properties
+		id, err := documents.ID(url)
+		if err != nil {
+			fmt.Println(err)
+			recorder.Failed(url, "", 0, err)
+			return
+		}
+
+		// Compare DateTime to determine wether we should reindex
+		p, _ := pagePropertiesIndexer.GetPagePropertiesFromKey(id)
+		if !p.GetDate().Equal(dateTime) {
+
+			page := Indexer.CreatePage(id, title, url, size, dateTime)
+			page.SetDescription(doc.Description)
+			// Analyse the page with the stopwords and stemmer of its language
+			language := tokenizer.ResolveLanguage(doc.Language, title+" "+doc.Body)
+			page.SetLanguage(language)
+			parsed <- parsedPage{page, language, []fieldText{
+				{tokenizer.FieldTitle, title, indexes.TitleInvertedIndexer},
+				{tokenizer.FieldBody, doc.Body, indexes.ContentInvertedIndexer},
+				{tokenizer.FieldURL, url, indexes.URLInvertedIndexer},
+				{tokenizer.FieldHeading, doc.Headings, indexes.HeadingInvertedIndexer},
+				{tokenizer.FieldDescription, doc.Description, indexes.DescriptionInvertedIndexer},
+				{tokenizer.FieldKeywords, doc.Keywords, indexes.KeywordsInvertedIndexer},
+				{tokenizer.FieldAlt, doc.Alt, indexes.AltInvertedIndexer},
+			}}
+
+		} else {
+			fmt.Println("Skipping page: " + url + " as it has not been modified")
+			recorder.Unchanged()
+		}
+
+		tempMap := &pageMap{}
+		tempMap.id = id
+		tempMap.children = concurrentMap.ConcurrentMap{}
+		tempMap.children.Init()
+		// Only wait for the links of this page
+		links := &sync.WaitGroup{}
+		for _, url := range e.ChildAttrs("a[href]", "href") {
+			links.Add(1)
+			checks <- linkCheck{e.Request.AbsoluteURL(url), e, tempMap, links}
+		}
+		links.Wait()
+		pagesMutex.Lock()
+		pages = append(pages, tempMap)
+		pagesMutex.Unlock()
+	})
+
+	collector.OnError(func(r *colly.Response, err error) {
+		fmt.Println("Failed", r.Request.URL, err)
+		recorder.Failed(r.Request.URL.String(), "", r.StatusCode, err)
+	})
+
+	collector.OnRequest(func(r *colly.Request) {
+		if rule, ok := scope.Admit(r.URL.String()); !ok {
+			fmt.Println("Rejected", r.URL, "by rule", rule)
+			recorder.Skipped(r.URL.String())
+			r.Abort()
+			return
+		}
+		fmt.Println("Visiting", r.URL)
+	})
+
+	collector.Visit(rootPage)
+
+	collector.Wait()
+	close(checks)
+	checkers.Wait()
+
+	// Let the workers and the writer finish the pages still in the pipeline
+	close(parsed)
+	analysers.Wait()
+	close(analysed)
+	<-written
+
+	fmt.Println("Finished crawling, writing the links..")
+
+	// The links of every page are written once the crawl is done, parent to
+	// children and child to parents
+	linkErr := writeLinks(indexes, pages)
+	if linkErr != nil {
+		fmt.Println(linkErr)
+	}
+
+	// After everything is done, compute pagerank
+	rank(indexes, options.PageRank, options.Topics)
+
+	report = recorder.Finish()
+	if err := indexes.CrawlReportIndexer.AddReport(report); err != nil {
+		fmt.Println(err)
+	}
+	if _, err := indexes.BumpGeneration(); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Printf("Crawl run %d: %d fetched, %d indexed, %d unchanged, %d skipped, %d failed\n",
+		report.ID, report.Fetched, report.Indexed, report.Unchanged, report.Skipped, len(report.Failures))
+	if writeErr != nil {
+		return report, fmt.Errorf("Error while writing crawled pages: %s", writeErr)
+	}
+	if linkErr != nil {
+		return report, fmt.Errorf("Error while writing the links: %s", linkErr)
+	}
+	return report, nil
+}
+
+// checkLink follows a link of a page if it is in the scope and answers 200,
+// recording it as a child of the page
+func checkLink(check linkCheck, scope *Scope, linkChecker *http.Client, documents *idAssigner, recorder *Recorder) {
+	url := check.url
+	if rule, ok := scope.Check(url); !ok {
+		fmt.Println("Rejected", url, "by rule", rule)
+		recorder.Skipped(url)
+		return
+	}
+	pageURL := check.page.Request.URL.String()
+	resp, err := linkChecker.Get(url)
+	if err != nil {
+		recorder.Failed(url, pageURL, 0, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		recorder.Failed(url, pageURL, resp.StatusCode, nil)
+		return
+	}
+
+	childID, err := documents.ID(url)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if _, ok := check.pageMap.children.Get(childID); !ok {
+		check.pageMap.children.Set(childID, nil)
+	}
+
+	check.page.Request.Visit(url)
+}
+
+// Rank computes the PageRank, the hub and authority scores and the PageRank
+// of every topic over the link graph of the indexes
+func Rank(indexes *Indexer.Indexes, options pageRank.Options, topics []pageRank.Topic) {
+	indexes.BeginWrite()
+	defer indexes.EndWrite()
+	rank(indexes, options, topics)
+
+	// Results ranked with the previous scores are stale
+	if _, err := indexes.BumpGeneration(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// rank is Rank for a caller already writing to the indexes, which bumps the
+// generation once it is done
+func rank(indexes *Indexer.Indexes, options pageRank.Options, topics []pageRank.Topic) {
+	pageRankCalculator := &pageRank.PageRank{}
+	pageRankCalculator.Initialize(indexes.DocumentIndexer, indexes.ReverseDocumentIndexer, indexes.ChildParentDocumentForwardIndexer, indexes.ParentChildDocumentForwardIndexer, indexes.PageRankIndexer)
+	pageRankCalculator.Options = options
+	pageRankCalculator.ProcessPageRank()
+
+	// And the global hub and authority scores over the same graph
+	if graph, err := pageRankCalculator.Graph(); err == nil {
+		hitsResult := hits.Solve(graph, hits.DefaultOptions)
+		fmt.Printf("HITS converged: %t after %d iterations\n", hitsResult.Converged, hitsResult.Iterations)
+		if err := hits.Store(hitsResult, indexes.HubIndexer, indexes.AuthorityIndexer); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	// Pages of a query topic are those with any of its words in their body
+	// analysed like the index was
+	recordedAnalyzers, _ := indexes.MetadataIndexer.GetFieldAnalyzers()
+	analyzers, analyzersErr := tokenizer.RecordedAnalyzers(recordedAnalyzers)
+	if analyzersErr != nil {
+		fmt.Println(analyzersErr)
+	}
+	bodyAnalyzer := analyzers.Analyzer(tokenizer.FieldBody)
+	searchBody := func(query string) []uint64 {
+		pageIDs := make([]uint64, 0)
+		for _, term := range bodyAnalyzer.Analyze(query) {
+			wordID, err := indexes.WordIndexer.GetValueFromKey(term)
+			if err != nil {
+				continue
+			}
+			invFileList, _ := indexes.ContentInvertedIndexer.GetInvertedFileFromKey(wordID)
+			for _, invFile := range invFileList {
+				pageIDs = append(pageIDs, invFile.GetPageID())
+			}
+		}
+		return pageIDs
+	}
+	pageRankCalculator.ProcessTopicPageRank(topics, searchBody, indexes.TopicPageRankIndexer)
 }
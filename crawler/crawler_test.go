@@ -0,0 +1,33 @@
+package crawler
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+func TestRankBumpsGeneration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexes, err := Indexer.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+
+	// Rank on its own bumps the generation once, a crawl ranks with rank and
+	// bumps it once itself when done
+	Rank(indexes, DefaultOptions.PageRank, nil)
+	if generation := indexes.Generation(); generation != 1 {
+		t.Errorf("generation %d after ranking, expected 1", generation)
+	}
+	rank(indexes, DefaultOptions.PageRank, nil)
+	if generation := indexes.Generation(); generation != 1 {
+		t.Errorf("generation %d after ranking for a crawl, expected 1", generation)
+	}
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+)
+
+// Document holds the text fields extracted from a crawled HTML page.
+// Each field is indexed separately so it can be boosted on its own.
+type Document struct {
+	Title       string
+	Body        string
+	Headings    string
+	Description string
+	Keywords    string
+	Alt         string
+	Language    string
+}
+
+// Elements whose text never shows up on the rendered page
+const hiddenElements = "script, style, noscript"
+
+// ExtractDocument pulls the indexed fields out of the <html> element of a page
+func ExtractDocument(e *colly.HTMLElement) Document {
+	return extractDocument(e.DOM)
+}
+
+// extractDocument pulls the indexed fields out of an <html> element
+func extractDocument(html *goquery.Selection) Document {
+	doc := Document{}
+
+	// Work on a copy so removing elements does not affect other callbacks
+	dom := html.Clone()
+	dom.Find(hiddenElements).Remove()
+
+	doc.Title = strings.TrimSpace(dom.Find("title").First().Text())
+	doc.Body = dom.Find("body").Text()
+	doc.Headings = joinText(dom.Find("h1, h2, h3"))
+	lang, _ := html.Attr("lang")
+	doc.Language = strings.TrimSpace(lang)
+
+	dom.Find("meta[name]").Each(func(_ int, s *goquery.Selection) {
+		name, _ := s.Attr("name")
+		content, _ := s.Attr("content")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "description":
+			doc.Description = strings.TrimSpace(content)
+		case "keywords":
+			doc.Keywords = strings.Replace(content, ",", " ", -1)
+		}
+	})
+
+	alts := make([]string, 0)
+	dom.Find("img[alt]").Each(func(_ int, s *goquery.Selection) {
+		if alt, ok := s.Attr("alt"); ok && strings.TrimSpace(alt) != "" {
+			alts = append(alts, strings.TrimSpace(alt))
+		}
+	})
+	doc.Alt = strings.Join(alts, " ")
+
+	return doc
+}
+
+// joinText returns the text of every element in the selection separated by spaces,
+// so that words from adjacent elements are not glued together
+func joinText(s *goquery.Selection) string {
+	texts := make([]string, 0)
+	s.Each(func(_ int, elem *goquery.Selection) {
+		texts = append(texts, strings.TrimSpace(elem.Text()))
+	})
+	return strings.Join(texts, " ")
+}
@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<title> Department of Computer Science </title>
+<meta name="Description" content=" Teaching and research in computing ">
+<meta name="keywords" content="hkust,computer science,research">
+<style>h1 { color: red; }</style>
+<script>var hidden = "not indexed";</script>
+</head>
+<body>
+<h1>Welcome</h1><h2>Research</h2><h4>Contact</h4>
+<p>Our programmes</p>
+<img src="logo.png" alt="HKUST logo">
+<img src="spacer.gif" alt=" ">
+<img src="campus.jpg" alt="Clear Water Bay campus">
+<noscript>Enable JavaScript</noscript>
+</body>
+</html>`
+
+func TestExtractDocument(t *testing.T) {
+	page, err := goquery.NewDocumentFromReader(strings.NewReader(testPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := page.Find("html")
+	doc := extractDocument(html)
+
+	if doc.Title != "Department of Computer Science" || doc.Language != "en" {
+		t.Errorf("title %q, language %q", doc.Title, doc.Language)
+	}
+	// Only h1 to h3 are headings, each separated so words are not glued together
+	if doc.Headings != "Welcome Research" {
+		t.Errorf("headings %q", doc.Headings)
+	}
+	// The meta name is matched in any case
+	if doc.Description != "Teaching and research in computing" {
+		t.Errorf("description %q", doc.Description)
+	}
+	if doc.Keywords != "hkust computer science research" {
+		t.Errorf("keywords %q", doc.Keywords)
+	}
+	// Blank alt texts are left out
+	if doc.Alt != "HKUST logo Clear Water Bay campus" {
+		t.Errorf("alt %q", doc.Alt)
+	}
+	for _, hidden := range []string{"not indexed", "color", "Enable JavaScript"} {
+		if strings.Contains(doc.Body, hidden) {
+			t.Errorf("body %q contains hidden text %q", doc.Body, hidden)
+		}
+	}
+	if !strings.Contains(doc.Body, "Our programmes") {
+		t.Errorf("body %q", doc.Body)
+	}
+
+	// The page is not changed for the other callbacks
+	if html.Find("script").Length() != 1 {
+		t.Error("hidden elements removed from the page")
+	}
+}
@@ -0,0 +1,273 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+	"github.com/davi1972/comp4321-search-engine/tokenizer"
+)
+
+// idAssigner gives names their IDs, the same ID to a name however many
+// goroutines ask for it at once. IDs already looked up are kept in memory, and
+// the reverse mapping of every name met is recorded.
+type idAssigner struct {
+	mutex   sync.Mutex
+	mapping *Indexer.MappingIndexer
+	reverse *Indexer.ReverseMappingIndexer
+	ids     map[string]uint64
+}
+
+func newIDAssigner(mapping *Indexer.MappingIndexer, reverse *Indexer.ReverseMappingIndexer) *idAssigner {
+	return &idAssigner{mapping: mapping, reverse: reverse, ids: make(map[string]uint64)}
+}
+
+// IDs returns the ID of every name, in order
+func (assigner *idAssigner) IDs(names []string) ([]uint64, error) {
+	assigner.mutex.Lock()
+	defer assigner.mutex.Unlock()
+	missing := make([]string, 0)
+	for _, name := range names {
+		if _, ok := assigner.ids[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		assigned, err := assigner.mapping.AssignIDs(missing)
+		if err != nil {
+			return nil, err
+		}
+		reverse := make(map[uint64]string, len(assigned))
+		for name, id := range assigned {
+			reverse[id] = name
+		}
+		if err := assigner.reverse.AddKeys(reverse); err != nil {
+			return nil, err
+		}
+		for name, id := range assigned {
+			assigner.ids[name] = id
+		}
+	}
+	ids := make([]uint64, len(names))
+	for i, name := range names {
+		ids[i] = assigner.ids[name]
+	}
+	return ids, nil
+}
+
+// ID returns the ID of one name
+func (assigner *idAssigner) ID(name string) (uint64, error) {
+	ids, err := assigner.IDs([]string{name})
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// fieldText is the text of one field of a page and the inverted index it goes to
+type fieldText struct {
+	field    string
+	text     string
+	inverted *Indexer.InvertedFileIndexer
+}
+
+// parsedPage is a new or modified page waiting to be analysed
+type parsedPage struct {
+	page     Indexer.Page
+	language string
+	fields   []fieldText
+}
+
+// analysedPage is a page with the postings and word frequencies of its fields
+type analysedPage struct {
+	page       Indexer.Page
+	postings   map[*Indexer.InvertedFileIndexer]map[uint64]*Indexer.InvertedFile
+	titleWords []Indexer.WordFrequency
+	bodyWords  []Indexer.WordFrequency
+}
+
+// analyse splits the fields of a page into words in the page's language and
+// gives them their IDs
+func analyse(parsed parsedPage, analyzers map[string]*tokenizer.Analyzer, words *idAssigner) (*analysedPage, error) {
+	id := parsed.page.GetId()
+	analysed := &analysedPage{
+		page:     parsed.page,
+		postings: make(map[*Indexer.InvertedFileIndexer]map[uint64]*Indexer.InvertedFile),
+	}
+	for _, field := range parsed.fields {
+		terms := analyzers[field.field].ForLanguage(parsed.language).Analyze(field.text)
+		wordIDs, err := words.IDs(terms)
+		if err != nil {
+			return nil, err
+		}
+		postings := make(map[uint64]*Indexer.InvertedFile)
+		wordCounter := make(map[uint64]uint64)
+		order := make([]uint64, 0)
+		for i, wordID := range wordIDs {
+			invFile, contain := postings[wordID]
+			if !contain {
+				invFile = Indexer.CreateInvertedFile(id)
+				postings[wordID] = invFile
+				order = append(order, wordID)
+			}
+			invFile.AddWordPositions(uint64(i))
+			wordCounter[wordID]++
+		}
+		analysed.postings[field.inverted] = postings
+
+		frequencySlice := make([]Indexer.WordFrequency, len(order))
+		for i, wordID := range order {
+			frequencySlice[i] = Indexer.CreateWordFrequency(wordID, wordCounter[wordID])
+		}
+		switch field.field {
+		case tokenizer.FieldTitle:
+			analysed.titleWords = frequencySlice
+		case tokenizer.FieldBody:
+			analysed.bodyWords = frequencySlice
+		}
+	}
+	return analysed, nil
+}
+
+// indexBatch accumulates the analysed pages in memory until they are flushed
+type indexBatch struct {
+	indexes    *Indexer.Indexes
+	pages      map[uint64]Indexer.Page
+	postings   map[*Indexer.InvertedFileIndexer]map[uint64][]Indexer.InvertedFile
+	titleWords map[uint64][]Indexer.WordFrequency
+	bodyWords  map[uint64][]Indexer.WordFrequency
+}
+
+func newIndexBatch(indexes *Indexer.Indexes) *indexBatch {
+	batch := &indexBatch{indexes: indexes}
+	batch.reset()
+	return batch
+}
+
+func (batch *indexBatch) reset() {
+	batch.pages = make(map[uint64]Indexer.Page)
+	batch.postings = make(map[*Indexer.InvertedFileIndexer]map[uint64][]Indexer.InvertedFile)
+	batch.titleWords = make(map[uint64][]Indexer.WordFrequency)
+	batch.bodyWords = make(map[uint64][]Indexer.WordFrequency)
+}
+
+func (batch *indexBatch) Len() int {
+	return len(batch.pages)
+}
+
+// Pages returns the pages of the batch, by ID
+func (batch *indexBatch) Pages() []Indexer.Page {
+	pages := make([]Indexer.Page, 0, len(batch.pages))
+	for _, page := range batch.pages {
+		pages = append(pages, page)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].GetId() < pages[j].GetId() })
+	return pages
+}
+
+func (batch *indexBatch) Add(analysed *analysedPage) {
+	id := analysed.page.GetId()
+	batch.pages[id] = analysed.page
+	for inverted, postings := range analysed.postings {
+		if batch.postings[inverted] == nil {
+			batch.postings[inverted] = make(map[uint64][]Indexer.InvertedFile)
+		}
+		for wordID, invFile := range postings {
+			batch.postings[inverted][wordID] = append(batch.postings[inverted][wordID], *invFile)
+		}
+	}
+	batch.titleWords[id] = analysed.titleWords
+	batch.bodyWords[id] = analysed.bodyWords
+}
+
+// Flush writes the batch with one batched write per database. The page
+// properties go last: a page whose postings were not all written is not
+// recorded as indexed, so the next crawl indexes it again.
+func (batch *indexBatch) Flush() error {
+	if batch.Len() == 0 {
+		return nil
+	}
+	defer batch.reset()
+	for inverted, postings := range batch.postings {
+		if err := inverted.AddInvertedFiles(postings); err != nil {
+			return err
+		}
+	}
+	if err := batch.indexes.TitleWordForwardIndexer.AddWordFrequencyLists(batch.titleWords); err != nil {
+		return err
+	}
+	if err := batch.indexes.DocumentWordForwardIndexer.AddWordFrequencyLists(batch.bodyWords); err != nil {
+		return err
+	}
+	if err := batch.indexes.PagePropertiesIndexer.AddPages(batch.pages); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote a batch of %d pages\n", batch.Len())
+	return nil
+}
+
+// writeBatches writes the analysed pages in batches of size pages, counting
+// them as indexed once written. The pages of a batch that could not be written
+// are recorded as failures, to be indexed again by the next crawl, and the
+// first error is returned once every page is written or failed.
+func writeBatches(analysed <-chan *analysedPage, batch *indexBatch, size int, recorder *Recorder) error {
+	var writeErr error
+	flush := func() {
+		pages := batch.Pages()
+		if err := batch.Flush(); err != nil {
+			fmt.Println(err)
+			for _, page := range pages {
+				recorder.Failed(page.GetUrl(), "", 0, err)
+			}
+			if writeErr == nil {
+				writeErr = err
+			}
+			return
+		}
+		for range pages {
+			recorder.Indexed()
+		}
+	}
+	for page := range analysed {
+		batch.Add(page)
+		if batch.Len() >= size {
+			flush()
+		}
+	}
+	flush()
+	return writeErr
+}
+
+// linkLists returns the children of every crawled page, and its parents among
+// the crawled pages, each sorted by ID
+func linkLists(pages []*pageMap) (map[uint64][]uint64, map[uint64][]uint64) {
+	children := make(map[uint64][]uint64, len(pages))
+	parents := make(map[uint64][]uint64, len(pages))
+	for _, page := range pages {
+		list := page.children.ConvertToSliceOfKeys()
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+		children[page.id] = list
+		parents[page.id] = make([]uint64, 0)
+	}
+	// Parents are met in the order of their IDs, so the lists stay sorted
+	sorted := append([]*pageMap(nil), pages...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].id < sorted[j].id })
+	for _, parent := range sorted {
+		for _, child := range children[parent.id] {
+			if list, crawled := parents[child]; crawled {
+				parents[child] = append(list, parent.id)
+			}
+		}
+	}
+	return children, parents
+}
+
+// writeLinks records the links between the crawled pages in both directions
+func writeLinks(indexes *Indexer.Indexes, pages []*pageMap) error {
+	children, parents := linkLists(pages)
+	if err := indexes.ParentChildDocumentForwardIndexer.AddIdLists(children); err != nil {
+		return err
+	}
+	return indexes.ChildParentDocumentForwardIndexer.AddIdLists(parents)
+}
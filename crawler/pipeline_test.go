@@ -0,0 +1,146 @@
+package crawler
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+func TestIndexBatchAdd(t *testing.T) {
+	title, content := &Indexer.InvertedFileIndexer{}, &Indexer.InvertedFileIndexer{}
+	batch := newIndexBatch(&Indexer.Indexes{})
+	for _, id := range []uint64{1, 2} {
+		posting := Indexer.CreateInvertedFile(id)
+		posting.AddWordPositions(0)
+		batch.Add(&analysedPage{
+			page: Indexer.CreatePage(id, "title", "https://www.cse.ust.hk/", 10, time.Now()),
+			postings: map[*Indexer.InvertedFileIndexer]map[uint64]*Indexer.InvertedFile{
+				title:   {7: posting},
+				content: {7: posting, 8: posting},
+			},
+			bodyWords: []Indexer.WordFrequency{Indexer.CreateWordFrequency(7, 1)},
+		})
+	}
+
+	if batch.Len() != 2 {
+		t.Errorf("batch has %d pages", batch.Len())
+	}
+	if pages := batch.Pages(); len(pages) != 2 || pages[0].GetId() != 1 || pages[1].GetId() != 2 {
+		t.Errorf("pages are %v", pages)
+	}
+	if len(batch.postings[title][7]) != 2 || len(batch.postings[content][8]) != 2 || len(batch.postings[content]) != 2 {
+		t.Errorf("postings are %v", batch.postings)
+	}
+	if len(batch.bodyWords[2]) != 1 {
+		t.Errorf("word lists are %v", batch.bodyWords)
+	}
+	batch.reset()
+	if batch.Len() != 0 || len(batch.postings) != 0 {
+		t.Error("reset batch is not empty")
+	}
+}
+
+func TestWriteBatchesFailure(t *testing.T) {
+	// Small tables make small transactions, which a long word list kept in
+	// the table does not fit in
+	dir, err := ioutil.TempDir("", "batches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexes, err := Indexer.OpenWithOptions(dir, Indexer.SmallTransactionOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+
+	longList := make([]Indexer.WordFrequency, 2000)
+	for i := range longList {
+		longList[i] = Indexer.CreateWordFrequency(uint64(i), 1)
+	}
+	analysed := make(chan *analysedPage, 2)
+	analysed <- &analysedPage{page: Indexer.CreatePage(1, "fits", "https://www.cse.ust.hk/a", 10, time.Now())}
+	analysed <- &analysedPage{page: Indexer.CreatePage(2, "too long", "https://www.cse.ust.hk/b", 10, time.Now()), bodyWords: longList}
+	close(analysed)
+	recorder := NewRecorder(&Indexer.CrawlReport{})
+	if err := writeBatches(analysed, newIndexBatch(indexes), 1, recorder); err == nil {
+		t.Error("batch that could not be written gave no error")
+	}
+
+	report := recorder.Finish()
+	if report.Indexed != 1 {
+		t.Errorf("%d pages indexed, expected only the written one", report.Indexed)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].URL != "https://www.cse.ust.hk/b" {
+		t.Errorf("failures are %v", report.Failures)
+	}
+	if page, err := indexes.PagePropertiesIndexer.GetPagePropertiesFromKey(1); err != nil || page.GetTitle() != "fits" {
+		t.Errorf("written page is %v, %v", page, err)
+	}
+	// Without its properties the page is indexed again by the next crawl
+	if _, err := indexes.PagePropertiesIndexer.GetPagePropertiesFromKey(2); err == nil {
+		t.Error("properties of the failed page were written")
+	}
+}
+
+func TestLinkLists(t *testing.T) {
+	page := func(id uint64, children ...uint64) *pageMap {
+		p := &pageMap{id: id}
+		p.children.Init()
+		for _, child := range children {
+			p.children.Set(child, nil)
+		}
+		return p
+	}
+	// 9 is linked to but was not crawled
+	pages := []*pageMap{page(3, 1, 9), page(1, 2, 3), page(2, 3, 1), page(4)}
+	children, parents := linkLists(pages)
+
+	expectedChildren := map[uint64][]uint64{1: {2, 3}, 2: {1, 3}, 3: {1, 9}, 4: {}}
+	expectedParents := map[uint64][]uint64{1: {2, 3}, 2: {1}, 3: {1, 2}, 4: {}}
+	if !reflect.DeepEqual(children, expectedChildren) {
+		t.Errorf("children %v, expected %v", children, expectedChildren)
+	}
+	if !reflect.DeepEqual(parents, expectedParents) {
+		t.Errorf("parents %v, expected %v", parents, expectedParents)
+	}
+}
+
+func TestWriteLinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexes, err := Indexer.OpenWithOptions(dir, Indexer.SmallTransactionOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+
+	// Every page links to every other, more than one transaction holds
+	pages := make([]*pageMap, 300)
+	for i := range pages {
+		pages[i] = &pageMap{id: uint64(i + 1)}
+		pages[i].children.Init()
+		for j := range pages {
+			if j != i {
+				pages[i].children.Set(uint64(j+1), nil)
+			}
+		}
+	}
+	if err := writeLinks(indexes, pages); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint64{1, 150, 300} {
+		children, _ := indexes.ParentChildDocumentForwardIndexer.GetIdListFromKey(id)
+		parents, _ := indexes.ChildParentDocumentForwardIndexer.GetIdListFromKey(id)
+		if len(children) != 299 || len(parents) != 299 {
+			t.Errorf("page %d has %d children and %d parents, expected 299", id, len(children), len(parents))
+		}
+	}
+}
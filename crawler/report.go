@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// Redirects followed before a request is given up, same as net/http
+const maxRedirects = 10
+
+// Recorder fills in the report of a crawl run. It is safe to use from the
+// collector callbacks and link checking goroutines at the same time.
+type Recorder struct {
+	mutex     sync.Mutex
+	report    *Indexer.CrawlReport
+	redirects map[string][]string
+	skipped   map[string]struct{}
+}
+
+func NewRecorder(report *Indexer.CrawlReport) *Recorder {
+	return &Recorder{report: report, redirects: make(map[string][]string), skipped: make(map[string]struct{})}
+}
+
+func (recorder *Recorder) Fetched() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.report.Fetched++
+}
+
+func (recorder *Recorder) Indexed() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.report.Indexed++
+}
+
+// Skipped counts a URL rejected by the crawl scope, once however many pages link to it
+func (recorder *Recorder) Skipped(url string) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	if _, contain := recorder.skipped[url]; contain {
+		return
+	}
+	recorder.skipped[url] = struct{}{}
+	recorder.report.Skipped++
+}
+
+// Unchanged counts a page not reindexed because it was not modified
+func (recorder *Recorder) Unchanged() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.report.Unchanged++
+}
+
+// Failed records a URL that could not be fetched, with the redirects taken to reach it
+func (recorder *Recorder) Failed(url string, referrer string, statusCode int, err error) {
+	failure := Indexer.CrawlFailure{URL: url, Referrer: referrer, StatusCode: statusCode}
+	if err != nil {
+		failure.Error = err.Error()
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	failure.RedirectChain = recorder.redirects[url]
+	recorder.report.Failures = append(recorder.report.Failures, failure)
+}
+
+// CheckRedirect remembers the redirect chain starting at the original request URL.
+// It can be used as http.Client.CheckRedirect and as the collector redirect handler.
+func (recorder *Recorder) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return http.ErrUseLastResponse
+	}
+	chain := make([]string, 0, len(via)+1)
+	for _, r := range via {
+		chain = append(chain, r.URL.String())
+	}
+	chain = append(chain, req.URL.String())
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.redirects[chain[0]] = chain
+	return nil
+}
+
+// Finish marks the run as done and returns the completed report
+func (recorder *Recorder) Finish() *Indexer.CrawlReport {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.report.End = time.Now()
+	recorder.report.Finished = true
+	return recorder.report
+}
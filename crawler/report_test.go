@@ -0,0 +1,18 @@
+package crawler
+
+import (
+	"testing"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+func TestRecorderSkipped(t *testing.T) {
+	recorder := NewRecorder(&Indexer.CrawlReport{})
+	recorder.Skipped("https://www.ust.hk/")
+	recorder.Skipped("https://www.ust.hk/")
+	recorder.Skipped("https://www.ust.hk/about")
+
+	if report := recorder.Finish(); report.Skipped != 2 {
+		t.Errorf("skipped %d URLs, want 2", report.Skipped)
+	}
+}
@@ -0,0 +1,189 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Scope decides which URLs a crawl may fetch. Hosts match themselves and
+// their subdomains; deny rules win over allow rules. Zero limits mean no limit.
+type Scope struct {
+	AllowedHosts    []string       `json:"allowed_hosts" yaml:"allowed_hosts,omitempty"`
+	DeniedHosts     []string       `json:"denied_hosts" yaml:"denied_hosts,omitempty"`
+	Include         []string       `json:"include" yaml:"include,omitempty"`
+	Exclude         []string       `json:"exclude" yaml:"exclude,omitempty"`
+	MaxPages        int            `json:"max_pages" yaml:"max_pages,omitempty"`
+	MaxPagesPerHost int            `json:"max_pages_per_host" yaml:"max_pages_per_host,omitempty"`
+	HostBudgets     map[string]int `json:"host_budgets" yaml:"host_budgets,omitempty"`
+	MaxDocumentSize int            `json:"max_document_size" yaml:"max_document_size,omitempty"`
+
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+
+	mutex     sync.Mutex
+	pages     int
+	hostPages map[string]int
+}
+
+// LoadScope reads a scope from a JSON file
+func LoadScope(path string) (*Scope, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error while loading scope: %s", err)
+	}
+	scope := &Scope{}
+	if err := json.Unmarshal(data, scope); err != nil {
+		return nil, fmt.Errorf("Error while parsing scope %s: %s", path, err)
+	}
+	return scope, scope.Compile()
+}
+
+// DefaultScope keeps a crawl on the host of its root page
+func DefaultScope(rootPage string) *Scope {
+	scope := &Scope{}
+	if u, err := url.Parse(rootPage); err == nil && u.Hostname() != "" {
+		scope.AllowedHosts = []string{u.Hostname()}
+	}
+	scope.Compile()
+	return scope
+}
+
+// Clone returns a scope with the same rules and no page counted yet, for another crawl
+func (scope *Scope) Clone() (*Scope, error) {
+	clone := &Scope{
+		AllowedHosts:    scope.AllowedHosts,
+		DeniedHosts:     scope.DeniedHosts,
+		Include:         scope.Include,
+		Exclude:         scope.Exclude,
+		MaxPages:        scope.MaxPages,
+		MaxPagesPerHost: scope.MaxPagesPerHost,
+		HostBudgets:     scope.HostBudgets,
+		MaxDocumentSize: scope.MaxDocumentSize,
+	}
+	return clone, clone.Compile()
+}
+
+// Compile prepares the URL patterns, it must be called before the scope is used
+func (scope *Scope) Compile() error {
+	scope.include = make([]*regexp.Regexp, 0, len(scope.Include))
+	for _, pattern := range scope.Include {
+		reg, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %q: %s", pattern, err)
+		}
+		scope.include = append(scope.include, reg)
+	}
+	scope.exclude = make([]*regexp.Regexp, 0, len(scope.Exclude))
+	for _, pattern := range scope.Exclude {
+		reg, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %s", pattern, err)
+		}
+		scope.exclude = append(scope.exclude, reg)
+	}
+	if len(scope.HostBudgets) > 0 {
+		budgets := make(map[string]int, len(scope.HostBudgets))
+		for host, budget := range scope.HostBudgets {
+			host = strings.ToLower(host)
+			if _, contain := budgets[host]; contain {
+				return fmt.Errorf("duplicate host budget for %q", host)
+			}
+			budgets[host] = budget
+		}
+		scope.HostBudgets = budgets
+	}
+	scope.hostPages = make(map[string]int)
+	return nil
+}
+
+func hostMatches(host string, rule string) bool {
+	rule = strings.TrimPrefix(strings.ToLower(rule), "*.")
+	return host == rule || strings.HasSuffix(host, "."+rule)
+}
+
+// Check returns the rule rejecting a URL, or ok if the URL is in scope.
+// Page budgets are not consulted, see Admit.
+func (scope *Scope) Check(rawURL string) (rule string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "invalid url", false
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "scheme " + u.Scheme, false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	for _, denied := range scope.DeniedHosts {
+		if hostMatches(host, denied) {
+			return "denied_hosts " + denied, false
+		}
+	}
+	if len(scope.AllowedHosts) > 0 {
+		allowed := false
+		for _, allowedHost := range scope.AllowedHosts {
+			if hostMatches(host, allowedHost) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "allowed_hosts", false
+		}
+	}
+	for _, reg := range scope.exclude {
+		if reg.MatchString(rawURL) {
+			return "exclude " + reg.String(), false
+		}
+	}
+	if len(scope.include) > 0 {
+		included := false
+		for _, reg := range scope.include {
+			if reg.MatchString(rawURL) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return "include", false
+		}
+	}
+	return "", true
+}
+
+// Admit checks a URL about to be fetched and counts it against the page budgets
+func (scope *Scope) Admit(rawURL string) (rule string, ok bool) {
+	if rule, ok := scope.Check(rawURL); !ok {
+		return rule, false
+	}
+	u, _ := url.Parse(rawURL)
+	host := strings.ToLower(u.Hostname())
+
+	scope.mutex.Lock()
+	defer scope.mutex.Unlock()
+	if scope.MaxPages > 0 && scope.pages >= scope.MaxPages {
+		return fmt.Sprintf("max_pages %d", scope.MaxPages), false
+	}
+	budget := scope.MaxPagesPerHost
+	if hostBudget, contain := scope.HostBudgets[host]; contain {
+		budget = hostBudget
+	}
+	if budget > 0 && scope.hostPages[host] >= budget {
+		return fmt.Sprintf("host budget %d for %s", budget, host), false
+	}
+	scope.pages++
+	scope.hostPages[host]++
+	return "", true
+}
+
+// CheckSize returns the rule rejecting a document of the given size in bytes
+func (scope *Scope) CheckSize(size int) (rule string, ok bool) {
+	if scope.MaxDocumentSize > 0 && size > scope.MaxDocumentSize {
+		return fmt.Sprintf("max_document_size %d", scope.MaxDocumentSize), false
+	}
+	return "", true
+}
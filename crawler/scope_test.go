@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"testing"
+)
+
+func TestScopeCheck(t *testing.T) {
+	scope := &Scope{
+		AllowedHosts: []string{"ust.hk"},
+		DeniedHosts:  []string{"library.ust.hk"},
+		Exclude:      []string{`\.pdf$`},
+	}
+	if err := scope.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := scope.Check("https://www.cse.ust.hk/admin/"); !ok {
+		t.Error("subdomain of allowed host rejected")
+	}
+	if rule, ok := scope.Check("https://library.ust.hk/"); ok || rule != "denied_hosts library.ust.hk" {
+		t.Errorf("denied host admitted, rule %q", rule)
+	}
+	if _, ok := scope.Check("https://www.google.com/"); ok {
+		t.Error("other host admitted")
+	}
+	if _, ok := scope.Check("https://www.cse.ust.hk/report.pdf"); ok {
+		t.Error("excluded url admitted")
+	}
+	if _, ok := scope.Check("mailto:someone@ust.hk"); ok {
+		t.Error("mailto link admitted")
+	}
+}
+
+func TestScopeAdmitBudgets(t *testing.T) {
+	scope := &Scope{MaxPages: 3, MaxPagesPerHost: 2, HostBudgets: map[string]int{"www.cse.ust.hk": 1}}
+	scope.Compile()
+
+	if _, ok := scope.Admit("https://www.cse.ust.hk/a"); !ok {
+		t.Fatal("first page rejected")
+	}
+	if _, ok := scope.Admit("https://www.cse.ust.hk/b"); ok {
+		t.Error("host budget exceeded")
+	}
+	scope.Admit("https://www.ust.hk/a")
+	scope.Admit("https://www.ust.hk/b")
+	if rule, ok := scope.Admit("https://www.ece.ust.hk/a"); ok || rule != "max_pages 3" {
+		t.Errorf("max pages exceeded, rule %q", rule)
+	}
+}
+
+func TestScopeHostBudgetCase(t *testing.T) {
+	scope := &Scope{HostBudgets: map[string]int{"WWW.CSE.UST.HK": 1}}
+	if err := scope.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := scope.Admit("https://www.cse.ust.hk/a"); !ok {
+		t.Fatal("first page rejected")
+	}
+	if _, ok := scope.Admit("https://Www.Cse.Ust.Hk/b"); ok {
+		t.Error("host budget not applied to an upper case host")
+	}
+
+	scope = &Scope{HostBudgets: map[string]int{"www.ust.hk": 1, "WWW.UST.HK": 2}}
+	if err := scope.Compile(); err == nil {
+		t.Error("budgets for the same host in two cases accepted")
+	}
+}
@@ -42,6 +42,7 @@ var searchResult = Vue.component('search-result', {
         title: String,
         url: String,
         date: String,
+        snippet: String,
         score: Number
 
     },
@@ -51,6 +52,7 @@ var searchResult = Vue.component('search-result', {
                 <p><a v-bind:href="url" class="title">{{title}}</a><span class="score">({{score.toFixed(3)}})</span></p>
                 <p><a v-bind:href="url" class="url">{{url}}</a></p>
                 <p class="date">{{date}}</p>
+                <p v-if="snippet" class="snippet">{{snippet}}</p>
                 <slot></slot>
             </div>
         </div>
@@ -66,7 +68,9 @@ new Vue({
         results: [],
         keywords: [],
         notfound: false,
-        newQuery: []
+        newQuery: [],
+        expand: false,
+        expandedTerms: []
     },
     methods: {
         checkQuery: function (e) {
@@ -89,10 +93,12 @@ new Vue({
             this.notfound = false;
             this.page = 1;
             this.results = [];
-            axios.get(this.API_URL + "query/" + query)
+            this.expandedTerms = [];
+            axios.get(this.API_URL + "query/" + query + (this.expand ? "?expand=true" : ""))
                 .then(res => {
 
                     this.results = res.data.documents;
+                    this.expandedTerms = res.data.expanded_terms || [];
                     if (!res.data.documents) {
                         this.notfound = true;
                     }
@@ -102,6 +108,18 @@ new Vue({
                 })
         },
 
+        similar: function (pageID) {
+            this.notfound = false;
+            this.page = 1;
+            this.results = [];
+            this.expandedTerms = [];
+            axios.get(this.API_URL + "similar/" + pageID + "?cocitation=true")
+                .then(res => {
+                    this.results = res.data.documents || [];
+                    this.notfound = this.results.length == 0;
+                })
+        },
+
         gotoSearch: function () {
             this.page = 1;
         },
@@ -0,0 +1,160 @@
+package hits
+
+import (
+	"fmt"
+	"math"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+	"github.com/davi1972/comp4321-search-engine/linkGraph"
+)
+
+// Options of the HITS iteration
+type Options struct {
+	// Iteration stops once the scores change by less than this in total
+	Tolerance float64
+	// Iteration stops after this many rounds even if not converged
+	MaxIterations int
+}
+
+var DefaultOptions = Options{Tolerance: 1e-6, MaxIterations: 100}
+
+// Pages taken from the top of a ranking as the root of a query base set, and
+// the most parents of each root page added to it
+const (
+	RootSetSize    = 200
+	ParentsPerPage = 50
+)
+
+// Result of a HITS computation. Both scores average 1 over all pages, like PageRank.
+type Result struct {
+	Hubs        map[uint64]float64
+	Authorities map[uint64]float64
+	Iterations  int
+	Delta       float64
+	Converged   bool
+}
+
+// Solve computes hub and authority scores: a page is a good authority if good
+// hubs link to it, and a good hub if it links to good authorities.
+func Solve(graph *linkGraph.Graph, options Options) Result {
+	n := graph.Len()
+	result := Result{Hubs: make(map[uint64]float64, n), Authorities: make(map[uint64]float64, n)}
+	if n == 0 {
+		result.Converged = true
+		return result
+	}
+
+	hubs := make([]float64, n)
+	authorities := make([]float64, n)
+	for i := range hubs {
+		hubs[i] = 1 / math.Sqrt(float64(n))
+	}
+	nextHubs := make([]float64, n)
+	for result.Iterations < options.MaxIterations {
+		result.Iterations++
+
+		for i := range authorities {
+			authorities[i] = 0
+		}
+		for source := 0; source < n; source++ {
+			for _, target := range graph.Links(source) {
+				authorities[target] += hubs[source]
+			}
+		}
+		normalize(authorities)
+
+		for source := 0; source < n; source++ {
+			nextHubs[source] = 0
+			for _, target := range graph.Links(source) {
+				nextHubs[source] += authorities[target]
+			}
+		}
+		normalize(nextHubs)
+
+		result.Delta = 0
+		for i := range hubs {
+			result.Delta += math.Abs(nextHubs[i] - hubs[i])
+		}
+		hubs, nextHubs = nextHubs, hubs
+		if result.Delta < options.Tolerance {
+			result.Converged = true
+			break
+		}
+	}
+
+	hubTotal, authorityTotal := 0.0, 0.0
+	for i := range hubs {
+		hubTotal += hubs[i]
+		authorityTotal += authorities[i]
+	}
+	for i, id := range graph.IDs {
+		result.Hubs[id] = scale(hubs[i], hubTotal, n)
+		result.Authorities[id] = scale(authorities[i], authorityTotal, n)
+	}
+	return result
+}
+
+func normalize(scores []float64) {
+	length := 0.0
+	for _, score := range scores {
+		length += score * score
+	}
+	if length == 0 {
+		return
+	}
+	length = math.Sqrt(length)
+	for i := range scores {
+		scores[i] /= length
+	}
+}
+
+// scale makes scores average 1, a graph without links gives all zero
+func scale(score float64, total float64, n int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return score / total * float64(n)
+}
+
+// BaseSet builds the graph of a query's root pages, the pages they link to and
+// up to ParentsPerPage of the pages linking to each of them
+func BaseSet(root []uint64, parentChild *Indexer.ForwardIndexer, childParent *Indexer.ForwardIndexer) (*linkGraph.Graph, error) {
+	pages := make(map[uint64]bool)
+	for _, id := range root {
+		pages[id] = true
+		children, _ := parentChild.GetIdListFromKey(id)
+		for _, child := range children {
+			pages[child] = true
+		}
+		parents, _ := childParent.GetIdListFromKey(id)
+		if len(parents) > ParentsPerPage {
+			parents = parents[:ParentsPerPage]
+		}
+		for _, parent := range parents {
+			pages[parent] = true
+		}
+	}
+	ids := make([]uint64, 0, len(pages))
+	for id := range pages {
+		ids = append(ids, id)
+	}
+	return linkGraph.NewGraph(ids, func(id uint64) ([]uint64, error) {
+		children, _ := parentChild.GetIdListFromKey(id)
+		return children, nil
+	})
+}
+
+// Store saves the scores in the hub and authority indexes
+func Store(result Result, hubIndexer *Indexer.PageRankIndexer, authorityIndexer *Indexer.PageRankIndexer) error {
+	for id, score := range result.Hubs {
+		if err := hubIndexer.AddKeyToIndexOrUpdate(id, score); err != nil {
+			return fmt.Errorf("Error inserting hub value: %s", err)
+		}
+	}
+	for id, score := range result.Authorities {
+		if err := authorityIndexer.AddKeyToIndexOrUpdate(id, score); err != nil {
+			return fmt.Errorf("Error inserting authority value: %s", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package hits
+
+import (
+	"testing"
+
+	"github.com/davi1972/comp4321-search-engine/linkGraph"
+)
+
+func TestSolve(t *testing.T) {
+	// 1 and 2 are hubs linking to the authorities 3 and 4, 3 is linked to most
+	links := map[uint64][]uint64{1: {3, 4}, 2: {3}, 3: {}, 4: {}}
+	graph, _ := linkGraph.NewGraph([]uint64{1, 2, 3, 4}, func(id uint64) ([]uint64, error) {
+		return links[id], nil
+	})
+	result := Solve(graph, DefaultOptions)
+	if !result.Converged {
+		t.Fatal("not converged")
+	}
+	if !(result.Authorities[3] > result.Authorities[4] && result.Authorities[4] > result.Authorities[1]) {
+		t.Errorf("authorities %v", result.Authorities)
+	}
+	if !(result.Hubs[1] > result.Hubs[2] && result.Hubs[2] > result.Hubs[3]) {
+		t.Errorf("hubs %v", result.Hubs)
+	}
+	total := 0.0
+	for _, score := range result.Authorities {
+		total += score
+	}
+	if total < 3.999 || total > 4.001 {
+		t.Errorf("authorities sum to %f", total)
+	}
+}
@@ -1,7 +1,10 @@
 package Indexer
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"sync"
 	"testing"
 	"time"
 )
@@ -284,6 +287,90 @@ func TestAllDatabaseMappingIndexer(t *testing.T) {
 
 }
 
+func TestMappingMappingIndexer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mapping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testDB := &MappingIndexer{}
+	if err := testDB.Initialize(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Release()
+
+	id, _ := testDB.AddKeyToIndex("test")
+	mapping, err := testDB.Mapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The sequence the IDs come from is not a key, in any listing
+	if len(mapping) != 1 || mapping["test"] != id {
+		t.Errorf("mapping %v, want test: %d", mapping, id)
+	}
+	if ids, err := testDB.All(); err != nil || len(ids) != 1 || ids[0] != id {
+		t.Errorf("IDs %v, want [%d], %v", ids, id, err)
+	}
+	if keys := testDB.AllValue(); len(keys) != 1 || keys[0] != "test" {
+		t.Errorf("keys %v, want [test]", keys)
+	}
+}
+
+func TestAssignIDsConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mapping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testDB := &MappingIndexer{}
+	if err := testDB.Initialize(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Release()
+
+	// Each goroutine asks for 20 words, half of them shared with the next one
+	results := make([]map[string]uint64, 8)
+	var wg sync.WaitGroup
+	for g := range results {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			keys := make([]string, 0, 20)
+			for i := 10 * g; i < 10*g+20; i++ {
+				keys = append(keys, fmt.Sprintf("word%d", i))
+			}
+			ids, err := testDB.AssignIDs(keys)
+			if err != nil {
+				t.Error(err)
+			}
+			results[g] = ids
+		}(g)
+	}
+	wg.Wait()
+
+	mapping, err := testDB.Mapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mapping) != 90 {
+		t.Errorf("%d words have an ID, expected 90", len(mapping))
+	}
+	keys := make(map[uint64]string)
+	for key, id := range mapping {
+		if other, ok := keys[id]; ok {
+			t.Errorf("%s and %s have ID %d", key, other, id)
+		}
+		keys[id] = key
+	}
+	for g, ids := range results {
+		for key, id := range ids {
+			if mapping[key] != id {
+				t.Errorf("goroutine %d got ID %d for %s, the index has %d", g, id, key, mapping[key])
+			}
+		}
+	}
+}
+
 func TestDeleteDatabaseMappingIndexer(t *testing.T) {
 	wd, _ := os.Getwd()
 	testDB := &MappingIndexer{}
@@ -532,6 +619,30 @@ func TestAddGetAllPagePropetiesIndexer(t *testing.T) {
 	}
 }
 
+func TestPageDescriptionPagePropetiesIndexer(t *testing.T) {
+	page := CreatePage(0, "Test Page", "www.testpage.com", 10, time.Now())
+	page.SetDescription("A page for testing")
+	page.SetLanguage("en")
+
+	pageResult, err := stringToPage(pageToString(&page))
+	if err != nil || pageResult.GetDescription() != "A page for testing" || pageResult.GetLanguage() != "en" {
+		t.Fail()
+	}
+
+	// Pages written before descriptions existed must still decode
+	oldPage, err := stringToPage("0/page/Test Page/page/www.testpage.com/page/10/page/" + time.Now().Format(time.RFC3339))
+	if err != nil || oldPage.GetTitle() != "Test Page" || oldPage.GetDescription() != "" {
+		t.Fail()
+	}
+
+	// Truncated or malformed values are errors, not panics
+	for _, value := range []string{"", "0/page/Test Page/page/www.testpage.com", "x/page/T/page/u/page/10/page/" + time.Now().Format(time.RFC3339), "0/page/T/page/u/page/10/page/yesterday"} {
+		if _, err := stringToPage(value); err == nil {
+			t.Errorf("decoding %q did not fail", value)
+		}
+	}
+}
+
 func TestDeleteDatabasePagePropetiesIndexer(t *testing.T) {
 	wd, _ := os.Getwd()
 	testDB := &PagePropetiesIndexer{}
@@ -631,3 +742,89 @@ func TestDeleteDatabaseReverseMappingIndexer(t *testing.T) {
 	}
 
 }
+
+func TestAddGetCrawlReportIndexer(t *testing.T) {
+	wd, _ := os.Getwd()
+	testDB := &CrawlReportIndexer{}
+	err := testDB.Initialize(wd + "/dbTest/CrawlReportIndexer")
+	defer testDB.Release()
+	if err != nil {
+		t.FailNow()
+	}
+
+	report, newErr := testDB.NewReport("www.testpage.com")
+	if newErr != nil || report.ID == 0 {
+		t.FailNow()
+	}
+	report.Fetched = 2
+	report.Failures = append(report.Failures, CrawlFailure{URL: "www.testpage.com/missing", StatusCode: 404})
+	if addErr := testDB.AddReport(report); addErr != nil {
+		t.FailNow()
+	}
+
+	reportResult, resultErr := testDB.GetReportFromKey(report.ID)
+	if resultErr != nil {
+		t.FailNow()
+	}
+	if reportResult.Fetched != 2 || len(reportResult.Failures) != 1 || reportResult.Failures[0].StatusCode != 404 {
+		t.Fail()
+	}
+
+	latest, latestErr := testDB.Latest()
+	if latestErr != nil || latest.ID != report.ID {
+		t.Fail()
+	}
+}
+
+func TestRecordFieldAnalyzersMetadataIndexer(t *testing.T) {
+	wd, _ := os.Getwd()
+	testDB := &MetadataIndexer{}
+	err := testDB.Initialize(wd + "/dbTest/MetadataIndexer")
+	defer testDB.Release()
+	if err != nil {
+		t.FailNow()
+	}
+	testDB.DeleteKeyValuePair(AnalyzerMetadataPrefix + "title")
+
+	if recordErr := testDB.RecordFieldAnalyzers(map[string]string{"title": "simple"}); recordErr != nil {
+		t.FailNow()
+	}
+	analyzers, analyzersErr := testDB.GetFieldAnalyzers()
+	if analyzersErr != nil || analyzers["title"] != "simple" {
+		t.Fail()
+	}
+	if testDB.RecordFieldAnalyzers(map[string]string{"title": "standard"}) == nil {
+		t.Fail()
+	}
+}
+
+func TestAddGetTopicPageRankIndexer(t *testing.T) {
+	wd, _ := os.Getwd()
+	testDB := &TopicPageRankIndexer{}
+	err := testDB.Initialize(wd + "/dbTest/TopicPageRankIndexer")
+	defer testDB.Release()
+	if err != nil {
+		t.FailNow()
+	}
+
+	testDB.AddKeyToIndex("ai", 1, 0.5)
+	testDB.AddKeyToIndex("ai", 1, 1.5)
+	testDB.AddKeyToIndex("cse", 1, 2)
+
+	value, valueErr := testDB.GetValueFromKey("ai", 1)
+	if valueErr != nil || value != 1.5 {
+		t.Fail()
+	}
+	topics, topicsErr := testDB.Topics()
+	if topicsErr != nil || len(topics) != 2 || topics[0] != "ai" || topics[1] != "cse" {
+		t.Fail()
+	}
+
+	testDB.DeleteTopic("ai")
+	if _, err := testDB.GetValueFromKey("ai", 1); err == nil {
+		t.Fail()
+	}
+	if value, _ := testDB.GetValueFromKey("cse", 1); value != 2 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,316 @@
+package Indexer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger"
+)
+
+// BackupVersion is the version of the backup archives written, restore only
+// reads archives of this version
+const BackupVersion = 1
+
+// The archive is a tar.gz of the manifest followed by a Badger backup of each database
+const (
+	manifestFile    = "manifest.json"
+	databasesPrefix = "databases/"
+	databaseSuffix  = ".backup"
+)
+
+// Manifest describes a backup archive and the databases in it
+type Manifest struct {
+	Version   int              `json:"version"`
+	Created   time.Time        `json:"created"`
+	Databases []BackupDatabase `json:"databases"`
+}
+
+// BackupDatabase is the backup of one database, with its size and SHA-256 checksum
+type BackupDatabase struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ErrWriting is returned by Backup while a change is under way
+var ErrWriting = errors.New("the indexes are being changed, back up once the crawl, ranking or repair is done")
+
+// BeginWrite marks the start of a change to the indexes, such as a crawl, a
+// ranking or a repair, and EndWrite its end. Several changes can be under way
+// at once. A change waits for the backups being taken to end.
+func (indexes *Indexes) BeginWrite() {
+	for {
+		indexes.writeMutex.Lock()
+		if indexes.backups == 0 {
+			indexes.writes++
+			indexes.writeMutex.Unlock()
+			return
+		}
+		backedUp := indexes.backedUp
+		indexes.writeMutex.Unlock()
+		<-backedUp
+	}
+}
+
+// EndWrite marks the end of a change started by BeginWrite
+func (indexes *Indexes) EndWrite() {
+	indexes.writeMutex.Lock()
+	indexes.writes--
+	indexes.writeMutex.Unlock()
+}
+
+// beginBackup holds off new changes, or returns ErrWriting if one is under way
+func (indexes *Indexes) beginBackup() error {
+	indexes.writeMutex.Lock()
+	defer indexes.writeMutex.Unlock()
+	if indexes.writes > 0 {
+		return ErrWriting
+	}
+	if indexes.backups == 0 {
+		indexes.backedUp = make(chan struct{})
+	}
+	indexes.backups++
+	return nil
+}
+
+// endBackup lets the changes waiting for the backups start
+func (indexes *Indexes) endBackup() {
+	indexes.writeMutex.Lock()
+	defer indexes.writeMutex.Unlock()
+	indexes.backups--
+	if indexes.backups == 0 {
+		close(indexes.backedUp)
+	}
+}
+
+// Backup writes every database into one archive. While a crawl, ranking or
+// repair changes the indexes it returns ErrWriting at once rather than wait
+// for the change, which can take as long as a crawl. Otherwise it holds off
+// new changes while the databases are backed up, so they agree with each
+// other; queries keep being served. Nothing is written to w until every
+// database is backed up.
+func (indexes *Indexes) Backup(w io.Writer) (*Manifest, error) {
+	dir, err := ioutil.TempDir("", "searchengine-backup")
+	if err != nil {
+		return nil, fmt.Errorf("Error while backing up: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := indexes.backupDatabases(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	if err := writeArchiveFile(archive, manifestFile, int64(len(data)), bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("Error while writing backup: %s", err)
+	}
+	for _, backup := range manifest.Databases {
+		f, err := os.Open(filepath.Join(dir, backup.Name))
+		if err != nil {
+			return nil, fmt.Errorf("Error while writing backup: %s", err)
+		}
+		err = writeArchiveFile(archive, databasesPrefix+backup.Name+databaseSuffix, backup.Size, f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Error while writing backup of %s: %s", backup.Name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("Error while writing backup: %s", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("Error while writing backup: %s", err)
+	}
+	return manifest, nil
+}
+
+// backupDatabases backs up every database into the directory at one point
+func (indexes *Indexes) backupDatabases(dir string) (*Manifest, error) {
+	if err := indexes.beginBackup(); err != nil {
+		return nil, err
+	}
+	defer indexes.endBackup()
+	manifest := &Manifest{Version: BackupVersion, Created: time.Now()}
+	for _, database := range indexes.Databases() {
+		backup, err := backupDatabase(database, filepath.Join(dir, database.Name))
+		if err != nil {
+			return nil, fmt.Errorf("Error while backing up %s: %s", database.Name, err)
+		}
+		manifest.Databases = append(manifest.Databases, backup)
+	}
+	return manifest, nil
+}
+
+func backupDatabase(database NamedDatabase, path string) (BackupDatabase, error) {
+	backup := BackupDatabase{Name: database.Name}
+	f, err := os.Create(path)
+	if err != nil {
+		return backup, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if err := database.Database.Backup(io.MultiWriter(f, hash)); err != nil {
+		return backup, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return backup, err
+	}
+	backup.Size = info.Size()
+	backup.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return backup, nil
+}
+
+func writeArchiveFile(archive *tar.Writer, name string, size int64, r io.Reader) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: size, ModTime: time.Now()}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := io.CopyN(archive, r, size)
+	return err
+}
+
+// Restore reads an archive written by Backup into a new index directory. The
+// whole archive is checked against its manifest before anything is written,
+// and the directory must not exist or be empty.
+func Restore(r io.Reader, directory string) (*Manifest, error) {
+	if entries, err := ioutil.ReadDir(directory); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("Error while restoring: %s is not empty", directory)
+	}
+	dir, err := ioutil.TempDir("", "searchengine-restore")
+	if err != nil {
+		return nil, fmt.Errorf("Error while restoring: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := readArchive(r, dir)
+	if err != nil {
+		return nil, fmt.Errorf("Error while restoring: %s", err)
+	}
+
+	for _, backup := range manifest.Databases {
+		if err := loadDatabase(filepath.Join(dir, backup.Name), filepath.Join(directory, backup.Name)); err != nil {
+			os.RemoveAll(directory)
+			return nil, fmt.Errorf("Error while restoring %s: %s", backup.Name, err)
+		}
+	}
+	return manifest, nil
+}
+
+// readArchive checks the manifest and extracts the backup of every database
+// into dir, checking its size and checksum
+func readArchive(r io.Reader, dir string) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	archive := tar.NewReader(gz)
+
+	header, err := archive.Next()
+	if err != nil || header.Name != manifestFile {
+		return nil, fmt.Errorf("archive does not start with %s", manifestFile)
+	}
+	manifest := &Manifest{}
+	if err := json.NewDecoder(archive).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %s", err)
+	}
+	if manifest.Version != BackupVersion {
+		return nil, fmt.Errorf("archive version %d cannot be restored, expected version %d", manifest.Version, BackupVersion)
+	}
+
+	expected := make(map[string]BackupDatabase)
+	for _, backup := range manifest.Databases {
+		expected[backup.Name] = backup
+	}
+	for _, database := range (&Indexes{}).Databases() {
+		if _, ok := expected[database.Name]; !ok {
+			return nil, fmt.Errorf("archive has no backup of %s", database.Name)
+		}
+	}
+
+	extracted := make(map[string]bool)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(header.Name, databasesPrefix), databaseSuffix)
+		backup, ok := expected[name]
+		if !ok || extracted[name] || header.Name != databasesPrefix+name+databaseSuffix {
+			return nil, fmt.Errorf("unexpected file %s in archive", header.Name)
+		}
+		if err := extractDatabase(archive, filepath.Join(dir, name), backup); err != nil {
+			return nil, fmt.Errorf("backup of %s: %s", name, err)
+		}
+		extracted[name] = true
+	}
+	for name := range expected {
+		if !extracted[name] {
+			return nil, fmt.Errorf("backup of %s is missing from the archive", name)
+		}
+	}
+	return manifest, nil
+}
+
+func extractDatabase(r io.Reader, path string, backup BackupDatabase) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), r)
+	if err != nil {
+		return err
+	}
+	if size != backup.Size {
+		return fmt.Errorf("size is %d bytes, expected %d", size, backup.Size)
+	}
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != backup.SHA256 {
+		return fmt.Errorf("checksum is %s, expected %s", checksum, backup.SHA256)
+	}
+	return nil
+}
+
+// loadDatabase loads a backup into a new database. It opens Badger directly,
+// as the sequences of the indexers would overwrite the restored ones on release.
+func loadDatabase(backupPath string, path string) error {
+	f, err := os.Open(backupPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := os.MkdirAll(path, 0774); err != nil {
+		return err
+	}
+	opts := badger.DefaultOptions
+	opts.Dir = path
+	opts.ValueDir = path
+	db, err := badger.Open(opts)
+	if err != nil {
+		return err
+	}
+	if err := db.Load(f); err != nil {
+		db.Close()
+		return err
+	}
+	return db.Close()
+}
@@ -0,0 +1,215 @@
+package Indexer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testArchive writes an archive with a backup of every database, after
+// letting change alter the manifest
+func testArchive(t *testing.T, change func(manifest *Manifest, contents map[string]string)) *bytes.Buffer {
+	manifest := &Manifest{Version: BackupVersion}
+	contents := make(map[string]string)
+	for _, database := range (&Indexes{}).Databases() {
+		content := "backup of " + database.Name
+		hash := sha256.Sum256([]byte(content))
+		manifest.Databases = append(manifest.Databases, BackupDatabase{database.Name, int64(len(content)), hex.EncodeToString(hash[:])})
+		contents[database.Name] = content
+	}
+	change(manifest, contents)
+
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	archive := tar.NewWriter(gz)
+	data, _ := json.Marshal(manifest)
+	if err := writeArchiveFile(archive, manifestFile, int64(len(data)), bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range contents {
+		if err := writeArchiveFile(archive, databasesPrefix+name+databaseSuffix, int64(len(content)), strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive.Close()
+	gz.Close()
+	return &buffer
+}
+
+func TestReadArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := readArchive(testArchive(t, func(*Manifest, map[string]string) {}), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Databases) != len((&Indexes{}).Databases()) {
+		t.Errorf("manifest has %d databases", len(manifest.Databases))
+	}
+	if data, err := ioutil.ReadFile(dir + "/wordIndex"); err != nil || string(data) != "backup of wordIndex" {
+		t.Errorf("extracted %q, %v", data, err)
+	}
+}
+
+// restoreFails restores an archive altered by change, which must fail without
+// creating the index directory
+func restoreFails(t *testing.T, change func(manifest *Manifest, contents map[string]string)) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	directory := filepath.Join(dir, "db")
+	if _, err := Restore(testArchive(t, change), directory); err == nil {
+		t.Error("archive restored")
+	}
+	if _, err := os.Stat(directory); !os.IsNotExist(err) {
+		t.Errorf("index directory written: %v", err)
+	}
+}
+
+func TestRestoreNewerArchive(t *testing.T) {
+	restoreFails(t, func(manifest *Manifest, contents map[string]string) {
+		manifest.Version = BackupVersion + 1
+	})
+}
+
+func TestRestoreCorruptedBackup(t *testing.T) {
+	// Same size, different contents
+	restoreFails(t, func(manifest *Manifest, contents map[string]string) {
+		contents["wordIndex"] = "backup of wordIndeX"
+	})
+	restoreFails(t, func(manifest *Manifest, contents map[string]string) {
+		contents["wordIndex"] += "!"
+	})
+}
+
+func TestRestoreIncompleteArchive(t *testing.T) {
+	restoreFails(t, func(manifest *Manifest, contents map[string]string) {
+		manifest.Databases = manifest.Databases[:len(manifest.Databases)-1]
+	})
+	restoreFails(t, func(manifest *Manifest, contents map[string]string) {
+		delete(contents, "hubIndex")
+	})
+}
+
+func TestRestoreUnexpectedFile(t *testing.T) {
+	restoreFails(t, func(manifest *Manifest, contents map[string]string) {
+		contents["extraIndex"] = ""
+	})
+}
+
+func TestBackupConsistent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexes, err := Open(filepath.Join(dir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+
+	// A change linking two pages writes one direction of the link, then the
+	// other, while a backup is asked for
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		indexes.BeginWrite()
+		defer indexes.EndWrite()
+		ids := make(map[uint64]string)
+		for _, url := range []string{"https://www.cse.ust.hk/a", "https://www.cse.ust.hk/b"} {
+			id, _ := indexes.DocumentIndexer.AddKeyToIndex(url)
+			ids[id] = url
+		}
+		indexes.ReverseDocumentIndexer.AddKeys(ids)
+		a, _ := indexes.DocumentIndexer.GetValueFromKey("https://www.cse.ust.hk/a")
+		b, _ := indexes.DocumentIndexer.GetValueFromKey("https://www.cse.ust.hk/b")
+		indexes.ParentChildDocumentForwardIndexer.AddIdListToKey(a, []uint64{b})
+		close(started)
+		<-release
+		indexes.ChildParentDocumentForwardIndexer.AddIdListToKey(b, []uint64{a})
+	}()
+	<-started
+	// The backup is refused at once rather than wait for the change
+	var archive bytes.Buffer
+	if _, err := indexes.Backup(&archive); err != ErrWriting {
+		t.Fatalf("backup during a change gave %v, expected ErrWriting", err)
+	}
+	if archive.Len() != 0 {
+		t.Error("refused backup wrote to the archive")
+	}
+	close(release)
+	<-done
+	if _, err := indexes.Backup(&archive); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := filepath.Join(dir, "restored")
+	if _, err := Restore(&archive, restored); err != nil {
+		t.Fatal(err)
+	}
+	restoredIndexes, err := Open(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restoredIndexes.Close()
+	problems, err := restoredIndexes.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		t.Errorf("restored backup: %s", problem)
+	}
+	b, _ := restoredIndexes.DocumentIndexer.GetValueFromKey("https://www.cse.ust.hk/b")
+	if parents, _ := restoredIndexes.ChildParentDocumentForwardIndexer.GetIdListFromKey(b); len(parents) == 0 {
+		t.Error("backup taken before the change ended")
+	}
+}
+
+func TestBackupHoldsOffWrites(t *testing.T) {
+	indexes := &Indexes{}
+	if err := indexes.beginBackup(); err != nil {
+		t.Fatal(err)
+	}
+	began := make(chan struct{})
+	go func() {
+		indexes.BeginWrite()
+		close(began)
+	}()
+	select {
+	case <-began:
+		t.Fatal("change began during a backup")
+	case <-time.After(50 * time.Millisecond):
+	}
+	indexes.endBackup()
+	select {
+	case <-began:
+	case <-time.After(time.Second):
+		t.Fatal("change did not begin once the backup was taken")
+	}
+	if err := indexes.beginBackup(); err != ErrWriting {
+		t.Errorf("backup during a change gave %v", err)
+	}
+	indexes.EndWrite()
+	if err := indexes.beginBackup(); err != nil {
+		t.Errorf("backup after the change ended gave %v", err)
+	}
+}
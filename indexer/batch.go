@@ -0,0 +1,45 @@
+package Indexer
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger"
+)
+
+// batchUpdate writes n entries in as few transactions as they fit in. When a
+// transaction grows too big it is committed with the entries written so far,
+// and the rest go into the next one.
+func batchUpdate(db *badger.DB, n int, write func(txn *badger.Txn, i int) error) error {
+	for start := 0; start < n; {
+		written := start
+		err := db.Update(func(txn *badger.Txn) error {
+			for ; written < n; written++ {
+				err := write(txn, written)
+				if err == badger.ErrTxnTooBig {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if written == start {
+			return fmt.Errorf("entry %d does not fit in a transaction", start)
+		}
+		start = written
+	}
+	return nil
+}
+
+// SmallTransactionOptions are badger options whose transactions hold a few
+// kilobytes, values included, to test writes split over many transactions
+func SmallTransactionOptions() badger.Options {
+	options := badger.DefaultOptions
+	options.MaxTableSize = 1 << 16
+	options.ValueThreshold = 1 << 15
+	return options
+}
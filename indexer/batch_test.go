@@ -0,0 +1,165 @@
+package Indexer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger"
+)
+
+func TestMergeInvertedFiles(t *testing.T) {
+	list := []InvertedFile{{1, []uint64{0}}, {3, []uint64{2, 5}}, {7, []uint64{1}}}
+	merged := mergeInvertedFiles(list, []InvertedFile{{5, []uint64{4}}, {3, []uint64{9}}, {2, []uint64{0}}})
+	expected := []InvertedFile{{1, []uint64{0}}, {2, []uint64{0}}, {3, []uint64{9}}, {5, []uint64{4}}, {7, []uint64{1}}}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("got %v, expected %v", merged, expected)
+	}
+	if merged = mergeInvertedFiles(nil, []InvertedFile{{4, []uint64{1}}}); len(merged) != 1 || merged[0].pageID != 4 {
+		t.Errorf("got %v", merged)
+	}
+}
+
+func TestBatchUpdateSplit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	opts := SmallTransactionOptions()
+	opts.Dir = dir
+	opts.ValueDir = dir
+	db, err := badger.Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Entries of 1KB, a few per transaction
+	value := strings.Repeat("x", 1<<10)
+	calls := make([]int, 0)
+	splits := make([]int, 0)
+	err = batchUpdate(db, 50, func(txn *badger.Txn, i int) error {
+		calls = append(calls, i)
+		err := txn.Set([]byte(fmt.Sprintf("key %02d", i)), []byte(fmt.Sprintf("%02d%s", i, value)))
+		if err == badger.ErrTxnTooBig {
+			splits = append(splits, len(calls)-1)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(splits) == 0 {
+		t.Fatal("entries written in one transaction")
+	}
+	// The next transaction starts again at the entry that did not fit
+	for _, split := range splits {
+		if split+1 >= len(calls) || calls[split+1] != calls[split] {
+			t.Errorf("entry %d did not fit, then entry %v was written", calls[split], calls[split+1:])
+		}
+	}
+	if len(calls) != 50+len(splits) {
+		t.Errorf("%d writes for 50 entries and %d splits", len(calls), len(splits))
+	}
+	db.View(func(txn *badger.Txn) error {
+		for i := 0; i < 50; i++ {
+			item, err := txn.Get([]byte(fmt.Sprintf("key %02d", i)))
+			if err != nil {
+				t.Errorf("entry %d: %s", i, err)
+				continue
+			}
+			item.Value(func(v []byte) error {
+				if string(v[:2]) != fmt.Sprintf("%02d", i) {
+					t.Errorf("entry %d holds the value of entry %s", i, v[:2])
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+
+	// An entry larger than a transaction is an error, not a loop
+	err = batchUpdate(db, 1, func(txn *badger.Txn, i int) error {
+		return txn.Set([]byte("large"), []byte(strings.Repeat(value, 20)))
+	})
+	if err == nil {
+		t.Error("entry larger than a transaction written")
+	}
+}
+
+func TestAddInvertedFilesExisting(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inverted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexer := &InvertedFileIndexer{}
+	if err := indexer.Initialize(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer indexer.Release()
+	indexer.cache = newReadCache("contentInvertedIndex", 1<<20)
+
+	indexer.AddKeyToIndexOrUpdate(1, InvertedFile{3, []uint64{0}})
+	indexer.AddKeyToIndexOrUpdate(1, InvertedFile{7, []uint64{2, 4}})
+	// Read once, so the list on disk is also cached
+	indexer.GetInvertedFileFromKey(1)
+
+	err = indexer.AddInvertedFiles(map[uint64][]InvertedFile{
+		1: {{5, []uint64{1}}, {3, []uint64{6, 8}}},
+		2: {{5, []uint64{0}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, _ := indexer.GetInvertedFileFromKey(1)
+	expected := []InvertedFile{{3, []uint64{6, 8}}, {5, []uint64{1}}, {7, []uint64{2, 4}}}
+	if !reflect.DeepEqual(list, expected) {
+		t.Errorf("merged list is %v, expected %v", list, expected)
+	}
+	if list, _ := indexer.GetInvertedFileFromKey(2); !reflect.DeepEqual(list, []InvertedFile{{5, []uint64{0}}}) {
+		t.Errorf("new list is %v", list)
+	}
+}
+
+func TestAddIdLists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forward")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexer := &ForwardIndexer{}
+	// Small transactions, so the lists are split over several
+	if err := indexer.InitializeWithOptions(dir, SmallTransactionOptions()); err != nil {
+		t.Fatal(err)
+	}
+	defer indexer.Release()
+
+	lists := make(map[uint64][]uint64)
+	for page := uint64(1); page <= 200; page++ {
+		lists[page] = make([]uint64, 0)
+		for child := uint64(1000); child < 1020; child++ {
+			lists[page] = append(lists[page], child)
+		}
+	}
+	lists[201] = []uint64{}
+	if err := indexer.AddIdLists(lists); err != nil {
+		t.Fatal(err)
+	}
+	all, err := indexer.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(lists) {
+		t.Fatalf("%d lists written, expected %d", len(all), len(lists))
+	}
+	for page, list := range lists {
+		if got, _ := indexer.GetIdListFromKey(page); len(got) != len(list) || (len(list) > 0 && !reflect.DeepEqual(got, list)) {
+			t.Errorf("list of %d is %v, expected %v", page, got, list)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package Indexer
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CacheBudgets are the bytes the read cache of each hot table may hold. A
+// budget of 0 leaves the tables uncached.
+type CacheBudgets struct {
+	// Posting lists, for each inverted index
+	Postings int64
+	// Page properties
+	Pages int64
+	// Word frequency lists, for the bodies and for the titles
+	WordLists int64
+}
+
+// CacheStats counts the lookups of a read cache and what it holds
+type CacheStats struct {
+	Table     string  `json:"table"`
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	HitRate   float64 `json:"hit_rate"`
+	Evictions uint64  `json:"evictions"`
+	Entries   int     `json:"entries"`
+	Bytes     int64   `json:"bytes"`
+	Budget    int64   `json:"budget"`
+}
+
+// readCache keeps the decoded values of recently read keys of one table,
+// least recently used first out when over its budget. The indexer reads
+// through it and drops the keys it writes. A nil cache caches nothing.
+//
+// A reader takes the version before reading the database and adds the value
+// with it. Every remove bumps the version, so a value read before a write
+// committed is not kept after the write dropped the key.
+type readCache struct {
+	mutex   sync.Mutex
+	table   string
+	version uint64
+	// Estimated bytes of the values held, at most budget
+	budget int64
+	bytes  int64
+	// Most recently used first
+	order   *list.List
+	entries map[uint64]*list.Element
+	stats   CacheStats
+}
+
+type cacheEntry struct {
+	key   uint64
+	value interface{}
+	size  int64
+}
+
+func newReadCache(table string, budget int64) *readCache {
+	if budget <= 0 {
+		return nil
+	}
+	return &readCache{
+		table:   table,
+		budget:  budget,
+		order:   list.New(),
+		entries: make(map[uint64]*list.Element),
+	}
+}
+
+func (cache *readCache) get(key uint64) (interface{}, bool) {
+	if cache == nil {
+		return nil, false
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	element, ok := cache.entries[key]
+	if !ok {
+		cache.stats.Misses++
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	cache.stats.Hits++
+	return element.Value.(*cacheEntry).value, true
+}
+
+// begin returns the version to add a value read from now on with
+func (cache *readCache) begin() uint64 {
+	if cache == nil {
+		return 0
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.version
+}
+
+// add keeps a value of an estimated size read since version. Values larger
+// than the budget, or read before a key was removed, are not kept.
+func (cache *readCache) add(key uint64, value interface{}, size int64, version uint64) {
+	if cache == nil || size > cache.budget {
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if version != cache.version {
+		return
+	}
+	if element, ok := cache.entries[key]; ok {
+		cache.removeElement(element)
+	}
+	cache.entries[key] = cache.order.PushFront(&cacheEntry{key, value, size})
+	cache.bytes += size
+	for cache.bytes > cache.budget {
+		cache.removeElement(cache.order.Back())
+		cache.stats.Evictions++
+	}
+}
+
+// remove drops a key after it is written
+func (cache *readCache) remove(key uint64) {
+	if cache == nil {
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.version++
+	if element, ok := cache.entries[key]; ok {
+		cache.removeElement(element)
+	}
+}
+
+func (cache *readCache) removeElement(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+	cache.order.Remove(element)
+	delete(cache.entries, entry.key)
+	cache.bytes -= entry.size
+}
+
+func (cache *readCache) counters() CacheStats {
+	if cache == nil {
+		return CacheStats{}
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	stats := cache.stats
+	stats.Table = cache.table
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	stats.Entries = cache.order.Len()
+	stats.Bytes = cache.bytes
+	stats.Budget = cache.budget
+	return stats
+}
+
+// Sizes of the cached values, estimated from their slices and strings
+func postingsSize(list []InvertedFile) int64 {
+	size := int64(24)
+	for _, invertedFile := range list {
+		size += 32 + 8*int64(len(invertedFile.wordPositions))
+	}
+	return size
+}
+
+func pageSize(page *Page) int64 {
+	return 128 + int64(len(page.title)+len(page.url)+len(page.description)+len(page.language))
+}
+
+func wordListSize(list []WordFrequency) int64 {
+	return 24 + 16*int64(len(list))
+}
+
+// EnableCaches makes the hot tables read through caches within the budgets,
+// for a server answering queries. Each table has a cache of its own, so the
+// reads of one do not evict the values of another. Writes through the
+// indexers keep the caches up to date.
+func (indexes *Indexes) EnableCaches(budgets CacheBudgets) {
+	indexes.caches = nil
+	for _, database := range indexes.Databases() {
+		var cache *readCache
+		switch indexer := database.Database.(type) {
+		case *InvertedFileIndexer:
+			cache = newReadCache(database.Name, budgets.Postings)
+			indexer.cache = cache
+		case *PagePropetiesIndexer:
+			cache = newReadCache(database.Name, budgets.Pages)
+			indexer.cache = cache
+		case *DocumentWordForwardIndexer:
+			cache = newReadCache(database.Name, budgets.WordLists)
+			indexer.cache = cache
+		default:
+			continue
+		}
+		indexes.caches = append(indexes.caches, cache)
+	}
+}
+
+// CacheStats returns the counters of the enabled read caches
+func (indexes *Indexes) CacheStats() []CacheStats {
+	stats := make([]CacheStats, 0)
+	for _, cache := range indexes.caches {
+		if cache != nil {
+			stats = append(stats, cache.counters())
+		}
+	}
+	return stats
+}
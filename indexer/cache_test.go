@@ -0,0 +1,190 @@
+package Indexer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestReadCache(t *testing.T) {
+	cache := newReadCache("contentInvertedIndex", 100)
+	cache.add(1, "word 1", 40, cache.begin())
+	cache.add(2, "word 2", 40, cache.begin())
+	if value, ok := cache.get(1); !ok || value != "word 1" {
+		t.Fatalf("got %v, %t", value, ok)
+	}
+	// Over the budget, the least recently used value goes
+	cache.add(3, "word 3", 40, cache.begin())
+	if _, ok := cache.get(2); ok {
+		t.Error("least recently used value is kept over the budget")
+	}
+	if value, ok := cache.get(1); !ok || value != "word 1" {
+		t.Errorf("got %v, %t", value, ok)
+	}
+	// Values larger than the budget are not kept
+	cache.add(4, "large", 101, cache.begin())
+	if _, ok := cache.get(4); ok {
+		t.Error("value over the budget is kept")
+	}
+	cache.remove(1)
+	if _, ok := cache.get(1); ok {
+		t.Error("removed value is kept")
+	}
+
+	// A value read before a key was removed is not kept
+	version := cache.begin()
+	cache.remove(5)
+	cache.add(5, "stale", 40, version)
+	if _, ok := cache.get(5); ok {
+		t.Error("value read before the remove is kept")
+	}
+
+	stats := cache.counters()
+	if stats.Table != "contentInvertedIndex" || stats.Hits != 2 || stats.Misses != 4 || stats.Evictions != 1 || stats.Entries != 1 || stats.Bytes != 40 || stats.HitRate != 2.0/6 {
+		t.Errorf("got %+v", stats)
+	}
+}
+
+func TestEnableCaches(t *testing.T) {
+	dir, err := ioutil.TempDir("", "caches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexes, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+	for word := uint64(1); word <= 20; word++ {
+		indexes.TitleInvertedIndexer.AddKeyToIndexOrUpdate(word, InvertedFile{1, []uint64{0}})
+		indexes.ContentInvertedIndexer.AddKeyToIndexOrUpdate(word, InvertedFile{1, []uint64{0}})
+	}
+	// Room for a few posting lists in each inverted index
+	budget := 4 * postingsSize([]InvertedFile{{1, []uint64{0}}})
+	indexes.EnableCaches(CacheBudgets{Postings: budget, Pages: 1 << 20})
+
+	indexes.ContentInvertedIndexer.GetInvertedFileFromKey(1)
+	// Reading more titles than fit does not evict the content posting list
+	for word := uint64(1); word <= 20; word++ {
+		indexes.TitleInvertedIndexer.GetInvertedFileFromKey(word)
+	}
+	indexes.ContentInvertedIndexer.GetInvertedFileFromKey(1)
+
+	stats := make(map[string]CacheStats)
+	for _, table := range indexes.CacheStats() {
+		stats[table.Table] = table
+	}
+	if len(stats) != 8 {
+		t.Errorf("caches of %d tables, expected the 7 inverted indexes and the pages", len(stats))
+	}
+	if content := stats["contentInvertedIndex"]; content.Hits != 1 || content.Budget != budget {
+		t.Errorf("content postings cache %+v", content)
+	}
+	if title := stats["titleInvertedIndex"]; title.Evictions == 0 || title.Bytes > budget {
+		t.Errorf("title postings cache %+v", title)
+	}
+	if _, ok := stats["documentWordForwardIndex"]; ok {
+		t.Error("word lists cached without a budget")
+	}
+}
+
+func TestReadCacheConcurrentWrites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "caches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexes, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+	indexes.EnableCaches(CacheBudgets{Postings: 1 << 20, Pages: 1 << 20, WordLists: 1 << 20})
+
+	const writes = 200
+	var readers sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				indexes.PagePropertiesIndexer.GetPagePropertiesFromKey(1)
+				indexes.ContentInvertedIndexer.GetInvertedFileFromKey(1)
+				indexes.DocumentWordForwardIndexer.GetWordFrequencyListFromKey(1)
+			}
+		}()
+	}
+	for i := uint64(1); i <= writes; i++ {
+		page := Page{id: 1, title: fmt.Sprint("title ", i)}
+		if err := indexes.PagePropertiesIndexer.AddPages(map[uint64]Page{1: page}); err != nil {
+			t.Fatal(err)
+		}
+		if err := indexes.ContentInvertedIndexer.AddInvertedFiles(map[uint64][]InvertedFile{1: {{1, []uint64{i}}}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := indexes.DocumentWordForwardIndexer.AddWordFrequencyLists(map[uint64][]WordFrequency{1: {{1, i}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	readers.Wait()
+
+	// Whatever the readers cached, the last write is what is read back
+	page, err := indexes.PagePropertiesIndexer.GetPagePropertiesFromKey(1)
+	if err != nil || page.title != fmt.Sprint("title ", writes) {
+		t.Errorf("cached page %q, %v", page.title, err)
+	}
+	postings, err := indexes.ContentInvertedIndexer.GetInvertedFileFromKey(1)
+	if err != nil || len(postings) != 1 || postings[0].wordPositions[0] != writes {
+		t.Errorf("cached postings %v, %v", postings, err)
+	}
+	words, err := indexes.DocumentWordForwardIndexer.GetWordFrequencyListFromKey(1)
+	if err != nil || len(words) != 1 || words[0].Frequency != writes {
+		t.Errorf("cached word list %v, %v", words, err)
+	}
+}
+
+func TestReadCacheDisabled(t *testing.T) {
+	cache := newReadCache("pages", 0)
+	if cache != nil {
+		t.Fatal("cache without a budget is enabled")
+	}
+	cache.add(1, "page", 1, cache.begin())
+	if _, ok := cache.get(1); ok {
+		t.Error("disabled cache returns a value")
+	}
+	cache.remove(1)
+}
+
+func TestMaxFrequency(t *testing.T) {
+	// The last word of the list used to be skipped, giving 5, so the word
+	// seen 7 times was weighted 7/5 instead of 1
+	if max := MaxFrequency([]WordFrequency{{1, 2}, {2, 5}, {3, 7}}); max != 7 {
+		t.Errorf("max frequency %d, expected 7", max)
+	}
+	if max := MaxFrequency([]WordFrequency{{1, 4}}); max != 4 {
+		t.Errorf("max frequency of one word %d, expected 4", max)
+	}
+	if max := MaxFrequency(nil); max != 0 {
+		t.Errorf("max frequency of no words %d", max)
+	}
+}
+
+func TestMaxTermFrequencies(t *testing.T) {
+	max := maxTermFrequencies(map[uint64][]WordFrequency{
+		1: {{1, 2}, {2, 5}, {3, 7}},
+		2: {},
+	})
+	if max[1] != 7 || max[2] != 0 {
+		t.Errorf("got %v", max)
+	}
+}
@@ -0,0 +1,78 @@
+package Indexer
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CollectionStats are the figures about the whole collection that the
+// ranking uses for every posting of a query
+type CollectionStats struct {
+	// Documents with a body word list, and with a title word list
+	Documents      uint64
+	TitleDocuments uint64
+	// Highest frequency of a word in the body of each document
+	MaxTermFrequency map[uint64]uint64
+}
+
+// Collection shares one snapshot of the collection statistics between
+// queries. The snapshot is taken again once the word lists are written.
+//
+// Writes are counted by the indexers of this process, which are the only
+// ones that can write: Badger locks the databases for the process that opened
+// them, so a crawl, a ranking or a repair must run inside the server, as its
+// admin jobs do, for queries to see it.
+type Collection struct {
+	mutex sync.Mutex
+	body  *DocumentWordForwardIndexer
+	title *DocumentWordForwardIndexer
+	stats *CollectionStats
+	// Writes to the word lists when the snapshot was taken
+	writes uint64
+	// Snapshots taken
+	builds uint64
+}
+
+func NewCollection(body *DocumentWordForwardIndexer, title *DocumentWordForwardIndexer) *Collection {
+	return &Collection{body: body, title: title}
+}
+
+// Stats returns the current snapshot, taking a new one if the word lists
+// changed since the last one. The snapshot must not be modified.
+func (collection *Collection) Stats() (*CollectionStats, error) {
+	collection.mutex.Lock()
+	defer collection.mutex.Unlock()
+	writes := collection.body.Writes() + collection.title.Writes()
+	if collection.stats != nil && writes == collection.writes {
+		return collection.stats, nil
+	}
+
+	frequencies, err := collection.body.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading collection stats: %s", err)
+	}
+	stats := &CollectionStats{
+		Documents:        uint64(len(frequencies)),
+		TitleDocuments:   collection.title.GetSize(),
+		MaxTermFrequency: maxTermFrequencies(frequencies),
+	}
+	collection.stats = stats
+	collection.writes = writes
+	collection.builds++
+	return stats, nil
+}
+
+// Builds counts the snapshots taken
+func (collection *Collection) Builds() uint64 {
+	collection.mutex.Lock()
+	defer collection.mutex.Unlock()
+	return collection.builds
+}
+
+func maxTermFrequencies(frequencies map[uint64][]WordFrequency) map[uint64]uint64 {
+	result := make(map[uint64]uint64, len(frequencies))
+	for documentID, list := range frequencies {
+		result[documentID] = MaxFrequency(list)
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package Indexer
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestCollectionWrites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexes, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+
+	indexes.DocumentWordForwardIndexer.AddWordFrequencyListToKey(1, []WordFrequency{{1, 3}})
+	stats, err := indexes.Collection.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := indexes.Collection.Stats(); again != stats {
+		t.Error("snapshot taken again without a write")
+	}
+
+	// A write through the indexers, as a crawl run by the server makes
+	indexes.DocumentWordForwardIndexer.AddWordFrequencyLists(map[uint64][]WordFrequency{2: {{1, 5}}})
+	stats, _ = indexes.Collection.Stats()
+	if stats.Documents != 2 || stats.MaxTermFrequency[2] != 5 {
+		t.Errorf("snapshot after the write %+v", stats)
+	}
+
+	// No other process can write behind the counters
+	if other, err := Open(dir); err == nil {
+		other.Close()
+		t.Error("index opened twice")
+	}
+}
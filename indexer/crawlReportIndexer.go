@@ -0,0 +1,176 @@
+package Indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dgraph-io/badger"
+)
+
+// Crawl run ID -> crawl report Indexer
+type CrawlReportIndexer struct {
+	db           *badger.DB
+	sequence     *badger.Sequence
+	databasePath string
+}
+
+// Key of the run ID sequence, kept apart from the 8 byte run ID keys
+var crawlRunSequenceKey = []byte("crawlRunSequence")
+
+// CrawlReport records what happened during one crawl run
+type CrawlReport struct {
+	ID        uint64         `json:"id"`
+	Root      string         `json:"root"`
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	Finished  bool           `json:"finished"`
+	Fetched   uint64         `json:"fetched"`
+	Indexed   uint64         `json:"indexed"`
+	Skipped   uint64         `json:"skipped"`
+	Unchanged uint64         `json:"unchanged"`
+	Failures  []CrawlFailure `json:"failures"`
+}
+
+// CrawlFailure is a URL that could not be fetched or checked
+type CrawlFailure struct {
+	URL           string   `json:"url"`
+	Referrer      string   `json:"referrer,omitempty"`
+	StatusCode    int      `json:"status_code"`
+	Error         string   `json:"error,omitempty"`
+	RedirectChain []string `json:"redirect_chain,omitempty"`
+}
+
+// After initializing the CrawlReportIndexer, we need to call defer CrawlReportIndexer.Release()
+func (crawlReportIndexer *CrawlReportIndexer) Initialize(path string) error {
+	return crawlReportIndexer.InitializeWithOptions(path, badger.DefaultOptions)
+}
+
+// InitializeWithOptions opens the database in a directory with badger options
+func (crawlReportIndexer *CrawlReportIndexer) InitializeWithOptions(path string, options badger.Options) error {
+	if err := os.MkdirAll(path, 0774); err != nil {
+		return err
+	}
+	options.Dir = path
+	options.ValueDir = path
+	db, err := badger.Open(options)
+	if err != nil {
+		return fmt.Errorf("Error while initializing: %s", err)
+	}
+	crawlReportIndexer.db = db
+	crawlReportIndexer.sequence, _ = db.GetSequence(crawlRunSequenceKey, 100)
+	crawlReportIndexer.databasePath = path
+	return err
+}
+
+func (crawlReportIndexer *CrawlReportIndexer) Release() error {
+	crawlReportIndexer.sequence.Release()
+	return crawlReportIndexer.db.Close()
+}
+
+// Size returns the bytes used on disk by the LSM tree and the value log
+func (crawlReportIndexer *CrawlReportIndexer) Size() (int64, int64) {
+	return crawlReportIndexer.db.Size()
+}
+
+// Backup writes a snapshot of the database, it can be taken while the database is in use
+func (crawlReportIndexer *CrawlReportIndexer) Backup(w io.Writer) error {
+	_, err := crawlReportIndexer.db.Backup(w, 0)
+	return err
+}
+
+// NewReport starts the report of a new crawl run with a fresh run ID
+func (crawlReportIndexer *CrawlReportIndexer) NewReport(root string) (*CrawlReport, error) {
+	id, err := crawlReportIndexer.sequence.Next()
+	if err != nil {
+		return nil, fmt.Errorf("Error when creating crawl report: %s", err)
+	}
+	// Sequences start at 0, run IDs start at 1
+	report := &CrawlReport{ID: id + 1, Root: root, Start: time.Now(), Failures: []CrawlFailure{}}
+	return report, crawlReportIndexer.AddReport(report)
+}
+
+// AddReport stores a report, replacing an earlier version with the same run ID
+func (crawlReportIndexer *CrawlReportIndexer) AddReport(report *CrawlReport) error {
+	value, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("Error in adding Key to Index: %s", err)
+	}
+	err = crawlReportIndexer.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(uint64ToByte(report.ID), value)
+	})
+	if err != nil {
+		err = fmt.Errorf("Error in adding Key to Index: %s", err)
+	}
+	return err
+}
+
+func (crawlReportIndexer *CrawlReportIndexer) GetReportFromKey(id uint64) (CrawlReport, error) {
+	var report CrawlReport
+	err := crawlReportIndexer.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(uint64ToByte(id))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &report)
+		})
+	})
+	if err != nil {
+		err = fmt.Errorf("Error when getting crawl report from key: %s", err)
+	}
+	return report, err
+}
+
+// All returns every stored report, oldest run first
+func (crawlReportIndexer *CrawlReportIndexer) All() ([]CrawlReport, error) {
+	reports := []CrawlReport{}
+	err := crawlReportIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			if bytes.Equal(item.Key(), crawlRunSequenceKey) {
+				continue
+			}
+			var report CrawlReport
+			err := item.Value(func(v []byte) error {
+				return json.Unmarshal(v, &report)
+			})
+			if err != nil {
+				return err
+			}
+			reports = append(reports, report)
+		}
+		return nil
+	})
+	return reports, err
+}
+
+// Latest returns the report of the most recent crawl run
+func (crawlReportIndexer *CrawlReportIndexer) Latest() (CrawlReport, error) {
+	reports, err := crawlReportIndexer.All()
+	if err != nil {
+		return CrawlReport{}, err
+	}
+	if len(reports) == 0 {
+		return CrawlReport{}, fmt.Errorf("Error when getting latest crawl report: %s", badger.ErrKeyNotFound)
+	}
+	return reports[len(reports)-1], nil
+}
+
+func (crawlReportIndexer *CrawlReportIndexer) DeleteKeyValuePair(id uint64) error {
+	err := crawlReportIndexer.db.Update(func(txn *badger.Txn) error {
+		err := txn.Delete(uint64ToByte(id))
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("Error when deleting value from key: %s", err)
+	}
+	return err
+}
@@ -2,16 +2,22 @@ package Indexer
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/dgraph-io/badger"
 )
 
 type DocumentWordForwardIndexer struct {
+	// Lists written since opening, first for 64 bit alignment of atomic access
+	writes       uint64
 	db           *badger.DB
 	databasePath string
+	// Word lists read recently, nil when not caching
+	cache *readCache
 }
 
 type WordFrequency struct {
@@ -37,6 +43,17 @@ func CreateWordFrequency(id uint64, f uint64) WordFrequency {
 	return WordFrequency{id, f}
 }
 
+// MaxFrequency returns the highest frequency of a word in a word list, 0 if it is empty
+func MaxFrequency(words []WordFrequency) uint64 {
+	var max uint64
+	for _, word := range words {
+		if word.Frequency > max {
+			max = word.Frequency
+		}
+	}
+	return max
+}
+
 func wordFrequencyToString(word *WordFrequency) string {
 	return strconv.Itoa(int(word.WordID)) + " " + strconv.Itoa(int(word.Frequency))
 }
@@ -49,13 +66,17 @@ func stringToWordFrequency(str string) WordFrequency {
 }
 
 func (documentWordForwardIndexer *DocumentWordForwardIndexer) Initialize(path string) error {
+	return documentWordForwardIndexer.InitializeWithOptions(path, badger.DefaultOptions)
+}
+
+// InitializeWithOptions opens the database in a directory with badger options
+func (documentWordForwardIndexer *DocumentWordForwardIndexer) InitializeWithOptions(path string, options badger.Options) error {
 	if err := os.MkdirAll(path, 0774); err != nil {
 		return err
 	}
-	opts := badger.DefaultOptions
-	opts.Dir = path
-	opts.ValueDir = path
-	db, err := badger.Open(opts)
+	options.Dir = path
+	options.ValueDir = path
+	db, err := badger.Open(options)
 	if err != nil {
 		return fmt.Errorf("Error while initializing: %s", err)
 	}
@@ -68,18 +89,19 @@ func (documentWordForwardIndexer *DocumentWordForwardIndexer) Release() error {
 	return documentWordForwardIndexer.db.Close()
 }
 
-func (documentWordForwardIndexer *DocumentWordForwardIndexer) Backup() error {
-	fmt.Println("Doing Database Backup")
-	f, err := os.Create(documentWordForwardIndexer.databasePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	documentWordForwardIndexer.db.Backup(f, 0)
+// Size returns the bytes used on disk by the LSM tree and the value log
+func (documentWordForwardIndexer *DocumentWordForwardIndexer) Size() (int64, int64) {
+	return documentWordForwardIndexer.db.Size()
+}
+
+// Backup writes a snapshot of the database, it can be taken while the database is in use
+func (documentWordForwardIndexer *DocumentWordForwardIndexer) Backup(w io.Writer) error {
+	_, err := documentWordForwardIndexer.db.Backup(w, 0)
 	return err
 }
 
 func (documentWordForwardIndexer *DocumentWordForwardIndexer) AddWordFrequencyListToKey(documentId uint64, wordFrequencyList []WordFrequency) error {
+	defer documentWordForwardIndexer.written(documentId)
 	var valueString string
 	if len(wordFrequencyList) > 0 {
 		valueString = wordFrequencyToString(&wordFrequencyList[0])
@@ -97,8 +119,32 @@ func (documentWordForwardIndexer *DocumentWordForwardIndexer) AddWordFrequencyLi
 	return err
 }
 
+// AddWordFrequencyLists stores the word lists of many documents in batched transactions
+func (documentWordForwardIndexer *DocumentWordForwardIndexer) AddWordFrequencyLists(lists map[uint64][]WordFrequency) error {
+	documentIDs := sortedKeys(lists)
+	err := batchUpdate(documentWordForwardIndexer.db, len(documentIDs), func(txn *badger.Txn, i int) error {
+		values := make([]string, len(lists[documentIDs[i]]))
+		for j, word := range lists[documentIDs[i]] {
+			values[j] = wordFrequencyToString(&word)
+		}
+		return txn.Set(uint64ToByte(documentIDs[i]), []byte(strings.Join(values, ",")))
+	})
+	for _, documentID := range documentIDs {
+		documentWordForwardIndexer.written(documentID)
+	}
+	if err != nil {
+		err = fmt.Errorf("Error in adding word lists: %s", err)
+	}
+	return err
+}
+
 func (documentWordForwardIndexer *DocumentWordForwardIndexer) GetWordFrequencyListFromKey(documentId uint64) ([]WordFrequency, error) {
+	if cached, ok := documentWordForwardIndexer.cache.get(documentId); ok {
+		// Callers sort the list
+		return append([]WordFrequency(nil), cached.([]WordFrequency)...), nil
+	}
 	result := make([]WordFrequency, 0)
+	version := documentWordForwardIndexer.cache.begin()
 	err := documentWordForwardIndexer.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get(uint64ToByte(documentId))
 		if err == nil {
@@ -122,11 +168,14 @@ func (documentWordForwardIndexer *DocumentWordForwardIndexer) GetWordFrequencyLi
 
 	if err != nil {
 		err = fmt.Errorf("Error in getting Value from Key: %s", err)
+		return result, err
 	}
-	return result, err
+	documentWordForwardIndexer.cache.add(documentId, append([]WordFrequency(nil), result...), wordListSize(result), version)
+	return result, nil
 }
 
 func (documentWordForwardIndexer *DocumentWordForwardIndexer) DeleteKeyValuePair(documentId uint64) error {
+	defer documentWordForwardIndexer.written(documentId)
 	err := documentWordForwardIndexer.db.Update(func(txn *badger.Txn) error {
 		err := txn.Delete(uint64ToByte(documentId))
 		return err
@@ -160,6 +209,18 @@ func (documentWordForwardIndexer *DocumentWordForwardIndexer) Iterate() error {
 	return err
 }
 
+// written drops a list from the cache and counts the write
+func (documentWordForwardIndexer *DocumentWordForwardIndexer) written(documentId uint64) {
+	documentWordForwardIndexer.cache.remove(documentId)
+	atomic.AddUint64(&documentWordForwardIndexer.writes, 1)
+}
+
+// Writes counts the lists written or deleted since opening. No other process
+// can write to the database while it is open here.
+func (documentWordForwardIndexer *DocumentWordForwardIndexer) Writes() uint64 {
+	return atomic.LoadUint64(&documentWordForwardIndexer.writes)
+}
+
 // find N = num of docs
 func (documentWordForwardIndexer *DocumentWordForwardIndexer) GetSize() uint64 {
 	//fmt.Println("Iterating over Document Word Forward Index to count size")
@@ -202,3 +263,36 @@ func (documentWordForwardIndexer *DocumentWordForwardIndexer) GetDocIDList() ([]
 	// fmt.Printf("Values in result: %v\n", result)
 	return result, err
 }
+
+// All returns the word frequency list of every document
+func (documentWordForwardIndexer *DocumentWordForwardIndexer) All() (map[uint64][]WordFrequency, error) {
+	result := make(map[uint64][]WordFrequency)
+	err := documentWordForwardIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			documentID := byteToUint64(item.Key())
+			err := item.Value(func(v []byte) error {
+				list := make([]WordFrequency, 0)
+				if len(v) > 0 {
+					for _, s := range strings.Split(string(v), ",") {
+						list = append(list, stringToWordFrequency(s))
+					}
+				}
+				result[documentID] = list
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("Error while reading word frequencies: %s", err)
+	}
+	return result, err
+}
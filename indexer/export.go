@@ -0,0 +1,521 @@
+package Indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// DumpVersion is the version of the JSON Lines dumps written by Export. Import
+// reads dumps up to this version into the current storage format.
+const DumpVersion = 1
+
+// Types of the records of a dump. The header comes first, then the words,
+// the documents and the crawl runs.
+const (
+	DumpHeaderRecord   = "header"
+	DumpWordRecord     = "word"
+	DumpDocumentRecord = "document"
+	DumpCrawlRecord    = "crawl"
+)
+
+// DumpHeader is the first line of a dump, with the metadata of the index
+type DumpHeader struct {
+	Type     string            `json:"type"`
+	Version  int               `json:"version"`
+	Created  time.Time         `json:"created"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// DumpWord is a word of the dictionary
+type DumpWord struct {
+	Type string `json:"type"`
+	ID   uint64 `json:"id"`
+	Word string `json:"word"`
+}
+
+// DumpDocument is everything known about a document. Pages only linked to
+// have no properties. Word lists are pairs of word ID and frequency, and
+// postings are the positions of each word ID in each field.
+type DumpDocument struct {
+	Type       string                         `json:"type"`
+	ID         uint64                         `json:"id"`
+	URL        string                         `json:"url"`
+	Properties *DumpProperties                `json:"properties,omitempty"`
+	Words      [][2]uint64                    `json:"words,omitempty"`
+	TitleWords [][2]uint64                    `json:"title_words,omitempty"`
+	Postings   map[string]map[uint64][]uint64 `json:"postings,omitempty"`
+	Links      []uint64                       `json:"links,omitempty"`
+	Ranks      map[string]float64             `json:"ranks,omitempty"`
+	Topics     map[string]float64             `json:"topics,omitempty"`
+}
+
+// DumpProperties are the page properties of a crawled document
+type DumpProperties struct {
+	Title       string    `json:"title"`
+	Size        int       `json:"size"`
+	Modified    time.Time `json:"modified"`
+	Description string    `json:"description,omitempty"`
+	Language    string    `json:"language,omitempty"`
+}
+
+// DumpCrawl is the report of a crawl run
+type DumpCrawl struct {
+	Type string `json:"type"`
+	CrawlReport
+}
+
+// DumpSummary counts the records of a dump
+type DumpSummary struct {
+	Words     int
+	Documents int
+	Crawls    int
+}
+
+// fieldIndexers are the inverted indexes by the field name used in dumps
+func (indexes *Indexes) fieldIndexers() map[string]*InvertedFileIndexer {
+	return map[string]*InvertedFileIndexer{
+		"title":       indexes.TitleInvertedIndexer,
+		"content":     indexes.ContentInvertedIndexer,
+		"heading":     indexes.HeadingInvertedIndexer,
+		"description": indexes.DescriptionInvertedIndexer,
+		"keywords":    indexes.KeywordsInvertedIndexer,
+		"alt":         indexes.AltInvertedIndexer,
+		"url":         indexes.URLInvertedIndexer,
+	}
+}
+
+// rankNames are the rank databases by the name used in dumps
+func (indexes *Indexes) rankNames() map[string]*PageRankIndexer {
+	return map[string]*PageRankIndexer{
+		"pagerank":  indexes.PageRankIndexer,
+		"hub":       indexes.HubIndexer,
+		"authority": indexes.AuthorityIndexer,
+	}
+}
+
+// Export writes the whole index as JSON Lines, one record per line. The
+// postings are regrouped by document, so the index is read into memory.
+func (indexes *Indexes) Export(w io.Writer) (*DumpSummary, error) {
+	summary := &DumpSummary{}
+	encoder := json.NewEncoder(w)
+
+	metadata, err := indexes.MetadataIndexer.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error while exporting: %s", err)
+	}
+	if err := encoder.Encode(DumpHeader{DumpHeaderRecord, DumpVersion, time.Now(), metadata}); err != nil {
+		return nil, fmt.Errorf("Error while exporting: %s", err)
+	}
+
+	words, err := indexes.WordIndexer.Mapping()
+	if err != nil {
+		return nil, fmt.Errorf("Error while exporting: %s", err)
+	}
+	wordNames := names(words)
+	for _, id := range sortedKeys(wordNames) {
+		if err := encoder.Encode(DumpWord{DumpWordRecord, id, wordNames[id]}); err != nil {
+			return nil, fmt.Errorf("Error while exporting: %s", err)
+		}
+		summary.Words++
+	}
+
+	documents, err := indexes.exportDocuments()
+	if err != nil {
+		return nil, fmt.Errorf("Error while exporting: %s", err)
+	}
+	for _, document := range documents {
+		if err := encoder.Encode(document); err != nil {
+			return nil, fmt.Errorf("Error while exporting: %s", err)
+		}
+		summary.Documents++
+	}
+
+	reports, err := indexes.CrawlReportIndexer.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error while exporting: %s", err)
+	}
+	for _, report := range reports {
+		if err := encoder.Encode(DumpCrawl{DumpCrawlRecord, report}); err != nil {
+			return nil, fmt.Errorf("Error while exporting: %s", err)
+		}
+		summary.Crawls++
+	}
+	return summary, nil
+}
+
+// exportDocuments gathers the records of every document, by ID
+func (indexes *Indexes) exportDocuments() ([]*DumpDocument, error) {
+	urls, err := indexes.DocumentIndexer.Mapping()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint64]*DumpDocument, len(urls))
+	for url, id := range urls {
+		byID[id] = &DumpDocument{Type: DumpDocumentRecord, ID: id, URL: url}
+	}
+	document := func(id uint64) *DumpDocument {
+		if d, ok := byID[id]; ok {
+			return d
+		}
+		// Entries of documents without a URL cannot be imported, verify reports them
+		return &DumpDocument{}
+	}
+
+	pages, err := indexes.PagePropertiesIndexer.All()
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		document(page.GetId()).Properties = &DumpProperties{page.GetTitle(), page.GetSize(), page.GetDate(), page.GetDescription(), page.GetLanguage()}
+	}
+
+	for _, forward := range []struct {
+		indexer *DocumentWordForwardIndexer
+		title   bool
+	}{{indexes.DocumentWordForwardIndexer, false}, {indexes.TitleWordForwardIndexer, true}} {
+		lists, err := forward.indexer.All()
+		if err != nil {
+			return nil, err
+		}
+		for id, list := range lists {
+			pairs := make([][2]uint64, len(list))
+			for i, frequency := range list {
+				pairs[i] = [2]uint64{frequency.WordID, frequency.Frequency}
+			}
+			if forward.title {
+				document(id).TitleWords = pairs
+			} else {
+				document(id).Words = pairs
+			}
+		}
+	}
+
+	for field, inverted := range indexes.fieldIndexers() {
+		postings, err := inverted.All()
+		if err != nil {
+			return nil, err
+		}
+		for wordID, files := range postings {
+			for _, file := range files {
+				d := document(file.pageID)
+				if d.Postings == nil {
+					d.Postings = make(map[string]map[uint64][]uint64)
+				}
+				if d.Postings[field] == nil {
+					d.Postings[field] = make(map[uint64][]uint64)
+				}
+				d.Postings[field][wordID] = file.wordPositions
+			}
+		}
+	}
+
+	links, err := indexes.ParentChildDocumentForwardIndexer.All()
+	if err != nil {
+		return nil, err
+	}
+	for id, children := range links {
+		known := make([]uint64, 0, len(children))
+		for _, child := range children {
+			if _, ok := byID[child]; ok {
+				known = append(known, child)
+			}
+		}
+		document(id).Links = known
+	}
+
+	for name, indexer := range indexes.rankNames() {
+		ranks, err := indexer.All()
+		if err != nil {
+			return nil, err
+		}
+		for id, rank := range ranks {
+			d := document(id)
+			if d.Ranks == nil {
+				d.Ranks = make(map[string]float64)
+			}
+			d.Ranks[name] = rank
+		}
+	}
+	topics, err := indexes.TopicPageRankIndexer.All()
+	if err != nil {
+		return nil, err
+	}
+	for topic, ranks := range topics {
+		for id, rank := range ranks {
+			d := document(id)
+			if d.Topics == nil {
+				d.Topics = make(map[string]float64)
+			}
+			d.Topics[topic] = rank
+		}
+	}
+
+	result := make([]*DumpDocument, 0, len(byID))
+	for _, d := range byID {
+		result = append(result, d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// Import reads a dump written by Export into a new index directory, which
+// must not exist or be empty. Documents, words and crawl runs get new IDs,
+// so a dump can also be imported into an index of a newer storage format.
+func Import(r io.Reader, directory string) (*DumpSummary, error) {
+	if entries, err := ioutil.ReadDir(directory); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("Error while importing: %s is not empty", directory)
+	}
+	indexes, err := Open(directory)
+	if err != nil {
+		return nil, err
+	}
+	defer indexes.Close()
+
+	summary, err := indexes.importDump(r)
+	if err != nil {
+		return nil, fmt.Errorf("Error while importing: %s", err)
+	}
+	return summary, nil
+}
+
+// dumpImport maps the IDs of a dump to the IDs of the index it is imported into
+type dumpImport struct {
+	indexes   *Indexes
+	words     map[uint64]uint64
+	documents map[uint64]uint64
+	// Links are added once every document has its ID
+	links map[uint64][]uint64
+}
+
+func (indexes *Indexes) importDump(r io.Reader) (*DumpSummary, error) {
+	summary := &DumpSummary{}
+	state := &dumpImport{indexes, make(map[uint64]uint64), make(map[uint64]uint64), make(map[uint64][]uint64)}
+	decoder := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			if line == 1 {
+				return nil, fmt.Errorf("dump is empty")
+			}
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("record %d: %s", line, err)
+		}
+		var record struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("record %d: %s", line, err)
+		}
+		if (line == 1) != (record.Type == DumpHeaderRecord) {
+			return nil, fmt.Errorf("record %d: the header must be the first record", line)
+		}
+
+		var err error
+		switch record.Type {
+		case DumpHeaderRecord:
+			err = state.header(raw)
+		case DumpWordRecord:
+			err = state.word(raw)
+			summary.Words++
+		case DumpDocumentRecord:
+			err = state.document(raw)
+			summary.Documents++
+		case DumpCrawlRecord:
+			err = state.crawl(raw)
+			summary.Crawls++
+		default:
+			err = fmt.Errorf("unknown type %q", record.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %s", line, err)
+		}
+	}
+	return summary, state.addLinks()
+}
+
+func (state *dumpImport) header(raw json.RawMessage) error {
+	header := DumpHeader{}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return err
+	}
+	if header.Version < 1 || header.Version > DumpVersion {
+		return fmt.Errorf("dump version %d cannot be imported, expected at most version %d", header.Version, DumpVersion)
+	}
+	for key, value := range header.Metadata {
+		if key == SchemaVersionKey || key == GenerationKey {
+			// The import is written in the current schema, and starts
+			// its own history of changes
+			continue
+		}
+		if err := state.indexes.MetadataIndexer.SetValue(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (state *dumpImport) word(raw json.RawMessage) error {
+	word := DumpWord{}
+	if err := json.Unmarshal(raw, &word); err != nil {
+		return err
+	}
+	if _, ok := state.words[word.ID]; ok {
+		return fmt.Errorf("word %d is repeated", word.ID)
+	}
+	id, err := state.indexes.WordIndexer.AddKeyToIndex(word.Word)
+	if err != nil {
+		return err
+	}
+	state.words[word.ID] = id
+	return state.indexes.ReverseWordIndexer.AddKeyToIndex(id, word.Word)
+}
+
+// documentID gives a document its ID in the index, adding its URL
+func (state *dumpImport) documentID(d *DumpDocument) (uint64, error) {
+	if _, ok := state.documents[d.ID]; ok {
+		return 0, fmt.Errorf("document %d is repeated", d.ID)
+	}
+	if d.URL == "" {
+		return 0, fmt.Errorf("document %d has no URL", d.ID)
+	}
+	id, err := state.indexes.DocumentIndexer.AddKeyToIndex(d.URL)
+	if err != nil {
+		return 0, err
+	}
+	state.documents[d.ID] = id
+	return id, state.indexes.ReverseDocumentIndexer.AddKeyToIndex(id, d.URL)
+}
+
+func (state *dumpImport) wordList(pairs [][2]uint64) ([]WordFrequency, error) {
+	list := make([]WordFrequency, len(pairs))
+	for i, pair := range pairs {
+		id, ok := state.words[pair[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown word %d", pair[0])
+		}
+		list[i] = WordFrequency{id, pair[1]}
+	}
+	return list, nil
+}
+
+func (state *dumpImport) document(raw json.RawMessage) error {
+	d := &DumpDocument{}
+	if err := json.Unmarshal(raw, d); err != nil {
+		return err
+	}
+	id, err := state.documentID(d)
+	if err != nil {
+		return err
+	}
+	indexes := state.indexes
+
+	if p := d.Properties; p != nil {
+		page := CreatePage(id, p.Title, d.URL, p.Size, p.Modified)
+		page.SetDescription(p.Description)
+		page.SetLanguage(p.Language)
+		if err := indexes.PagePropertiesIndexer.AddKeyToPageProperties(id, page); err != nil {
+			return err
+		}
+	}
+
+	for _, forward := range []struct {
+		indexer *DocumentWordForwardIndexer
+		pairs   [][2]uint64
+	}{{indexes.DocumentWordForwardIndexer, d.Words}, {indexes.TitleWordForwardIndexer, d.TitleWords}} {
+		if forward.pairs == nil {
+			continue
+		}
+		list, err := state.wordList(forward.pairs)
+		if err != nil {
+			return err
+		}
+		if err := forward.indexer.AddWordFrequencyListToKey(id, list); err != nil {
+			return err
+		}
+	}
+
+	fields := indexes.fieldIndexers()
+	for field, postings := range d.Postings {
+		inverted, ok := fields[field]
+		if !ok {
+			return fmt.Errorf("unknown field %q", field)
+		}
+		for wordID, positions := range postings {
+			newWordID, ok := state.words[wordID]
+			if !ok {
+				return fmt.Errorf("unknown word %d", wordID)
+			}
+			if err := inverted.AddKeyToIndexOrUpdate(newWordID, InvertedFile{id, positions}); err != nil {
+				return err
+			}
+		}
+	}
+
+	ranks := indexes.rankNames()
+	for name, rank := range d.Ranks {
+		indexer, ok := ranks[name]
+		if !ok {
+			return fmt.Errorf("unknown rank %q", name)
+		}
+		if err := indexer.AddKeyToIndexOrUpdate(id, rank); err != nil {
+			return err
+		}
+	}
+	for topic, rank := range d.Topics {
+		if err := indexes.TopicPageRankIndexer.AddKeyToIndex(topic, id, rank); err != nil {
+			return err
+		}
+	}
+
+	if len(d.Links) > 0 {
+		state.links[id] = d.Links
+	}
+	return nil
+}
+
+func (state *dumpImport) crawl(raw json.RawMessage) error {
+	crawl := DumpCrawl{}
+	if err := json.Unmarshal(raw, &crawl); err != nil {
+		return err
+	}
+	report, err := state.indexes.CrawlReportIndexer.NewReport(crawl.Root)
+	if err != nil {
+		return err
+	}
+	id := report.ID
+	*report = crawl.CrawlReport
+	report.ID = id
+	return state.indexes.CrawlReportIndexer.AddReport(report)
+}
+
+// addLinks writes the links both ways with the new document IDs
+func (state *dumpImport) addLinks() error {
+	parents := make(map[uint64][]uint64)
+	for _, parent := range sortedKeys(state.links) {
+		children := make([]uint64, 0, len(state.links[parent]))
+		for _, child := range state.links[parent] {
+			id, ok := state.documents[child]
+			if !ok {
+				return fmt.Errorf("document %d links to unknown document %d", parent, child)
+			}
+			children = append(children, id)
+			if !contains(parents[id], parent) {
+				parents[id] = append(parents[id], parent)
+			}
+		}
+		if err := state.indexes.ParentChildDocumentForwardIndexer.AddIdListToKey(parent, children); err != nil {
+			return err
+		}
+	}
+	for _, child := range sortedKeys(parents) {
+		if err := state.indexes.ChildParentDocumentForwardIndexer.AddIdListToKey(child, parents[child]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
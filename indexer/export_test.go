@@ -0,0 +1,251 @@
+package Indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// importFails imports a dump, which must be rejected
+func importFails(t *testing.T, dump string) {
+	t.Helper()
+	if _, err := (&Indexes{}).importDump(strings.NewReader(dump)); err == nil {
+		t.Errorf("imported %q", dump)
+	}
+}
+
+const testDumpHeader = `{"type":"header","version":1,"metadata":{}}` + "\n"
+
+func TestImportEmptyDump(t *testing.T) {
+	importFails(t, "")
+}
+
+func TestImportNewerDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dump := `{"type":"header","version":2,"metadata":{"analyzer.body":"standard/3"}}` + "\n"
+	if _, err := Import(strings.NewReader(dump), filepath.Join(dir, "db")); err == nil {
+		t.Error("dump of a newer version imported")
+	}
+	indexes, err := Open(filepath.Join(dir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+	if analyzers, _ := indexes.MetadataIndexer.GetFieldAnalyzers(); len(analyzers) > 0 {
+		t.Errorf("metadata of the newer dump imported: %v", analyzers)
+	}
+}
+
+func TestImportHeaderFirst(t *testing.T) {
+	importFails(t, `{"type":"word","id":1,"word":"cat"}`+"\n"+testDumpHeader)
+	importFails(t, testDumpHeader+testDumpHeader)
+}
+
+func TestImportMalformedRecord(t *testing.T) {
+	importFails(t, "not json\n")
+	importFails(t, testDumpHeader+`{"type":"word",`+"\n")
+	importFails(t, testDumpHeader+`{"type":"page"}`+"\n")
+}
+
+// searchState is what searches see of an index, by URL and word rather than ID
+type searchState struct {
+	Pages    map[string]string
+	Words    map[string]map[string]uint64
+	Postings map[string]map[string]map[string][]uint64
+	Links    map[string][]string
+	Parents  map[string][]string
+	Ranks    map[string]map[string]float64
+	Crawls   []CrawlReport
+	Metadata map[string]string
+}
+
+func readSearchState(t *testing.T, indexes *Indexes) *searchState {
+	t.Helper()
+	urls, _ := indexes.DocumentIndexer.Mapping()
+	words, _ := indexes.WordIndexer.Mapping()
+	url, word := names(urls), names(words)
+	state := &searchState{
+		Pages:    make(map[string]string),
+		Words:    make(map[string]map[string]uint64),
+		Postings: make(map[string]map[string]map[string][]uint64),
+		Links:    make(map[string][]string),
+		Parents:  make(map[string][]string),
+		Ranks:    make(map[string]map[string]float64),
+	}
+	pages, _ := indexes.PagePropertiesIndexer.All()
+	for _, page := range pages {
+		state.Pages[url[page.GetId()]] = page.GetTitle() + "/" + page.GetLanguage()
+	}
+	lists, _ := indexes.DocumentWordForwardIndexer.All()
+	for id, list := range lists {
+		state.Words[url[id]] = make(map[string]uint64)
+		for _, frequency := range list {
+			state.Words[url[id]][word[frequency.WordID]] = frequency.Frequency
+		}
+	}
+	for field, inverted := range indexes.fieldIndexers() {
+		postings, _ := inverted.All()
+		for wordID, files := range postings {
+			for _, file := range files {
+				if state.Postings[field] == nil {
+					state.Postings[field] = make(map[string]map[string][]uint64)
+				}
+				if state.Postings[field][word[wordID]] == nil {
+					state.Postings[field][word[wordID]] = make(map[string][]uint64)
+				}
+				state.Postings[field][word[wordID]][url[file.pageID]] = file.wordPositions
+			}
+		}
+	}
+	for _, forward := range []struct {
+		links   map[string][]string
+		indexer *ForwardIndexer
+	}{{state.Links, indexes.ParentChildDocumentForwardIndexer}, {state.Parents, indexes.ChildParentDocumentForwardIndexer}} {
+		links := forward.links
+		all, _ := forward.indexer.All()
+		for id, others := range all {
+			for _, other := range others {
+				links[url[id]] = append(links[url[id]], url[other])
+			}
+			sort.Strings(links[url[id]])
+		}
+	}
+	for name, indexer := range indexes.rankNames() {
+		ranks, _ := indexer.All()
+		state.Ranks[name] = make(map[string]float64)
+		for id, rank := range ranks {
+			state.Ranks[name][url[id]] = rank
+		}
+	}
+	topics, _ := indexes.TopicPageRankIndexer.All()
+	for topic, ranks := range topics {
+		state.Ranks["topic "+topic] = make(map[string]float64)
+		for id, rank := range ranks {
+			state.Ranks["topic "+topic][url[id]] = rank
+		}
+	}
+	state.Crawls, _ = indexes.CrawlReportIndexer.All()
+	for i := range state.Crawls {
+		state.Crawls[i].ID = 0
+	}
+	state.Metadata, _ = indexes.MetadataIndexer.All()
+	delete(state.Metadata, SchemaVersionKey)
+	delete(state.Metadata, GenerationKey)
+	return state
+}
+
+func TestExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexes, err := Open(filepath.Join(dir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+
+	// Keys removed before the pages were indexed leave gaps, so the import
+	// gives the documents and words other IDs
+	indexes.DocumentIndexer.AddKeyToIndex("https://www.cse.ust.hk/removed")
+	indexes.DocumentIndexer.DeleteKeyValuePair("https://www.cse.ust.hk/removed")
+	indexes.WordIndexer.AddKeyToIndex("removed")
+	indexes.WordIndexer.DeleteKeyValuePair("removed")
+	ids := make(map[string]uint64)
+	for _, url := range []string{"https://www.cse.ust.hk/a", "https://www.cse.ust.hk/b", "https://www.cse.ust.hk/c"} {
+		ids[url], _ = indexes.DocumentIndexer.AddKeyToIndex(url)
+		indexes.ReverseDocumentIndexer.AddKeyToIndex(ids[url], url)
+	}
+	for _, word := range []string{"comput", "scienc"} {
+		ids[word], _ = indexes.WordIndexer.AddKeyToIndex(word)
+		indexes.ReverseWordIndexer.AddKeyToIndex(ids[word], word)
+	}
+	a, b, c := ids["https://www.cse.ust.hk/a"], ids["https://www.cse.ust.hk/b"], ids["https://www.cse.ust.hk/c"]
+	for _, id := range []uint64{a, b} {
+		page := CreatePage(id, "Page", "", 100, time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC))
+		page.SetLanguage("en")
+		indexes.PagePropertiesIndexer.AddKeyToPageProperties(id, page)
+	}
+	indexes.DocumentWordForwardIndexer.AddWordFrequencyListToKey(a, []WordFrequency{{ids["comput"], 2}, {ids["scienc"], 1}})
+	indexes.DocumentWordForwardIndexer.AddWordFrequencyListToKey(b, []WordFrequency{{ids["scienc"], 1}})
+	indexes.ContentInvertedIndexer.AddKeyToIndexOrUpdate(ids["comput"], InvertedFile{a, []uint64{0, 2}})
+	indexes.ContentInvertedIndexer.AddKeyToIndexOrUpdate(ids["scienc"], InvertedFile{a, []uint64{1}})
+	indexes.ContentInvertedIndexer.AddKeyToIndexOrUpdate(ids["scienc"], InvertedFile{b, []uint64{0}})
+	indexes.TitleInvertedIndexer.AddKeyToIndexOrUpdate(ids["comput"], InvertedFile{a, []uint64{0}})
+	// c is only linked to
+	indexes.ParentChildDocumentForwardIndexer.AddIdListToKey(a, []uint64{b, c})
+	indexes.ChildParentDocumentForwardIndexer.AddIdListToKey(b, []uint64{a})
+	indexes.ChildParentDocumentForwardIndexer.AddIdListToKey(c, []uint64{a})
+	indexes.PageRankIndexer.AddKeyToIndexOrUpdate(a, 0.3)
+	indexes.PageRankIndexer.AddKeyToIndexOrUpdate(b, 0.7)
+	indexes.HubIndexer.AddKeyToIndexOrUpdate(a, 1)
+	indexes.TopicPageRankIndexer.AddKeyToIndex("science", b, 1)
+	indexes.MetadataIndexer.RecordFieldAnalyzers(map[string]string{"body": "standard/3"})
+	for _, root := range []string{"https://www.cse.ust.hk/", "https://www.cse.ust.hk/a"} {
+		report, _ := indexes.CrawlReportIndexer.NewReport(root)
+		report.Fetched = 2
+		report.Failures = []CrawlFailure{{URL: root + "missing", StatusCode: 404}}
+		indexes.CrawlReportIndexer.AddReport(report)
+	}
+	indexes.BumpGeneration()
+
+	var dump bytes.Buffer
+	if _, err := indexes.Export(&dump); err != nil {
+		t.Fatal(err)
+	}
+	// A dump of an older index records its schema version, which the import
+	// must not take for its own
+	lines := strings.SplitN(dump.String(), "\n", 2)
+	header := DumpHeader{}
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	header.Metadata[SchemaVersionKey] = "1"
+	headerLine, _ := json.Marshal(header)
+
+	imported := filepath.Join(dir, "imported")
+	summary, err := Import(strings.NewReader(string(headerLine)+"\n"+lines[1]), imported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Words != 2 || summary.Documents != 3 || summary.Crawls != 2 {
+		t.Errorf("imported %+v", summary)
+	}
+	importedIndexes, err := Open(imported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer importedIndexes.Close()
+
+	if id, _ := importedIndexes.DocumentIndexer.GetValueFromKey("https://www.cse.ust.hk/a"); id == a {
+		t.Errorf("document kept ID %d, the import did not remap it", id)
+	}
+	if id, _ := importedIndexes.WordIndexer.GetValueFromKey("comput"); id == ids["comput"] {
+		t.Errorf("word kept ID %d, the import did not remap it", id)
+	}
+	if version, err := importedIndexes.SchemaVersion(); err != nil || version != SchemaVersion {
+		t.Errorf("imported index at schema version %d, %v", version, err)
+	}
+	if generation := importedIndexes.Generation(); generation != 0 {
+		t.Errorf("imported index at generation %d", generation)
+	}
+	if problems, err := importedIndexes.Verify(); err != nil || len(problems) > 0 {
+		t.Errorf("imported index has problems %v, %v", problems, err)
+	}
+	exported, got := readSearchState(t, indexes), readSearchState(t, importedIndexes)
+	if !reflect.DeepEqual(exported, got) {
+		t.Errorf("imported %+v\nexported %+v", got, exported)
+	}
+}
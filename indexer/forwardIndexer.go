@@ -2,6 +2,7 @@ package Indexer
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -15,13 +16,17 @@ type ForwardIndexer struct {
 }
 
 func (forwardIndexer *ForwardIndexer) Initialize(path string) error {
+	return forwardIndexer.InitializeWithOptions(path, badger.DefaultOptions)
+}
+
+// InitializeWithOptions opens the database in a directory with badger options
+func (forwardIndexer *ForwardIndexer) InitializeWithOptions(path string, options badger.Options) error {
 	if err := os.MkdirAll(path, 0774); err != nil {
 		return err
 	}
-	opts := badger.DefaultOptions
-	opts.Dir = path
-	opts.ValueDir = path
-	db, err := badger.Open(opts)
+	options.Dir = path
+	options.ValueDir = path
+	db, err := badger.Open(options)
 	if err != nil {
 		return fmt.Errorf("Error while initializing: %s", err)
 	}
@@ -34,29 +39,28 @@ func (forwardIndexer *ForwardIndexer) Release() error {
 	return forwardIndexer.db.Close()
 }
 
-func (forwardIndexer *ForwardIndexer) Backup() error {
-	fmt.Println("Doing Database Backup")
-	f, err := os.Create(forwardIndexer.databasePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	forwardIndexer.db.Backup(f, 0)
+// Size returns the bytes used on disk by the LSM tree and the value log
+func (forwardIndexer *ForwardIndexer) Size() (int64, int64) {
+	return forwardIndexer.db.Size()
+}
+
+// Backup writes a snapshot of the database, it can be taken while the database is in use
+func (forwardIndexer *ForwardIndexer) Backup(w io.Writer) error {
+	_, err := forwardIndexer.db.Backup(w, 0)
 	return err
 }
 
-func (forwardIndexer *ForwardIndexer) AddIdListToKey(documentId uint64, idList []uint64) error {
-	var valueString string
-	if len(idList) > 0 {
-		valueString = strconv.FormatUint(idList[0], 10)
-		if len(idList) > 1 {
-			for _, v := range idList[1:] {
-				valueString = valueString + " " + strconv.FormatUint(v, 10)
-			}
-		}
+func idListToString(idList []uint64) string {
+	values := make([]string, len(idList))
+	for i, v := range idList {
+		values[i] = strconv.FormatUint(v, 10)
 	}
+	return strings.Join(values, " ")
+}
+
+func (forwardIndexer *ForwardIndexer) AddIdListToKey(documentId uint64, idList []uint64) error {
 	err := forwardIndexer.db.Update(func(txn *badger.Txn) error {
-		err := txn.Set(uint64ToByte(documentId), []byte(valueString))
+		err := txn.Set(uint64ToByte(documentId), []byte(idListToString(idList)))
 		return err
 	})
 	if err != nil {
@@ -65,6 +69,18 @@ func (forwardIndexer *ForwardIndexer) AddIdListToKey(documentId uint64, idList [
 	return err
 }
 
+// AddIdLists sets the ID lists of many keys in batched transactions
+func (forwardIndexer *ForwardIndexer) AddIdLists(lists map[uint64][]uint64) error {
+	documentIds := sortedKeys(lists)
+	err := batchUpdate(forwardIndexer.db, len(documentIds), func(txn *badger.Txn, i int) error {
+		return txn.Set(uint64ToByte(documentIds[i]), []byte(idListToString(lists[documentIds[i]])))
+	})
+	if err != nil {
+		err = fmt.Errorf("Error in adding ID lists: %s", err)
+	}
+	return err
+}
+
 func (forwardIndexer *ForwardIndexer) GetIdListFromKey(documentId uint64) ([]uint64, error) {
 	result := make([]uint64, 0)
 	err := forwardIndexer.db.View(func(txn *badger.Txn) error {
@@ -127,3 +143,35 @@ func (forwardIndexer *ForwardIndexer) Iterate() error {
 	})
 	return err
 }
+
+// All returns the ID list of every key
+func (forwardIndexer *ForwardIndexer) All() (map[uint64][]uint64, error) {
+	result := make(map[uint64][]uint64)
+	err := forwardIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			documentID := byteToUint64(item.Key())
+			err := item.Value(func(v []byte) error {
+				list := make([]uint64, 0)
+				for _, s := range strings.Fields(string(v)) {
+					id, _ := strconv.ParseUint(s, 10, 64)
+					list = append(list, id)
+				}
+				result[documentID] = list
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("Error while reading forward index: %s", err)
+	}
+	return result, err
+}
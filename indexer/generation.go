@@ -0,0 +1,52 @@
+package Indexer
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// GenerationKey is the metadata key of the generation of an index, bumped
+// whenever a crawl or a ranking changes its contents. Results computed from
+// the index are only valid for the generation they were computed at.
+const GenerationKey = "generation"
+
+// Generation returns the generation of the index. It is read from the
+// metadata when the index is opened, then kept up to date by BumpGeneration:
+// the databases are locked by the process that opened them, so no other
+// process can change it.
+func (indexes *Indexes) Generation() uint64 {
+	indexes.generationMutex.Lock()
+	defer indexes.generationMutex.Unlock()
+	return indexes.generation
+}
+
+// loadGeneration reads the generation recorded in the metadata, 0 if none is
+func (indexes *Indexes) loadGeneration() error {
+	metadata, err := indexes.MetadataIndexer.All()
+	if err != nil {
+		return fmt.Errorf("Error while reading generation: %s", err)
+	}
+	var generation uint64
+	if value, ok := metadata[GenerationKey]; ok {
+		if generation, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return fmt.Errorf("Error while reading generation: invalid generation %q", value)
+		}
+	}
+	indexes.generationMutex.Lock()
+	defer indexes.generationMutex.Unlock()
+	indexes.generation = generation
+	return nil
+}
+
+// BumpGeneration records that the contents of the index changed, returning
+// the new generation
+func (indexes *Indexes) BumpGeneration() (uint64, error) {
+	indexes.generationMutex.Lock()
+	defer indexes.generationMutex.Unlock()
+	generation := indexes.generation + 1
+	if err := indexes.MetadataIndexer.SetValue(GenerationKey, strconv.FormatUint(generation, 10)); err != nil {
+		return 0, fmt.Errorf("Error while bumping generation: %s", err)
+	}
+	indexes.generation = generation
+	return generation, nil
+}
@@ -0,0 +1,37 @@
+package Indexer
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestGeneration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "generation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexes, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if generation := indexes.Generation(); generation != 0 {
+		t.Errorf("new index at generation %d", generation)
+	}
+	indexes.BumpGeneration()
+	if generation, err := indexes.BumpGeneration(); err != nil || generation != 2 || indexes.Generation() != 2 {
+		t.Errorf("bumped to generation %d, reads %d, %v", generation, indexes.Generation(), err)
+	}
+	indexes.Close()
+
+	// The generation is kept in the metadata across openings
+	indexes, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+	if generation := indexes.Generation(); generation != 2 {
+		t.Errorf("reopened at generation %d", generation)
+	}
+}
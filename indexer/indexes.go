@@ -0,0 +1,165 @@
+package Indexer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dgraph-io/badger"
+)
+
+// DefaultDirectory is where the indexes are kept, relative to the working directory
+const DefaultDirectory = "db"
+
+// Indexes are all the databases of one search index
+type Indexes struct {
+	Directory                         string
+	DocumentIndexer                   *MappingIndexer
+	ReverseDocumentIndexer            *ReverseMappingIndexer
+	WordIndexer                       *MappingIndexer
+	ReverseWordIndexer                *ReverseMappingIndexer
+	PagePropertiesIndexer             *PagePropetiesIndexer
+	TitleInvertedIndexer              *InvertedFileIndexer
+	ContentInvertedIndexer            *InvertedFileIndexer
+	HeadingInvertedIndexer            *InvertedFileIndexer
+	DescriptionInvertedIndexer        *InvertedFileIndexer
+	KeywordsInvertedIndexer           *InvertedFileIndexer
+	AltInvertedIndexer                *InvertedFileIndexer
+	URLInvertedIndexer                *InvertedFileIndexer
+	DocumentWordForwardIndexer        *DocumentWordForwardIndexer
+	TitleWordForwardIndexer           *DocumentWordForwardIndexer
+	ParentChildDocumentForwardIndexer *ForwardIndexer
+	ChildParentDocumentForwardIndexer *ForwardIndexer
+	PageRankIndexer                   *PageRankIndexer
+	HubIndexer                        *PageRankIndexer
+	AuthorityIndexer                  *PageRankIndexer
+	TopicPageRankIndexer              *TopicPageRankIndexer
+	CrawlReportIndexer                *CrawlReportIndexer
+	MetadataIndexer                   *MetadataIndexer
+	// Statistics of the collection shared between queries
+	Collection *Collection
+	// Read caches enabled by EnableCaches
+	caches []*readCache
+	// Generation of the contents, see Generation
+	generationMutex sync.Mutex
+	generation      uint64
+	// Changes under way and backups being taken, which keep each other off,
+	// and the channel closed when the last backup is taken
+	writeMutex sync.Mutex
+	writes     int
+	backups    int
+	backedUp   chan struct{}
+}
+
+// Database is what every indexer has in common
+type Database interface {
+	InitializeWithOptions(path string, options badger.Options) error
+	Release() error
+	Size() (int64, int64)
+	Backup(w io.Writer) error
+}
+
+// NamedDatabase is one of the databases of the indexes and the directory it is stored in
+type NamedDatabase struct {
+	Name     string
+	Database Database
+}
+
+// Databases lists the databases of the indexes in a fixed order. The names
+// are the directories they are stored in, kept from the first crawler.
+func (indexes *Indexes) Databases() []NamedDatabase {
+	return []NamedDatabase{
+		{"documentIndex", indexes.DocumentIndexer},
+		{"reverseDocumentIndexer", indexes.ReverseDocumentIndexer},
+		{"wordIndex", indexes.WordIndexer},
+		{"reverseWordIndexer", indexes.ReverseWordIndexer},
+		{"pagePropertiesIndex", indexes.PagePropertiesIndexer},
+		{"titleInvertedIndex", indexes.TitleInvertedIndexer},
+		{"contentInvertedIndex", indexes.ContentInvertedIndexer},
+		{"headingInvertedIndex", indexes.HeadingInvertedIndexer},
+		{"descriptionInvertedIndex", indexes.DescriptionInvertedIndexer},
+		{"keywordsInvertedIndex", indexes.KeywordsInvertedIndexer},
+		{"altInvertedIndex", indexes.AltInvertedIndexer},
+		{"urlInvertedIndex", indexes.URLInvertedIndexer},
+		{"documentWordForwardIndex", indexes.DocumentWordForwardIndexer},
+		{"titleWordForwardIndex", indexes.TitleWordForwardIndexer},
+		{"parentChildDocumentForwardIndex", indexes.ParentChildDocumentForwardIndexer},
+		{"childParentDocumentForwardIndex", indexes.ChildParentDocumentForwardIndexer},
+		{"pageRankIndex", indexes.PageRankIndexer},
+		{"hubIndex", indexes.HubIndexer},
+		{"authorityIndex", indexes.AuthorityIndexer},
+		{"topicPageRankIndex", indexes.TopicPageRankIndexer},
+		{"crawlReportIndex", indexes.CrawlReportIndexer},
+		{"metadataIndex", indexes.MetadataIndexer},
+	}
+}
+
+// Open opens every database of the indexes in a directory, creating the ones
+// that do not exist. After opening, we need to call defer indexes.Close()
+func Open(directory string) (*Indexes, error) {
+	return OpenWithOptions(directory, badger.DefaultOptions)
+}
+
+// OpenWithOptions opens the indexes with badger options for every database
+func OpenWithOptions(directory string, options badger.Options) (*Indexes, error) {
+	if err := os.MkdirAll(directory, 0774); err != nil {
+		return nil, fmt.Errorf("Error while opening indexes: %s", err)
+	}
+	indexes := &Indexes{
+		Directory:                         directory,
+		DocumentIndexer:                   &MappingIndexer{},
+		ReverseDocumentIndexer:            &ReverseMappingIndexer{},
+		WordIndexer:                       &MappingIndexer{},
+		ReverseWordIndexer:                &ReverseMappingIndexer{},
+		PagePropertiesIndexer:             &PagePropetiesIndexer{},
+		TitleInvertedIndexer:              &InvertedFileIndexer{},
+		ContentInvertedIndexer:            &InvertedFileIndexer{},
+		HeadingInvertedIndexer:            &InvertedFileIndexer{},
+		DescriptionInvertedIndexer:        &InvertedFileIndexer{},
+		KeywordsInvertedIndexer:           &InvertedFileIndexer{},
+		AltInvertedIndexer:                &InvertedFileIndexer{},
+		URLInvertedIndexer:                &InvertedFileIndexer{},
+		DocumentWordForwardIndexer:        &DocumentWordForwardIndexer{},
+		TitleWordForwardIndexer:           &DocumentWordForwardIndexer{},
+		ParentChildDocumentForwardIndexer: &ForwardIndexer{},
+		ChildParentDocumentForwardIndexer: &ForwardIndexer{},
+		PageRankIndexer:                   &PageRankIndexer{},
+		HubIndexer:                        &PageRankIndexer{},
+		AuthorityIndexer:                  &PageRankIndexer{},
+		TopicPageRankIndexer:              &TopicPageRankIndexer{},
+		CrawlReportIndexer:                &CrawlReportIndexer{},
+		MetadataIndexer:                   &MetadataIndexer{},
+	}
+	indexes.Collection = NewCollection(indexes.DocumentWordForwardIndexer, indexes.TitleWordForwardIndexer)
+	databases := indexes.Databases()
+	for i, database := range databases {
+		if err := database.Database.InitializeWithOptions(filepath.Join(directory, database.Name), options); err != nil {
+			for _, opened := range databases[:i] {
+				opened.Database.Release()
+			}
+			return nil, fmt.Errorf("Error while opening %s: %s", database.Name, err)
+		}
+	}
+	if err := indexes.migrate(); err != nil {
+		indexes.Close()
+		return nil, fmt.Errorf("Error while migrating indexes: %s", err)
+	}
+	if err := indexes.loadGeneration(); err != nil {
+		indexes.Close()
+		return nil, err
+	}
+	return indexes, nil
+}
+
+// Close releases every database, returning the first error
+func (indexes *Indexes) Close() error {
+	var result error
+	for _, database := range indexes.Databases() {
+		if err := database.Database.Release(); err != nil && result == nil {
+			result = fmt.Errorf("Error while closing %s: %s", database.Name, err)
+		}
+	}
+	return result
+}
@@ -2,7 +2,9 @@ package Indexer
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -12,6 +14,8 @@ import (
 type InvertedFileIndexer struct {
 	db           *badger.DB
 	databasePath string
+	// Posting lists read recently, nil when not caching
+	cache *readCache
 }
 
 type InvertedFile struct {
@@ -57,13 +61,17 @@ func (invertedFile *InvertedFile) Same(compared *InvertedFile) bool {
 }
 
 func (invertedFileIndexer *InvertedFileIndexer) Initialize(path string) error {
+	return invertedFileIndexer.InitializeWithOptions(path, badger.DefaultOptions)
+}
+
+// InitializeWithOptions opens the database in a directory with badger options
+func (invertedFileIndexer *InvertedFileIndexer) InitializeWithOptions(path string, options badger.Options) error {
 	if err := os.MkdirAll(path, 0774); err != nil {
 		return err
 	}
-	opts := badger.DefaultOptions
-	opts.Dir = path
-	opts.ValueDir = path
-	db, err := badger.Open(opts)
+	options.Dir = path
+	options.ValueDir = path
+	db, err := badger.Open(options)
 	if err != nil {
 		return fmt.Errorf("Error while initializing: %s", err)
 	}
@@ -77,6 +85,11 @@ func (invertedFileIndexer *InvertedFileIndexer) Release() error {
 	return invertedFileIndexer.db.Close()
 }
 
+// Size returns the bytes used on disk by the LSM tree and the value log
+func (invertedFileIndexer *InvertedFileIndexer) Size() (int64, int64) {
+	return invertedFileIndexer.db.Size()
+}
+
 func (invertedFileIndexer *InvertedFileIndexer) Iterate() error {
 	fmt.Println("iterating over InvertedFile")
 	err := invertedFileIndexer.db.View(func(txn *badger.Txn) error {
@@ -109,14 +122,9 @@ func stringToInvertedFile(str string) InvertedFile {
 	return InvertedFile{pageSliceUint64[0], pageSliceUint64[1:]}
 }
 
-func (InvertedFileIndexer *InvertedFileIndexer) Backup() error {
-	fmt.Println("Doing Database Backup")
-	f, err := os.Create(InvertedFileIndexer.databasePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	InvertedFileIndexer.db.Backup(f, 0)
+// Backup writes a snapshot of the database, it can be taken while the database is in use
+func (invertedFileIndexer *InvertedFileIndexer) Backup(w io.Writer) error {
+	_, err := invertedFileIndexer.db.Backup(w, 0)
 	return err
 }
 
@@ -131,6 +139,7 @@ func invertedFileToString(i InvertedFile) string {
 
 func (invertedFileIndexer *InvertedFileIndexer) AddKeyToIndexOrUpdate(wordID uint64, invertedFile InvertedFile) error {
 	keyString := uint64ToByte(wordID)
+	defer invertedFileIndexer.cache.remove(wordID)
 
 	// Construct a string to to add to inverted file
 	valueString := invertedFileToString(invertedFile)
@@ -187,9 +196,69 @@ func (invertedFileIndexer *InvertedFileIndexer) AddKeyToIndexOrUpdate(wordID uin
 	return err
 }
 
+// AddInvertedFiles merges the postings of many words into the index in
+// batched transactions. A page's posting replaces the one it had for the word,
+// and every list stays sorted by page ID.
+func (invertedFileIndexer *InvertedFileIndexer) AddInvertedFiles(postings map[uint64][]InvertedFile) error {
+	wordIDs := sortedKeys(postings)
+	err := batchUpdate(invertedFileIndexer.db, len(wordIDs), func(txn *badger.Txn, i int) error {
+		wordID := wordIDs[i]
+		list := make([]InvertedFile, 0)
+		item, err := txn.Get(uint64ToByte(wordID))
+		if err == nil {
+			err = item.Value(func(val []byte) error {
+				if len(val) > 0 {
+					for _, v := range strings.Split(string(val), ",") {
+						list = append(list, stringToInvertedFile(v))
+					}
+				}
+				return nil
+			})
+		}
+		if err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+		list = mergeInvertedFiles(list, postings[wordID])
+		values := make([]string, len(list))
+		for j, invertedFile := range list {
+			values[j] = invertedFileToString(invertedFile)
+		}
+		return txn.Set(uint64ToByte(wordID), []byte(strings.Join(values, ",")))
+	})
+	for _, wordID := range wordIDs {
+		invertedFileIndexer.cache.remove(wordID)
+	}
+	if err != nil {
+		err = fmt.Errorf("Error in adding inverted files: %s", err)
+	}
+	return err
+}
+
+// mergeInvertedFiles replaces the postings of the pages given, sorted by page ID
+func mergeInvertedFiles(list []InvertedFile, added []InvertedFile) []InvertedFile {
+	replaced := make(map[uint64]bool, len(added))
+	for _, invertedFile := range added {
+		replaced[invertedFile.pageID] = true
+	}
+	merged := make([]InvertedFile, 0, len(list)+len(added))
+	for _, invertedFile := range list {
+		if !replaced[invertedFile.pageID] {
+			merged = append(merged, invertedFile)
+		}
+	}
+	merged = append(merged, added...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].pageID < merged[j].pageID })
+	return merged
+}
+
 func (invertedFileIndexer *InvertedFileIndexer) GetInvertedFileFromKey(wordID uint64) ([]InvertedFile, error) {
+	if cached, ok := invertedFileIndexer.cache.get(wordID); ok {
+		// Callers may reorder the list, not the positions
+		return append([]InvertedFile(nil), cached.([]InvertedFile)...), nil
+	}
 	keyString := uint64ToByte(wordID)
 	result := make([]InvertedFile, 0)
+	version := invertedFileIndexer.cache.begin()
 	err := invertedFileIndexer.db.View(func(txn *badger.Txn) error {
 		item, getErr := txn.Get(keyString)
 		if getErr == nil {
@@ -206,12 +275,15 @@ func (invertedFileIndexer *InvertedFileIndexer) GetInvertedFileFromKey(wordID ui
 	})
 	if err != nil {
 		err = fmt.Errorf("Error when getting value transaction: %s", err)
+		return result, err
 	}
-	return result, err
+	invertedFileIndexer.cache.add(wordID, append([]InvertedFile(nil), result...), postingsSize(result), version)
+	return result, nil
 }
 
 func (invertedFileIndexer *InvertedFileIndexer) DeleteAllInvertedFileFromKey(wordID uint64) error {
 	keyString := uint64ToByte(wordID)
+	defer invertedFileIndexer.cache.remove(wordID)
 	err := invertedFileIndexer.db.Update(func(txn *badger.Txn) error {
 		err := txn.Delete([]byte(keyString))
 		return err
@@ -259,6 +331,7 @@ func (invertedFileIndexer *InvertedFileIndexer) DeleteInvertedFileFromWordListAn
 
 			return err
 		})
+		invertedFileIndexer.cache.remove(word)
 	}
 	return err
 }
@@ -267,3 +340,63 @@ func (invertedFileIndexer *InvertedFileIndexer) GetDocFreq(wordID uint64) (uint6
 	invertedFile, err := invertedFileIndexer.GetInvertedFileFromKey(wordID)
 	return uint64(len(invertedFile)), err
 }
+
+// Postings counts the (word, page) pairs of the index
+func (invertedFileIndexer *InvertedFileIndexer) Postings() (uint64, error) {
+	var postings uint64
+	err := invertedFileIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(v []byte) error {
+				if len(v) > 0 {
+					postings += uint64(strings.Count(string(v), ",") + 1)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("Error while counting postings: %s", err)
+	}
+	return postings, err
+}
+
+// All returns the inverted files of every word
+func (invertedFileIndexer *InvertedFileIndexer) All() (map[uint64][]InvertedFile, error) {
+	result := make(map[uint64][]InvertedFile)
+	err := invertedFileIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			wordID := byteToUint64(item.Key())
+			err := item.Value(func(v []byte) error {
+				list := make([]InvertedFile, 0)
+				if len(v) > 0 {
+					for _, s := range strings.Split(string(v), ",") {
+						list = append(list, stringToInvertedFile(s))
+					}
+				}
+				result[wordID] = list
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("Error while reading inverted files: %s", err)
+	}
+	return result, err
+}
@@ -2,32 +2,43 @@ package Indexer
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sync"
 
 	"github.com/dgraph-io/badger"
 )
 
+// sequenceKey is where the next IDs are leased from, stored beside the keys
+const sequenceKey = "sequence"
+
 // URL -> Page ID Indexer and Word -> Page ID Indexer
 type MappingIndexer struct {
 	db           *badger.DB
 	sequence     *badger.Sequence
 	databasePath string
+	// Held while giving IDs, so a key added concurrently gets one ID
+	mutex sync.Mutex
 }
 
 // After initializing the mappingIndexer, we need to call defer mappingIndexer.Release()
 func (mappingIndexer *MappingIndexer) Initialize(path string) error {
+	return mappingIndexer.InitializeWithOptions(path, badger.DefaultOptions)
+}
+
+// InitializeWithOptions opens the database in a directory with badger options
+func (mappingIndexer *MappingIndexer) InitializeWithOptions(path string, options badger.Options) error {
 	if err := os.MkdirAll(path, 0774); err != nil {
 		return err
 	}
-	opts := badger.DefaultOptions
-	opts.Dir = path
-	opts.ValueDir = path
-	db, err := badger.Open(opts)
+	options.Dir = path
+	options.ValueDir = path
+	db, err := badger.Open(options)
 	if err != nil {
 		return fmt.Errorf("Error while initializing: %s", err)
 	}
 	mappingIndexer.db = db
-	mappingIndexer.sequence, _ = db.GetSequence([]byte("sequence"), 10000)
+	mappingIndexer.sequence, _ = db.GetSequence([]byte(sequenceKey), 10000)
 	mappingIndexer.databasePath = path
 	return err
 }
@@ -37,14 +48,14 @@ func (mappingIndexer *MappingIndexer) Release() error {
 	return mappingIndexer.db.Close()
 }
 
-func (mappingIndexer *MappingIndexer) Backup() error {
-	fmt.Println("Doing Database Backup")
-	f, err := os.Create(mappingIndexer.databasePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	mappingIndexer.db.Backup(f, 0)
+// Size returns the bytes used on disk by the LSM tree and the value log
+func (mappingIndexer *MappingIndexer) Size() (int64, int64) {
+	return mappingIndexer.db.Size()
+}
+
+// Backup writes a snapshot of the database, it can be taken while the database is in use
+func (mappingIndexer *MappingIndexer) Backup(w io.Writer) error {
+	_, err := mappingIndexer.db.Backup(w, 0)
 	return err
 }
 
@@ -58,6 +69,9 @@ func (mappingIndexer *MappingIndexer) Iterate() {
 		for it.Rewind(); it.Valid(); it.Next() {
 			item := it.Item()
 			k := item.Key()
+			if string(k) == sequenceKey {
+				continue
+			}
 			err := item.Value(func(v []byte) error {
 				fmt.Printf("key=%s, value=%d\n", k, byteToUint64(v))
 				return nil
@@ -82,6 +96,9 @@ func (mappingIndexer *MappingIndexer) All() ([]uint64, error) {
 		for it.Rewind(); it.Valid(); it.Next() {
 			var p uint64
 			item := it.Item()
+			if string(item.Key()) == sequenceKey {
+				continue
+			}
 			err := item.Value(func(v []byte) error {
 				p = byteToUint64(v)
 				return nil
@@ -98,18 +115,31 @@ func (mappingIndexer *MappingIndexer) All() ([]uint64, error) {
 	return pageIds, err
 }
 
+// AddKeyToIndex gives a key the next ID, or returns its ID if it has one
 func (mappingIndexer *MappingIndexer) AddKeyToIndex(key string) (uint64, error) {
+	mappingIndexer.mutex.Lock()
+	defer mappingIndexer.mutex.Unlock()
 	var id uint64
 	var err error
 	err = mappingIndexer.db.Update(func(txn *badger.Txn) error {
-		_, err := txn.Get([]byte(key))
+		item, err := txn.Get([]byte(key))
 		if err == badger.ErrKeyNotFound {
 			// Get new value for index
 			id, err = mappingIndexer.sequence.Next()
+			if err != nil {
+				return err
+			}
 			err = txn.Set([]byte(key), []byte(uint64ToByte(id)))
 			return err
 		}
-		return err
+		if err != nil {
+			return err
+		}
+		// Added by another goroutine since the caller looked it up
+		return item.Value(func(val []byte) error {
+			id = byteToUint64(val)
+			return nil
+		})
 	})
 	if err != nil {
 		err = fmt.Errorf("Error in adding Key to Index: %s", err)
@@ -117,6 +147,43 @@ func (mappingIndexer *MappingIndexer) AddKeyToIndex(key string) (uint64, error)
 	return id, err
 }
 
+// AssignIDs returns the ID of every key, giving the keys without one the next
+// IDs in batched transactions. It is safe to call concurrently.
+func (mappingIndexer *MappingIndexer) AssignIDs(keys []string) (map[string]uint64, error) {
+	mappingIndexer.mutex.Lock()
+	defer mappingIndexer.mutex.Unlock()
+	ids := make(map[string]uint64, len(keys))
+	err := batchUpdate(mappingIndexer.db, len(keys), func(txn *badger.Txn, i int) error {
+		key := keys[i]
+		if _, ok := ids[key]; ok {
+			return nil
+		}
+		item, err := txn.Get([]byte(key))
+		if err == nil {
+			return item.Value(func(val []byte) error {
+				ids[key] = byteToUint64(val)
+				return nil
+			})
+		}
+		if err != badger.ErrKeyNotFound {
+			return err
+		}
+		id, err := mappingIndexer.sequence.Next()
+		if err != nil {
+			return err
+		}
+		if err := txn.Set([]byte(key), uint64ToByte(id)); err != nil {
+			return err
+		}
+		ids[key] = id
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error while assigning IDs: %s", err)
+	}
+	return ids, nil
+}
+
 func (mappingIndexer *MappingIndexer) GetValueFromKey(key string) (uint64, error) {
 	var result uint64
 	err := mappingIndexer.db.View(func(txn *badger.Txn) error {
@@ -149,6 +216,9 @@ func (mappingIndexer *MappingIndexer) AllValue() []string {
 		for it.Rewind(); it.Valid(); it.Next() {
 			item := it.Item()
 			k := item.Key()
+			if string(k) == sequenceKey {
+				continue
+			}
 			err := item.Value(func(v []byte) error {
 				result = append(result, string(k))
 				return nil
@@ -172,3 +242,33 @@ func (mappingIndexer *MappingIndexer) DeleteKeyValuePair(key string) error {
 	}
 	return err
 }
+
+// Mapping returns the ID of every key
+func (mappingIndexer *MappingIndexer) Mapping() (map[string]uint64, error) {
+	result := make(map[string]uint64)
+	err := mappingIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			k := string(item.Key())
+			if k == sequenceKey {
+				continue
+			}
+			err := item.Value(func(v []byte) error {
+				result[k] = byteToUint64(v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("Error while reading mapping: %s", err)
+	}
+	return result, err
+}
@@ -0,0 +1,165 @@
+package Indexer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dgraph-io/badger"
+)
+
+// Metadata name -> value Indexer, describing how the other indexes were built
+type MetadataIndexer struct {
+	db           *badger.DB
+	databasePath string
+}
+
+// Metadata key prefix of the analyzer used for a field
+const AnalyzerMetadataPrefix = "analyzer."
+
+// After initializing the MetadataIndexer, we need to call defer MetadataIndexer.Release()
+func (metadataIndexer *MetadataIndexer) Initialize(path string) error {
+	return metadataIndexer.InitializeWithOptions(path, badger.DefaultOptions)
+}
+
+// InitializeWithOptions opens the database in a directory with badger options
+func (metadataIndexer *MetadataIndexer) InitializeWithOptions(path string, options badger.Options) error {
+	if err := os.MkdirAll(path, 0774); err != nil {
+		return err
+	}
+	options.Dir = path
+	options.ValueDir = path
+	db, err := badger.Open(options)
+	if err != nil {
+		return fmt.Errorf("Error while initializing: %s", err)
+	}
+	metadataIndexer.db = db
+	metadataIndexer.databasePath = path
+	return err
+}
+
+func (metadataIndexer *MetadataIndexer) Release() error {
+	return metadataIndexer.db.Close()
+}
+
+// Size returns the bytes used on disk by the LSM tree and the value log
+func (metadataIndexer *MetadataIndexer) Size() (int64, int64) {
+	return metadataIndexer.db.Size()
+}
+
+// Backup writes a snapshot of the database, it can be taken while the database is in use
+func (metadataIndexer *MetadataIndexer) Backup(w io.Writer) error {
+	_, err := metadataIndexer.db.Backup(w, 0)
+	return err
+}
+
+// SetValue stores a metadata value, replacing the previous one
+func (metadataIndexer *MetadataIndexer) SetValue(key string, value string) error {
+	err := metadataIndexer.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(key), []byte(value))
+	})
+	if err != nil {
+		err = fmt.Errorf("Error in adding Key to Index: %s", err)
+	}
+	return err
+}
+
+func (metadataIndexer *MetadataIndexer) GetValueFromKey(key string) (string, error) {
+	var result string
+	err := metadataIndexer.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			result = string(val)
+			return nil
+		})
+	})
+	if err != nil {
+		err = fmt.Errorf("Error in getting Value from Key: %s", err)
+	}
+	return result, err
+}
+
+// All returns every metadata entry
+func (metadataIndexer *MetadataIndexer) All() (map[string]string, error) {
+	result := make(map[string]string)
+	err := metadataIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			k := string(item.Key())
+			err := item.Value(func(v []byte) error {
+				result[k] = string(v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (metadataIndexer *MetadataIndexer) Iterate() {
+	fmt.Println("Iterating over Metadata Index")
+	values, _ := metadataIndexer.All()
+	for k, v := range values {
+		fmt.Printf("key=%s, value=%s\n", k, v)
+	}
+}
+
+func (metadataIndexer *MetadataIndexer) DeleteKeyValuePair(key string) error {
+	err := metadataIndexer.db.Update(func(txn *badger.Txn) error {
+		err := txn.Delete([]byte(key))
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("Error when deleting value from key: %s", err)
+	}
+	return err
+}
+
+// GetFieldAnalyzers returns the analyzer name recorded for every field
+func (metadataIndexer *MetadataIndexer) GetFieldAnalyzers() (map[string]string, error) {
+	values, err := metadataIndexer.All()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	for k, v := range values {
+		if strings.HasPrefix(k, AnalyzerMetadataPrefix) {
+			result[strings.TrimPrefix(k, AnalyzerMetadataPrefix)] = v
+		}
+	}
+	return result, nil
+}
+
+// RecordFieldAnalyzers stores the analyzer of each field, refusing to change the
+// analyzer of a field that already has one since its index would no longer match
+func (metadataIndexer *MetadataIndexer) RecordFieldAnalyzers(analyzers map[string]string) error {
+	recorded, err := metadataIndexer.GetFieldAnalyzers()
+	if err != nil {
+		return err
+	}
+	for field, name := range analyzers {
+		if previous, ok := recorded[field]; ok && previous != name {
+			return fmt.Errorf("field %s was indexed with analyzer %s, not %s", field, previous, name)
+		}
+	}
+	for field, name := range analyzers {
+		if _, ok := recorded[field]; ok {
+			continue
+		}
+		if err := metadataIndexer.SetValue(AnalyzerMetadataPrefix+field, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package Indexer
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -13,6 +16,8 @@ import (
 type PagePropetiesIndexer struct {
 	db           *badger.DB
 	databasePath string
+	// Pages read recently, nil when not caching
+	cache *readCache
 }
 
 type Page struct {
@@ -21,6 +26,8 @@ type Page struct {
 	url          string
 	size         int
 	dateModified time.Time
+	description  string
+	language     string
 }
 
 func (page *Page) GetId() uint64 {
@@ -47,6 +54,24 @@ func (page *Page) GetDate() time.Time {
 	return page.dateModified
 }
 
+// Meta description of the page, also used as its result snippet
+func (page *Page) GetDescription() string {
+	return page.description
+}
+
+func (page *Page) SetDescription(description string) {
+	page.description = description
+}
+
+// Language declared by the page, empty if unknown
+func (page *Page) GetLanguage() string {
+	return page.language
+}
+
+func (page *Page) SetLanguage(language string) {
+	page.language = language
+}
+
 func CreatePage(id uint64, title string, url string, size int, date time.Time) Page {
 	page := Page{}
 	page.id = id
@@ -57,27 +82,128 @@ func CreatePage(id uint64, title string, url string, size int, date time.Time) P
 	return page
 }
 
+// pageToString is the text encoding of schema version 1, it cannot store titles containing "/page/"
 func pageToString(page *Page) string {
-	return strconv.Itoa(int(page.id)) + "/page/" + page.title + "/page/" + page.url + "/page/" + strconv.FormatInt(int64(page.size), 10) + "/page/" + page.dateModified.Format(time.RFC3339)
+	return strconv.Itoa(int(page.id)) + "/page/" + page.title + "/page/" + page.url + "/page/" + strconv.FormatInt(int64(page.size), 10) + "/page/" + page.dateModified.Format(time.RFC3339) + "/page/" + page.description + "/page/" + page.language
 }
 
-func stringToPage(str string) Page {
+func stringToPage(str string) (Page, error) {
 	splitString := strings.Split(str, "/page/")
-	idString, _ := strconv.ParseUint(splitString[0], 10, 64)
-	size, _ := strconv.Atoi(splitString[3])
-	time, _ := time.Parse(time.RFC3339, splitString[4])
-	return Page{idString, splitString[1], splitString[2], size, time}
+	// Pages stored before descriptions were extracted only have five parts
+	if len(splitString) < 5 {
+		return Page{}, fmt.Errorf("page properties have %d parts, expected at least 5", len(splitString))
+	}
+	id, err := strconv.ParseUint(splitString[0], 10, 64)
+	if err != nil {
+		return Page{}, fmt.Errorf("invalid page ID: %s", err)
+	}
+	size, err := strconv.Atoi(splitString[3])
+	if err != nil {
+		return Page{}, fmt.Errorf("invalid page size: %s", err)
+	}
+	date, err := time.Parse(time.RFC3339, splitString[4])
+	if err != nil {
+		return Page{}, fmt.Errorf("invalid page date: %s", err)
+	}
+	page := Page{id: id, title: splitString[1], url: splitString[2], size: size, dateModified: date}
+	if len(splitString) >= 7 {
+		page.description = splitString[5]
+		page.language = splitString[6]
+	}
+	return page, nil
+}
+
+// pageFormat starts the binary encoding of page properties. The text encoding
+// of schema version 1 always starts with a digit.
+const pageFormat byte = 0
+
+// encodePage stores the page properties as length prefixed fields
+func encodePage(page *Page) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(pageFormat)
+	putUvarint(&buf, page.id)
+	putBytes(&buf, []byte(page.title))
+	putBytes(&buf, []byte(page.url))
+	putUvarint(&buf, uint64(page.size))
+	date, _ := page.dateModified.MarshalBinary()
+	putBytes(&buf, date)
+	putBytes(&buf, []byte(page.description))
+	putBytes(&buf, []byte(page.language))
+	return buf.Bytes()
+}
+
+func decodePage(value []byte) (Page, error) {
+	page := Page{}
+	if len(value) == 0 || value[0] != pageFormat {
+		return page, fmt.Errorf("page properties are not in the binary format")
+	}
+	r := bytes.NewReader(value[1:])
+	var err error
+	var size uint64
+	var title, url, date, description, language []byte
+	if page.id, err = binary.ReadUvarint(r); err != nil {
+		return page, fmt.Errorf("invalid page properties: %s", err)
+	}
+	for _, field := range []*[]byte{&title, &url} {
+		if *field, err = readBytes(r); err != nil {
+			return page, fmt.Errorf("invalid page properties: %s", err)
+		}
+	}
+	if size, err = binary.ReadUvarint(r); err != nil {
+		return page, fmt.Errorf("invalid page properties: %s", err)
+	}
+	for _, field := range []*[]byte{&date, &description, &language} {
+		if *field, err = readBytes(r); err != nil {
+			return page, fmt.Errorf("invalid page properties: %s", err)
+		}
+	}
+	if err := page.dateModified.UnmarshalBinary(date); err != nil {
+		return page, fmt.Errorf("invalid page properties: %s", err)
+	}
+	page.title = string(title)
+	page.url = string(url)
+	page.size = int(size)
+	page.description = string(description)
+	page.language = string(language)
+	return page, nil
+}
+
+func putUvarint(buf *bytes.Buffer, v uint64) {
+	var n [binary.MaxVarintLen64]byte
+	buf.Write(n[:binary.PutUvarint(n[:], v)])
+}
+
+func putBytes(buf *bytes.Buffer, b []byte) {
+	putUvarint(buf, uint64(len(b)))
+	buf.Write(b)
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	return b, err
 }
 
 // After initializing the PagePropetiesIndexer, we need to call defer PagePropetiesIndexer.Release()
 func (pagePropetiesIndexer *PagePropetiesIndexer) Initialize(path string) error {
+	return pagePropetiesIndexer.InitializeWithOptions(path, badger.DefaultOptions)
+}
+
+// InitializeWithOptions opens the database in a directory with badger options
+func (pagePropetiesIndexer *PagePropetiesIndexer) InitializeWithOptions(path string, options badger.Options) error {
 	if err := os.MkdirAll(path, 0774); err != nil {
 		return err
 	}
-	opts := badger.DefaultOptions
-	opts.Dir = path
-	opts.ValueDir = path
-	db, err := badger.Open(opts)
+	options.Dir = path
+	options.ValueDir = path
+	db, err := badger.Open(options)
 	if err != nil {
 		return fmt.Errorf("Error while initializing: %s", err)
 	}
@@ -100,8 +226,9 @@ func (pagePropetiesIndexer *PagePropetiesIndexer) All() ([]Page, error) {
 			item := it.Item()
 			// k := item.Key()
 			err := item.Value(func(v []byte) error {
-				p = stringToPage(string(v))
-				return nil
+				var decodeErr error
+				p, decodeErr = decodePage(v)
+				return decodeErr
 			})
 			if err != nil {
 				return err
@@ -127,7 +254,11 @@ func (pagePropetiesIndexer *PagePropetiesIndexer) Iterate() error {
 			item := it.Item()
 			k := item.Key()
 			err := item.Value(func(v []byte) error {
-				fmt.Printf("key=%d, value=%s\n", byteToUint64(k), v)
+				page, err := decodePage(v)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("key=%d, title=%s, url=%s, size=%d, date=%s\n", byteToUint64(k), page.title, page.url, page.size, page.GetDateString())
 				return nil
 			})
 			if err != nil {
@@ -143,19 +274,20 @@ func (pagePropetiesIndexer *PagePropetiesIndexer) Release() error {
 	return pagePropetiesIndexer.db.Close()
 }
 
-func (pagePropetiesIndexer *PagePropetiesIndexer) Backup() error {
-	fmt.Println("Doing Database Backup")
-	f, err := os.Create(pagePropetiesIndexer.databasePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	pagePropetiesIndexer.db.Backup(f, 0)
+// Size returns the bytes used on disk by the LSM tree and the value log
+func (pagePropetiesIndexer *PagePropetiesIndexer) Size() (int64, int64) {
+	return pagePropetiesIndexer.db.Size()
+}
+
+// Backup writes a snapshot of the database, it can be taken while the database is in use
+func (pagePropetiesIndexer *PagePropetiesIndexer) Backup(w io.Writer) error {
+	_, err := pagePropetiesIndexer.db.Backup(w, 0)
 	return err
 }
 
 func (pagePropetiesIndexer *PagePropetiesIndexer) AddKeyToPageProperties(pageID uint64, page Page) error {
 	pageString := uint64ToByte(pageID)
+	defer pagePropetiesIndexer.cache.remove(pageID)
 	err := pagePropetiesIndexer.db.Update(func(txn *badger.Txn) error {
 		_, err := txn.Get(pageString)
 
@@ -164,9 +296,7 @@ func (pagePropetiesIndexer *PagePropetiesIndexer) AddKeyToPageProperties(pageID
 			txn.Delete([]byte(pageString))
 		}
 
-		pagePropetiesString := pageToString(&page)
-
-		err = txn.Set(uint64ToByte(pageID), []byte(pagePropetiesString))
+		err = txn.Set(uint64ToByte(pageID), encodePage(&page))
 		return err
 	})
 	if err != nil {
@@ -175,17 +305,38 @@ func (pagePropetiesIndexer *PagePropetiesIndexer) AddKeyToPageProperties(pageID
 	return err
 }
 
+// AddPages stores the properties of many pages in batched transactions
+func (pagePropetiesIndexer *PagePropetiesIndexer) AddPages(pages map[uint64]Page) error {
+	pageIDs := sortedKeys(pages)
+	err := batchUpdate(pagePropetiesIndexer.db, len(pageIDs), func(txn *badger.Txn, i int) error {
+		page := pages[pageIDs[i]]
+		return txn.Set(uint64ToByte(pageIDs[i]), encodePage(&page))
+	})
+	for _, pageID := range pageIDs {
+		pagePropetiesIndexer.cache.remove(pageID)
+	}
+	if err != nil {
+		err = fmt.Errorf("Error in adding pages: %s", err)
+	}
+	return err
+}
+
 func (pagePropetiesIndexer *PagePropetiesIndexer) GetPagePropertiesFromKey(pageID uint64) (Page, error) {
+	if cached, ok := pagePropetiesIndexer.cache.get(pageID); ok {
+		return cached.(Page), nil
+	}
 	pageString := uint64ToByte(pageID)
 	var resultPage Page
+	version := pagePropetiesIndexer.cache.begin()
 	err := pagePropetiesIndexer.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get(pageString)
 		if err != nil {
 			return err
 		}
 		itemErr := item.Value(func(val []byte) error {
-			resultPage = stringToPage(string(val))
-			return nil
+			var decodeErr error
+			resultPage, decodeErr = decodePage(val)
+			return decodeErr
 		})
 		if itemErr != nil {
 			return itemErr
@@ -194,12 +345,15 @@ func (pagePropetiesIndexer *PagePropetiesIndexer) GetPagePropertiesFromKey(pageI
 	})
 	if err != nil {
 		err = fmt.Errorf("Error when getting page properties from key: %s", err)
+		return resultPage, err
 	}
-	return resultPage, err
+	pagePropetiesIndexer.cache.add(pageID, resultPage, pageSize(&resultPage), version)
+	return resultPage, nil
 }
 
 func (pagePropetiesIndexer *PagePropetiesIndexer) DeletePagePropertiesFromKey(pageID uint64) error {
 	pageString := uint64ToByte(pageID)
+	defer pagePropetiesIndexer.cache.remove(pageID)
 	err := pagePropetiesIndexer.db.Update(func(txn *badger.Txn) error {
 		err := txn.Delete([]byte(pageString))
 		return err
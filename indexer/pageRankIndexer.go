@@ -2,6 +2,7 @@ package Indexer
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 
@@ -16,13 +17,17 @@ type PageRankIndexer struct {
 
 // After initializing the PageRankIndexer, we need to call defer PageRankIndexer.Release()
 func (pageRankIndexer *PageRankIndexer) Initialize(path string) error {
+	return pageRankIndexer.InitializeWithOptions(path, badger.DefaultOptions)
+}
+
+// InitializeWithOptions opens the database in a directory with badger options
+func (pageRankIndexer *PageRankIndexer) InitializeWithOptions(path string, options badger.Options) error {
 	if err := os.MkdirAll(path, 0774); err != nil {
 		return err
 	}
-	opts := badger.DefaultOptions
-	opts.Dir = path
-	opts.ValueDir = path
-	db, err := badger.Open(opts)
+	options.Dir = path
+	options.ValueDir = path
+	db, err := badger.Open(options)
 	if err != nil {
 		return fmt.Errorf("Error while initializing: %s", err)
 	}
@@ -35,14 +40,14 @@ func (pageRankIndexer *PageRankIndexer) Release() error {
 	return pageRankIndexer.db.Close()
 }
 
-func (pageRankIndexer *PageRankIndexer) Backup() error {
-	fmt.Println("Doing Database Backup")
-	f, err := os.Create(pageRankIndexer.databasePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	pageRankIndexer.db.Backup(f, 0)
+// Size returns the bytes used on disk by the LSM tree and the value log
+func (pageRankIndexer *PageRankIndexer) Size() (int64, int64) {
+	return pageRankIndexer.db.Size()
+}
+
+// Backup writes a snapshot of the database, it can be taken while the database is in use
+func (pageRankIndexer *PageRankIndexer) Backup(w io.Writer) error {
+	_, err := pageRankIndexer.db.Backup(w, 0)
 	return err
 }
 
@@ -64,6 +69,17 @@ func (pageRankIndexer *PageRankIndexer) AddKeyToIndex(key uint64, value float64)
 	return err
 }
 
+// AddKeyToIndexOrUpdate stores a value, replacing the previous one
+func (pageRankIndexer *PageRankIndexer) AddKeyToIndexOrUpdate(key uint64, value float64) error {
+	err := pageRankIndexer.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(uint64ToByte(key), []byte(fmt.Sprintf("%.6f", value)))
+	})
+	if err != nil {
+		err = fmt.Errorf("Error in adding Key to Index: %s", err)
+	}
+	return err
+}
+
 func (pageRankIndexer *PageRankIndexer) GetValueFromKey(key uint64) (float64, error) {
 	var result float64
 	var floatErr error
@@ -126,3 +142,31 @@ func (pageRankIndexer *PageRankIndexer) DeleteKeyValuePair(key uint64) error {
 	}
 	return err
 }
+
+// All returns the value of every page
+func (pageRankIndexer *PageRankIndexer) All() (map[uint64]float64, error) {
+	result := make(map[uint64]float64)
+	err := pageRankIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			k := byteToUint64(item.Key())
+			err := item.Value(func(v []byte) error {
+				value, err := strconv.ParseFloat(string(v), 64)
+				result[k] = value
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("Error while reading ranks: %s", err)
+	}
+	return result, err
+}
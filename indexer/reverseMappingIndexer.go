@@ -2,6 +2,7 @@ package Indexer
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/dgraph-io/badger"
@@ -15,13 +16,17 @@ type ReverseMappingIndexer struct {
 
 // After initializing the ReverseMappingIndexer, we need to call defer ReverseMappingIndexer.Release()
 func (reverseMappingIndexer *ReverseMappingIndexer) Initialize(path string) error {
+	return reverseMappingIndexer.InitializeWithOptions(path, badger.DefaultOptions)
+}
+
+// InitializeWithOptions opens the database in a directory with badger options
+func (reverseMappingIndexer *ReverseMappingIndexer) InitializeWithOptions(path string, options badger.Options) error {
 	if err := os.MkdirAll(path, 0774); err != nil {
 		return err
 	}
-	opts := badger.DefaultOptions
-	opts.Dir = path
-	opts.ValueDir = path
-	db, err := badger.Open(opts)
+	options.Dir = path
+	options.ValueDir = path
+	db, err := badger.Open(options)
 	if err != nil {
 		return fmt.Errorf("Error while initializing: %s", err)
 	}
@@ -34,14 +39,14 @@ func (reverseMappingIndexer *ReverseMappingIndexer) Release() error {
 	return reverseMappingIndexer.db.Close()
 }
 
-func (reverseMappingIndexer *ReverseMappingIndexer) Backup() error {
-	fmt.Println("Doing Database Backup")
-	f, err := os.Create(reverseMappingIndexer.databasePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	reverseMappingIndexer.db.Backup(f, 0)
+// Size returns the bytes used on disk by the LSM tree and the value log
+func (reverseMappingIndexer *ReverseMappingIndexer) Size() (int64, int64) {
+	return reverseMappingIndexer.db.Size()
+}
+
+// Backup writes a snapshot of the database, it can be taken while the database is in use
+func (reverseMappingIndexer *ReverseMappingIndexer) Backup(w io.Writer) error {
+	_, err := reverseMappingIndexer.db.Backup(w, 0)
 	return err
 }
 
@@ -61,6 +66,22 @@ func (reverseMappingIndexer *ReverseMappingIndexer) AddKeyToIndex(key uint64, wo
 	return err
 }
 
+// AddKeys adds the names of the IDs without one in batched transactions
+func (reverseMappingIndexer *ReverseMappingIndexer) AddKeys(names map[uint64]string) error {
+	keys := sortedKeys(names)
+	err := batchUpdate(reverseMappingIndexer.db, len(keys), func(txn *badger.Txn, i int) error {
+		_, err := txn.Get(uint64ToByte(keys[i]))
+		if err == badger.ErrKeyNotFound {
+			return txn.Set(uint64ToByte(keys[i]), []byte(names[keys[i]]))
+		}
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("Error in adding Keys to Index: %s", err)
+	}
+	return err
+}
+
 func (ReverseMappingIndexer *ReverseMappingIndexer) GetValueFromKey(key uint64) (string, error) {
 	var result string
 	err := ReverseMappingIndexer.db.View(func(txn *badger.Txn) error {
@@ -115,3 +136,41 @@ func (ReverseMappingIndexer *ReverseMappingIndexer) DeleteKeyValuePair(key uint6
 	}
 	return err
 }
+
+// All returns the value of every key
+func (reverseMappingIndexer *ReverseMappingIndexer) All() (map[uint64]string, error) {
+	result := make(map[uint64]string)
+	err := reverseMappingIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			k := byteToUint64(item.Key())
+			err := item.Value(func(v []byte) error {
+				result[k] = string(v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("Error while reading reverse mapping: %s", err)
+	}
+	return result, err
+}
+
+// SetValue stores the value of a key, replacing the previous one
+func (reverseMappingIndexer *ReverseMappingIndexer) SetValue(key uint64, value string) error {
+	err := reverseMappingIndexer.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(uint64ToByte(key), []byte(value))
+	})
+	if err != nil {
+		err = fmt.Errorf("Error in adding Key to Index: %s", err)
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package Indexer
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dgraph-io/badger"
+)
+
+// SchemaVersion is the version of the storage format written by this code.
+// Open upgrades indexes of older versions with the migrations.
+const SchemaVersion = 2
+
+// SchemaVersionKey is the metadata key of the schema version of an index
+const SchemaVersionKey = "schema_version"
+
+// migration upgrades an index from the previous schema version to its version
+type migration struct {
+	version     int
+	description string
+	run         func(indexes *Indexes) error
+}
+
+// migrations are applied in order, each one once
+var migrations = []migration{
+	{2, "store page properties in a binary encoding", migrateBinaryPages},
+}
+
+// SchemaVersion reads the schema version recorded in the metadata, 0 if none is
+func (indexes *Indexes) SchemaVersion() (int, error) {
+	metadata, err := indexes.MetadataIndexer.All()
+	if err != nil {
+		return 0, err
+	}
+	value, ok := metadata[SchemaVersionKey]
+	if !ok {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q", value)
+	}
+	return version, nil
+}
+
+func (indexes *Indexes) setSchemaVersion(version int) error {
+	return indexes.MetadataIndexer.SetValue(SchemaVersionKey, strconv.Itoa(version))
+}
+
+// migrate brings the index to SchemaVersion. A new index starts at it, an
+// index without a recorded version was written before versions were recorded.
+func (indexes *Indexes) migrate() error {
+	version, err := indexes.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		documents, err := indexes.DocumentIndexer.All()
+		if err != nil {
+			return err
+		}
+		if len(documents) == 0 {
+			return indexes.setSchemaVersion(SchemaVersion)
+		}
+		version = 1
+	}
+	_, err = runMigrations(version, SchemaVersion, migrations, func(step migration) error {
+		fmt.Printf("Migrating index to schema version %d: %s\n", step.version, step.description)
+		if err := step.run(indexes); err != nil {
+			return err
+		}
+		// Recorded after every step, so an interrupted upgrade resumes from the failed step
+		return indexes.setSchemaVersion(step.version)
+	})
+	return err
+}
+
+// runMigrations runs the steps above version, up to latest
+func runMigrations(version int, latest int, steps []migration, run func(migration) error) (int, error) {
+	if version > latest {
+		return version, fmt.Errorf("index schema version %d is newer than version %d this program reads", version, latest)
+	}
+	for _, step := range steps {
+		if step.version <= version || step.version > latest {
+			continue
+		}
+		if err := run(step); err != nil {
+			return version, fmt.Errorf("migration to schema version %d failed: %s", step.version, err)
+		}
+		version = step.version
+	}
+	return version, nil
+}
+
+// migrateBinaryPages rewrites the page properties of schema version 1, stored
+// as text separated by "/page/", in the binary encoding
+func migrateBinaryPages(indexes *Indexes) error {
+	db := indexes.PagePropertiesIndexer.db
+	values := make(map[uint64][]byte)
+	err := db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			value, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			values[byteToUint64(it.Item().Key())] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for id, value := range values {
+		if len(value) > 0 && value[0] == pageFormat {
+			// Already rewritten by an interrupted migration
+			continue
+		}
+		page, err := stringToPage(string(value))
+		if err != nil {
+			return fmt.Errorf("page properties of key %d: %s", id, err)
+		}
+		err = db.Update(func(txn *badger.Txn) error {
+			return txn.Set(uint64ToByte(id), encodePage(&page))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
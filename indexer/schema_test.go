@@ -0,0 +1,165 @@
+package Indexer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger"
+)
+
+func TestEncodePage(t *testing.T) {
+	date := time.Date(2019, 4, 1, 12, 30, 0, 0, time.FixedZone("HKT", 8*3600))
+	page := CreatePage(42, "Rules/page/of the course", "https://www.cse.ust.hk/page/", 1234, date)
+	page.SetDescription("Description, with commas/page/and separators")
+	page.SetLanguage("en")
+
+	decoded, err := decodePage(encodePage(&page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.GetTitle() != page.GetTitle() || decoded.GetUrl() != page.GetUrl() || decoded.GetId() != 42 || decoded.GetSize() != 1234 ||
+		decoded.GetDescription() != page.GetDescription() || decoded.GetLanguage() != "en" || !decoded.GetDate().Equal(date) {
+		t.Errorf("decoded %+v, expected %+v", decoded, page)
+	}
+
+	empty := Page{}
+	if decoded, err := decodePage(encodePage(&empty)); err != nil || !reflect.DeepEqual(decoded, empty) {
+		t.Errorf("decoded empty page %+v, %v", decoded, err)
+	}
+}
+
+func TestDecodePageErrors(t *testing.T) {
+	page := CreatePage(1, "Title", "http://a/", 10, time.Now())
+	encoded := encodePage(&page)
+	for name, value := range map[string][]byte{
+		"empty":     {},
+		"text":      []byte(pageToString(&page)),
+		"truncated": encoded[:len(encoded)-3],
+	} {
+		if _, err := decodePage(value); err == nil {
+			t.Errorf("decoding %s page properties did not fail", name)
+		}
+	}
+}
+
+func TestRunMigrations(t *testing.T) {
+	steps := []migration{{2, "two", nil}, {3, "three", nil}, {4, "four", nil}}
+	for _, test := range []struct {
+		from, latest int
+		ran          []int
+		version      int
+	}{
+		{1, 4, []int{2, 3, 4}, 4},
+		{2, 4, []int{3, 4}, 4},
+		{4, 4, nil, 4},
+		{1, 3, []int{2, 3}, 3},
+	} {
+		var ran []int
+		version, err := runMigrations(test.from, test.latest, steps, func(step migration) error {
+			ran = append(ran, step.version)
+			return nil
+		})
+		if err != nil || version != test.version || !reflect.DeepEqual(ran, test.ran) {
+			t.Errorf("migrating from %d to %d ran %v and reached %d, %v", test.from, test.latest, ran, version, err)
+		}
+	}
+
+	if _, err := runMigrations(5, 4, steps, func(migration) error { return nil }); err == nil {
+		t.Error("migrating a newer index did not fail")
+	}
+	version, err := runMigrations(1, 4, steps, func(step migration) error {
+		if step.version == 3 {
+			return fmt.Errorf("disk full")
+		}
+		return nil
+	})
+	if err == nil || version != 2 {
+		t.Errorf("failed migration reached version %d, %v", version, err)
+	}
+}
+
+// writeVersion1Index writes an index as the first crawler did, with the page
+// properties in the text encoding and no schema version recorded
+func writeVersion1Index(t *testing.T, dir string, pages map[string]string) {
+	documents := &MappingIndexer{}
+	if err := documents.Initialize(filepath.Join(dir, "documentIndex")); err != nil {
+		t.Fatal(err)
+	}
+	properties := &PagePropetiesIndexer{}
+	if err := properties.Initialize(filepath.Join(dir, "pagePropertiesIndex")); err != nil {
+		t.Fatal(err)
+	}
+	for url, value := range pages {
+		id, err := documents.AddKeyToIndex(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value = strings.Replace(value, "{id}", fmt.Sprint(id), 1)
+		err = properties.db.Update(func(txn *badger.Txn) error {
+			return txn.Set(uint64ToByte(id), []byte(value))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	documents.Release()
+	properties.Release()
+}
+
+func TestMigrateVersion1Index(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	date := time.Date(2019, 4, 1, 12, 30, 0, 0, time.UTC)
+	writeVersion1Index(t, dir, map[string]string{
+		"https://www.cse.ust.hk/":  "{id}/page/Home/page/https://www.cse.ust.hk//page/1234/page/" + date.Format(time.RFC3339) + "/page/The department/page/en",
+		"https://www.cse.ust.hk/a": "{id}/page/Old page/page/https://www.cse.ust.hk/a/page/10/page/" + date.Format(time.RFC3339),
+	})
+
+	indexes, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexes.Close()
+	if version, err := indexes.SchemaVersion(); err != nil || version != SchemaVersion {
+		t.Errorf("schema version %d after opening, %v", version, err)
+	}
+	home, _ := indexes.DocumentIndexer.GetValueFromKey("https://www.cse.ust.hk/")
+	page, err := indexes.PagePropertiesIndexer.GetPagePropertiesFromKey(home)
+	if err != nil || page.GetId() != home || page.GetTitle() != "Home" || page.GetUrl() != "https://www.cse.ust.hk/" || page.GetSize() != 1234 ||
+		!page.GetDate().Equal(date) || page.GetDescription() != "The department" || page.GetLanguage() != "en" {
+		t.Errorf("migrated page %+v, %v", page, err)
+	}
+	a, _ := indexes.DocumentIndexer.GetValueFromKey("https://www.cse.ust.hk/a")
+	if page, err := indexes.PagePropertiesIndexer.GetPagePropertiesFromKey(a); err != nil || page.GetTitle() != "Old page" || page.GetDescription() != "" {
+		t.Errorf("migrated page without a description %+v, %v", page, err)
+	}
+}
+
+func TestMigrateTruncatedPage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeVersion1Index(t, dir, map[string]string{
+		"https://www.cse.ust.hk/": "{id}/page/Home/page/https://www.cse.ust.hk/",
+	})
+
+	indexes, err := Open(dir)
+	if err == nil {
+		indexes.Close()
+		t.Fatal("index with truncated page properties opened")
+	}
+	if !strings.Contains(err.Error(), "page properties of key") {
+		t.Errorf("error does not name the bad key: %s", err)
+	}
+}
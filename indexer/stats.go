@@ -0,0 +1,207 @@
+package Indexer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TermStats is how often a word occurs in the bodies of the documents
+type TermStats struct {
+	Word      string `json:"word"`
+	Frequency uint64 `json:"frequency"`
+	Documents int    `json:"documents"`
+}
+
+// RankSummary describes the distribution of the PageRank of the pages
+type RankSummary struct {
+	Pages  int     `json:"pages"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+}
+
+// DatabaseSize is the space one database takes on disk
+type DatabaseSize struct {
+	Name     string `json:"name"`
+	LSM      int64  `json:"lsm"`
+	ValueLog int64  `json:"value_log"`
+}
+
+// Stats describes the size and the contents of the indexes
+type Stats struct {
+	SchemaVersion int `json:"schema_version"`
+	// URLs known, crawled or only linked to
+	Documents int `json:"documents"`
+	// Pages with their properties, i.e. crawled
+	IndexedPages int    `json:"indexed_pages"`
+	Vocabulary   int    `json:"vocabulary"`
+	Postings     uint64 `json:"postings"`
+	// Average number of words in the body of a document
+	AverageDocumentLength float64        `json:"average_document_length"`
+	TopTerms              []TermStats    `json:"top_terms"`
+	Links                 int            `json:"links"`
+	PageRank              RankSummary    `json:"pagerank"`
+	Databases             []DatabaseSize `json:"databases"`
+	Size                  int64          `json:"size"`
+	Crawls                int            `json:"crawls"`
+	LastCrawl             *time.Time     `json:"last_crawl,omitempty"`
+}
+
+// Stats reads every database to describe the indexes, with the limit most
+// frequent words. It goes over all the postings, so it is slow on large indexes.
+func (indexes *Indexes) Stats(limit int) (*Stats, error) {
+	stats := &Stats{}
+	version, err := indexes.SchemaVersion()
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading stats: %s", err)
+	}
+	stats.SchemaVersion = version
+	documents, err := indexes.DocumentIndexer.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading stats: %s", err)
+	}
+	stats.Documents = len(documents)
+	pages, err := indexes.PagePropertiesIndexer.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading stats: %s", err)
+	}
+	stats.IndexedPages = len(pages)
+	stats.Vocabulary = len(indexes.WordIndexer.AllValue())
+
+	for _, inverted := range []*InvertedFileIndexer{
+		indexes.TitleInvertedIndexer,
+		indexes.ContentInvertedIndexer,
+		indexes.HeadingInvertedIndexer,
+		indexes.DescriptionInvertedIndexer,
+		indexes.KeywordsInvertedIndexer,
+		indexes.AltInvertedIndexer,
+		indexes.URLInvertedIndexer,
+	} {
+		postings, err := inverted.Postings()
+		if err != nil {
+			return nil, fmt.Errorf("Error while reading stats: %s", err)
+		}
+		stats.Postings += postings
+	}
+
+	frequencies, err := indexes.DocumentWordForwardIndexer.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading stats: %s", err)
+	}
+	var top []WordFrequency
+	var documentFrequency map[uint64]int
+	stats.AverageDocumentLength, top, documentFrequency = termStats(frequencies, limit)
+	stats.TopTerms = make([]TermStats, len(top))
+	for i, term := range top {
+		// Words missing from the reverse index keep an empty name, verify reports them
+		word, _ := indexes.ReverseWordIndexer.GetValueFromKey(term.WordID)
+		stats.TopTerms[i] = TermStats{word, term.Frequency, documentFrequency[term.WordID]}
+	}
+
+	links, err := indexes.ParentChildDocumentForwardIndexer.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading stats: %s", err)
+	}
+	stats.Links = countLinks(links)
+
+	ranks, err := indexes.PageRankIndexer.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading stats: %s", err)
+	}
+	stats.PageRank = summarizeRanks(ranks)
+
+	stats.Databases = make([]DatabaseSize, 0)
+	for _, database := range indexes.Databases() {
+		lsm, vlog := database.Database.Size()
+		stats.Databases = append(stats.Databases, DatabaseSize{database.Name, lsm, vlog})
+		stats.Size += lsm + vlog
+	}
+
+	reports, err := indexes.CrawlReportIndexer.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading stats: %s", err)
+	}
+	stats.Crawls = len(reports)
+	if len(reports) > 0 {
+		last := reports[len(reports)-1].Start
+		stats.LastCrawl = &last
+	}
+	return stats, nil
+}
+
+// termStats finds the average length of the documents, the limit words
+// occurring the most and in how many documents each word occurs
+func termStats(frequencies map[uint64][]WordFrequency, limit int) (float64, []WordFrequency, map[uint64]int) {
+	totals := make(map[uint64]uint64)
+	documentFrequency := make(map[uint64]int)
+	var length uint64
+	for _, list := range frequencies {
+		for _, word := range list {
+			totals[word.WordID] += word.Frequency
+			documentFrequency[word.WordID]++
+			length += word.Frequency
+		}
+	}
+	var average float64
+	if len(frequencies) > 0 {
+		average = float64(length) / float64(len(frequencies))
+	}
+
+	top := make([]WordFrequency, 0, len(totals))
+	for id, frequency := range totals {
+		top = append(top, WordFrequency{id, frequency})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Frequency != top[j].Frequency {
+			return top[i].Frequency > top[j].Frequency
+		}
+		return top[i].WordID < top[j].WordID
+	})
+	if limit >= 0 && len(top) > limit {
+		top = top[:limit]
+	}
+	return average, top, documentFrequency
+}
+
+// countLinks counts the distinct links of every page
+func countLinks(links map[uint64][]uint64) int {
+	count := 0
+	for _, children := range links {
+		seen := make(map[uint64]bool, len(children))
+		for _, child := range children {
+			if !seen[child] {
+				seen[child] = true
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func summarizeRanks(ranks map[uint64]float64) RankSummary {
+	summary := RankSummary{Pages: len(ranks)}
+	if len(ranks) == 0 {
+		return summary
+	}
+	values := make([]float64, 0, len(ranks))
+	for _, rank := range ranks {
+		values = append(values, rank)
+	}
+	// Summed in order, the mean does not depend on the order of the map
+	sort.Float64s(values)
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	summary.Min = values[0]
+	summary.Max = values[len(values)-1]
+	summary.Mean = sum / float64(len(values))
+	if middle := len(values) / 2; len(values)%2 == 1 {
+		summary.Median = values[middle]
+	} else {
+		summary.Median = (values[middle-1] + values[middle]) / 2
+	}
+	return summary
+}
@@ -0,0 +1,49 @@
+package Indexer
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTermStats(t *testing.T) {
+	frequencies := map[uint64][]WordFrequency{
+		1: {{10, 3}, {11, 1}},
+		2: {{10, 1}, {12, 4}},
+		3: {},
+	}
+	average, top, documents := termStats(frequencies, 2)
+	if average != 3 {
+		t.Errorf("average length is %g, expected 3", average)
+	}
+	if expected := []WordFrequency{{10, 4}, {12, 4}}; !reflect.DeepEqual(top, expected) {
+		t.Errorf("top terms are %v, expected %v", top, expected)
+	}
+	if documents[10] != 2 || documents[11] != 1 {
+		t.Errorf("document frequencies are %v", documents)
+	}
+}
+
+func TestCountLinks(t *testing.T) {
+	if count := countLinks(map[uint64][]uint64{1: {2, 3, 2}, 2: {1}, 3: {}}); count != 3 {
+		t.Errorf("counted %d links, expected 3", count)
+	}
+}
+
+func TestSummarizeRanks(t *testing.T) {
+	ranks := map[uint64]float64{1: 0.1, 2: 0.4, 3: 0.2, 4: 0.3}
+	summary := summarizeRanks(ranks)
+	expected := RankSummary{Pages: 4, Min: 0.1, Max: 0.4, Mean: 0.25, Median: 0.25}
+	if summary.Pages != expected.Pages || summary.Min != expected.Min || summary.Max != expected.Max ||
+		math.Abs(summary.Mean-expected.Mean) > 1e-9 || math.Abs(summary.Median-expected.Median) > 1e-9 {
+		t.Errorf("summary is %+v, expected %+v", summary, expected)
+	}
+	for i := 0; i < 10; i++ {
+		if again := summarizeRanks(ranks); again != summary {
+			t.Fatalf("summary is %+v, then %+v", summary, again)
+		}
+	}
+	if summary := summarizeRanks(nil); summary != (RankSummary{}) {
+		t.Errorf("summary of no ranks is %+v", summary)
+	}
+}
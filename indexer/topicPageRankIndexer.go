@@ -0,0 +1,186 @@
+package Indexer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/dgraph-io/badger"
+)
+
+// Topic + Page ID -> topic sensitive PageRank Indexer. Keys are the topic name,
+// a zero byte and the page ID, so the ranks of a topic are stored together.
+type TopicPageRankIndexer struct {
+	db           *badger.DB
+	databasePath string
+}
+
+// After initializing the TopicPageRankIndexer, we need to call defer TopicPageRankIndexer.Release()
+func (topicPageRankIndexer *TopicPageRankIndexer) Initialize(path string) error {
+	return topicPageRankIndexer.InitializeWithOptions(path, badger.DefaultOptions)
+}
+
+// InitializeWithOptions opens the database in a directory with badger options
+func (topicPageRankIndexer *TopicPageRankIndexer) InitializeWithOptions(path string, options badger.Options) error {
+	if err := os.MkdirAll(path, 0774); err != nil {
+		return err
+	}
+	options.Dir = path
+	options.ValueDir = path
+	db, err := badger.Open(options)
+	if err != nil {
+		return fmt.Errorf("Error while initializing: %s", err)
+	}
+	topicPageRankIndexer.db = db
+	topicPageRankIndexer.databasePath = path
+	return err
+}
+
+func (topicPageRankIndexer *TopicPageRankIndexer) Release() error {
+	return topicPageRankIndexer.db.Close()
+}
+
+// Size returns the bytes used on disk by the LSM tree and the value log
+func (topicPageRankIndexer *TopicPageRankIndexer) Size() (int64, int64) {
+	return topicPageRankIndexer.db.Size()
+}
+
+// Backup writes a snapshot of the database, it can be taken while the database is in use
+func (topicPageRankIndexer *TopicPageRankIndexer) Backup(w io.Writer) error {
+	_, err := topicPageRankIndexer.db.Backup(w, 0)
+	return err
+}
+
+func topicKey(topic string, key uint64) []byte {
+	return append([]byte(topic+"\x00"), uint64ToByte(key)...)
+}
+
+func splitTopicKey(k []byte) (string, uint64) {
+	return string(k[:len(k)-9]), byteToUint64(k[len(k)-8:])
+}
+
+// AddKeyToIndex stores the rank of a page for a topic, replacing the previous one
+func (topicPageRankIndexer *TopicPageRankIndexer) AddKeyToIndex(topic string, key uint64, value float64) error {
+	err := topicPageRankIndexer.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(topicKey(topic, key), []byte(fmt.Sprintf("%.6f", value)))
+	})
+	if err != nil {
+		err = fmt.Errorf("Error in adding Key to Index: %s", err)
+	}
+	return err
+}
+
+func (topicPageRankIndexer *TopicPageRankIndexer) GetValueFromKey(topic string, key uint64) (float64, error) {
+	var result float64
+	err := topicPageRankIndexer.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(topicKey(topic, key))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			var floatErr error
+			result, floatErr = strconv.ParseFloat(string(val), 64)
+			return floatErr
+		})
+	})
+	if err != nil {
+		err = fmt.Errorf("Error in getting Value from Key: %s", err)
+	}
+	return result, err
+}
+
+// Topics lists the topics with stored ranks, seeking past the ranks of each one
+func (topicPageRankIndexer *TopicPageRankIndexer) Topics() ([]string, error) {
+	topics := make([]string, 0)
+	err := topicPageRankIndexer.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); {
+			topic, _ := splitTopicKey(it.Item().Key())
+			topics = append(topics, topic)
+			it.Seek([]byte(topic + "\x01"))
+		}
+		return nil
+	})
+	return topics, err
+}
+
+// DeleteTopic removes all the ranks of a topic
+func (topicPageRankIndexer *TopicPageRankIndexer) DeleteTopic(topic string) error {
+	prefix := []byte(topic + "\x00")
+	keys := make([][]byte, 0)
+	err := topicPageRankIndexer.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Error when deleting topic: %s", err)
+	}
+	for _, k := range keys {
+		err = topicPageRankIndexer.db.Update(func(txn *badger.Txn) error {
+			return txn.Delete(k)
+		})
+		if err != nil {
+			return fmt.Errorf("Error when deleting topic: %s", err)
+		}
+	}
+	return nil
+}
+
+func (topicPageRankIndexer *TopicPageRankIndexer) Iterate() {
+	fmt.Println("Iterating over Topic PageRank Index")
+	_ = topicPageRankIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			topic, key := splitTopicKey(item.Key())
+			err := item.Value(func(v []byte) error {
+				fmt.Printf("topic=%s, key=%d, value=%s\n", topic, key, v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// All returns the ranks of every topic
+func (topicPageRankIndexer *TopicPageRankIndexer) All() (map[string]map[uint64]float64, error) {
+	result := make(map[string]map[uint64]float64)
+	err := topicPageRankIndexer.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			topic, key := splitTopicKey(item.Key())
+			err := item.Value(func(v []byte) error {
+				value, err := strconv.ParseFloat(string(v), 64)
+				if result[topic] == nil {
+					result[topic] = make(map[uint64]float64)
+				}
+				result[topic][key] = value
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("Error while reading topic ranks: %s", err)
+	}
+	return result, err
+}
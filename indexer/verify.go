@@ -0,0 +1,433 @@
+package Indexer
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Classes of inconsistencies between the indexes
+const (
+	// An ID of the document or word index has no reverse mapping
+	MissingReverse = "missing reverse mapping"
+	// A reverse mapping has no ID in the document or word index, or another value
+	StaleReverse = "stale reverse mapping"
+	// A page links to a document that is not in the document index
+	UnknownLink = "link to unknown document"
+	// A parent to child link has no child to parent link, or the other way round
+	OneWayLink = "one-way link"
+	// A word list or an inverted file refers to a word that is not in the word index
+	UnknownWord = "unknown word"
+	// A database has an entry for a document that is not in the document index
+	UnknownDocument = "entry of unknown document"
+	// A posting points at a page without page properties
+	PostingWithoutPage = "posting without page properties"
+)
+
+// Problem is an inconsistency between the indexes. Related is the other
+// document or word involved, if any.
+type Problem struct {
+	Class    string
+	Database string
+	Key      uint64
+	Related  uint64
+	Message  string
+}
+
+func (problem Problem) String() string {
+	return fmt.Sprintf("%s %d: %s", problem.Database, problem.Key, problem.Message)
+}
+
+// ProblemClass counts the problems of a class, with the first few as examples
+type ProblemClass struct {
+	Class    string
+	Count    int
+	Examples []Problem
+}
+
+// Summarize groups problems by class, the most frequent first
+func Summarize(problems []Problem, examples int) []ProblemClass {
+	classes := make([]ProblemClass, 0)
+	index := make(map[string]int)
+	for _, problem := range problems {
+		i, ok := index[problem.Class]
+		if !ok {
+			i = len(classes)
+			index[problem.Class] = i
+			classes = append(classes, ProblemClass{Class: problem.Class})
+		}
+		classes[i].Count++
+		if len(classes[i].Examples) < examples {
+			classes[i].Examples = append(classes[i].Examples, problem)
+		}
+	}
+	sort.SliceStable(classes, func(i, j int) bool {
+		return classes[i].Count > classes[j].Count
+	})
+	return classes
+}
+
+// snapshot is the contents of the databases Verify checks
+type snapshot struct {
+	urls         map[string]uint64
+	documentURLs map[uint64]string
+	words        map[string]uint64
+	wordNames    map[uint64]string
+	pages        map[uint64]bool
+	parentChild  map[uint64][]uint64
+	childParent  map[uint64][]uint64
+	// Keyed by database name
+	forward  map[string]map[uint64][]WordFrequency
+	inverted map[string]map[uint64][]InvertedFile
+	ranks    map[string]map[uint64]float64
+}
+
+func (indexes *Indexes) forwardIndexers() map[string]*DocumentWordForwardIndexer {
+	return map[string]*DocumentWordForwardIndexer{
+		"documentWordForwardIndex": indexes.DocumentWordForwardIndexer,
+		"titleWordForwardIndex":    indexes.TitleWordForwardIndexer,
+	}
+}
+
+func (indexes *Indexes) invertedIndexers() map[string]*InvertedFileIndexer {
+	return map[string]*InvertedFileIndexer{
+		"titleInvertedIndex":       indexes.TitleInvertedIndexer,
+		"contentInvertedIndex":     indexes.ContentInvertedIndexer,
+		"headingInvertedIndex":     indexes.HeadingInvertedIndexer,
+		"descriptionInvertedIndex": indexes.DescriptionInvertedIndexer,
+		"keywordsInvertedIndex":    indexes.KeywordsInvertedIndexer,
+		"altInvertedIndex":         indexes.AltInvertedIndexer,
+		"urlInvertedIndex":         indexes.URLInvertedIndexer,
+	}
+}
+
+func (indexes *Indexes) rankIndexers() map[string]*PageRankIndexer {
+	return map[string]*PageRankIndexer{
+		"pageRankIndex":  indexes.PageRankIndexer,
+		"hubIndex":       indexes.HubIndexer,
+		"authorityIndex": indexes.AuthorityIndexer,
+	}
+}
+
+func (indexes *Indexes) snapshot() (*snapshot, error) {
+	var err error
+	s := &snapshot{
+		pages:    make(map[uint64]bool),
+		forward:  make(map[string]map[uint64][]WordFrequency),
+		inverted: make(map[string]map[uint64][]InvertedFile),
+		ranks:    make(map[string]map[uint64]float64),
+	}
+	if s.urls, err = indexes.DocumentIndexer.Mapping(); err != nil {
+		return nil, err
+	}
+	if s.documentURLs, err = indexes.ReverseDocumentIndexer.All(); err != nil {
+		return nil, err
+	}
+	if s.words, err = indexes.WordIndexer.Mapping(); err != nil {
+		return nil, err
+	}
+	if s.wordNames, err = indexes.ReverseWordIndexer.All(); err != nil {
+		return nil, err
+	}
+	pages, err := indexes.PagePropertiesIndexer.All()
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		s.pages[page.GetId()] = true
+	}
+	if s.parentChild, err = indexes.ParentChildDocumentForwardIndexer.All(); err != nil {
+		return nil, err
+	}
+	if s.childParent, err = indexes.ChildParentDocumentForwardIndexer.All(); err != nil {
+		return nil, err
+	}
+	for name, indexer := range indexes.forwardIndexers() {
+		if s.forward[name], err = indexer.All(); err != nil {
+			return nil, err
+		}
+	}
+	for name, indexer := range indexes.invertedIndexers() {
+		if s.inverted[name], err = indexer.All(); err != nil {
+			return nil, err
+		}
+	}
+	for name, indexer := range indexes.rankIndexers() {
+		if s.ranks[name], err = indexer.All(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Verify checks the indexes agree with each other: the document and word IDs
+// are mapped both ways, and the links, word lists, postings, page properties
+// and ranks only refer to known documents and words
+func (indexes *Indexes) Verify() ([]Problem, error) {
+	s, err := indexes.snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("Error while verifying indexes: %s", err)
+	}
+	return s.check(), nil
+}
+
+func (s *snapshot) check() []Problem {
+	problems := make([]Problem, 0)
+	documents := ids(s.urls)
+	words := ids(s.words)
+
+	problems = append(problems, checkReverse("reverseDocumentIndexer", "URL", s.urls, s.documentURLs)...)
+	problems = append(problems, checkReverse("reverseWordIndexer", "word", s.words, s.wordNames)...)
+
+	for _, link := range []struct {
+		name, otherName string
+		links, other    map[uint64][]uint64
+	}{
+		{"parentChildDocumentForwardIndex", "childParentDocumentForwardIndex", s.parentChild, s.childParent},
+		{"childParentDocumentForwardIndex", "parentChildDocumentForwardIndex", s.childParent, s.parentChild},
+	} {
+		for _, id := range sortedKeys(link.links) {
+			if !documents[id] {
+				problems = append(problems, Problem{UnknownDocument, link.name, id, 0, "links of unknown document"})
+				continue
+			}
+			for _, other := range link.links[id] {
+				if !documents[other] {
+					problems = append(problems, Problem{UnknownLink, link.name, id, other, fmt.Sprintf("links to unknown document %d", other)})
+				} else if !contains(link.other[other], id) {
+					problems = append(problems, Problem{OneWayLink, link.name, id, other, fmt.Sprintf("link to %d is missing from %s", other, link.otherName)})
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedNames(s.forward) {
+		for _, id := range sortedKeys(s.forward[name]) {
+			if !documents[id] {
+				problems = append(problems, Problem{UnknownDocument, name, id, 0, "word list of unknown document"})
+				continue
+			}
+			for _, frequency := range s.forward[name][id] {
+				if !words[frequency.WordID] {
+					problems = append(problems, Problem{UnknownWord, name, id, frequency.WordID, fmt.Sprintf("has unknown word %d", frequency.WordID)})
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedNames(s.inverted) {
+		for _, wordID := range sortedKeys(s.inverted[name]) {
+			if !words[wordID] {
+				problems = append(problems, Problem{UnknownWord, name, wordID, 0, "postings of unknown word"})
+				continue
+			}
+			for _, posting := range s.inverted[name][wordID] {
+				if !s.pages[posting.pageID] {
+					problems = append(problems, Problem{PostingWithoutPage, name, wordID, posting.pageID, fmt.Sprintf("posting of page %d without properties", posting.pageID)})
+				}
+			}
+		}
+	}
+
+	for _, id := range sortedKeys(s.pages) {
+		if !documents[id] {
+			problems = append(problems, Problem{UnknownDocument, "pagePropertiesIndex", id, 0, "page properties of unknown document"})
+		}
+	}
+	for _, name := range sortedNames(s.ranks) {
+		for _, id := range sortedKeys(s.ranks[name]) {
+			if !documents[id] {
+				problems = append(problems, Problem{UnknownDocument, name, id, 0, "rank of unknown document"})
+			}
+		}
+	}
+	return problems
+}
+
+// checkReverse compares a mapping from names to IDs with its reverse mapping
+func checkReverse(database string, kind string, mapping map[string]uint64, reverse map[uint64]string) []Problem {
+	problems := make([]Problem, 0)
+	byID := names(mapping)
+	for _, id := range sortedKeys(byID) {
+		if _, ok := reverse[id]; !ok {
+			problems = append(problems, Problem{MissingReverse, database, id, 0, fmt.Sprintf("%s %s has no reverse mapping", kind, byID[id])})
+		}
+	}
+	for _, id := range sortedKeys(reverse) {
+		if name, ok := byID[id]; !ok {
+			problems = append(problems, Problem{StaleReverse, database, id, 0, fmt.Sprintf("%s %s is not mapped to this ID", kind, reverse[id])})
+		} else if name != reverse[id] {
+			problems = append(problems, Problem{StaleReverse, database, id, 0, fmt.Sprintf("maps to %s %s instead of %s", kind, reverse[id], name)})
+		}
+	}
+	return problems
+}
+
+// Repair rebuilds the databases derived from the authoritative ones, so that
+// Verify finds no problem. The document and word indexes, the parent to child
+// links, the word lists and the postings are authoritative: reverse mappings
+// and child to parent links are rebuilt from them, and entries referring to
+// unknown documents, words or pages are removed. It returns the problems fixed.
+func (indexes *Indexes) Repair() ([]Problem, error) {
+	indexes.BeginWrite()
+	defer indexes.EndWrite()
+	s, err := indexes.snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("Error while repairing indexes: %s", err)
+	}
+	problems := s.check()
+	documents := ids(s.urls)
+	words := ids(s.words)
+	urls := names(s.urls)
+	wordNames := names(s.words)
+	rebuildLinks := false
+
+	for _, problem := range problems {
+		switch {
+		case problem.Database == "reverseDocumentIndexer":
+			err = repairReverse(indexes.ReverseDocumentIndexer, problem.Key, urls)
+		case problem.Database == "reverseWordIndexer":
+			err = repairReverse(indexes.ReverseWordIndexer, problem.Key, wordNames)
+		case problem.Class == UnknownLink || problem.Class == OneWayLink:
+			rebuildLinks = true
+		case problem.Database == "parentChildDocumentForwardIndex":
+			err = indexes.ParentChildDocumentForwardIndexer.DeleteKeyValuePair(problem.Key)
+		case problem.Database == "childParentDocumentForwardIndex":
+			// Rebuilt from the parent to child links
+			rebuildLinks = true
+		case problem.Database == "pagePropertiesIndex":
+			err = indexes.PagePropertiesIndexer.DeletePagePropertiesFromKey(problem.Key)
+		case indexes.forwardIndexers()[problem.Database] != nil:
+			forward := indexes.forwardIndexers()[problem.Database]
+			if problem.Class == UnknownDocument {
+				err = forward.DeleteKeyValuePair(problem.Key)
+				break
+			}
+			list := make([]WordFrequency, 0)
+			for _, frequency := range s.forward[problem.Database][problem.Key] {
+				if words[frequency.WordID] {
+					list = append(list, frequency)
+				}
+			}
+			err = forward.AddWordFrequencyListToKey(problem.Key, list)
+		case indexes.invertedIndexers()[problem.Database] != nil:
+			inverted := indexes.invertedIndexers()[problem.Database]
+			if problem.Class == UnknownWord {
+				err = inverted.DeleteAllInvertedFileFromKey(problem.Key)
+			} else {
+				err = inverted.DeleteInvertedFileFromWordListAndPage([]uint64{problem.Key}, problem.Related)
+			}
+		case indexes.rankIndexers()[problem.Database] != nil:
+			err = indexes.rankIndexers()[problem.Database].DeleteKeyValuePair(problem.Key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error while repairing %s: %s", problem, err)
+		}
+	}
+
+	if rebuildLinks {
+		if err := indexes.rebuildLinks(s.parentChild, s.childParent, documents); err != nil {
+			return nil, fmt.Errorf("Error while repairing links: %s", err)
+		}
+	}
+	if len(problems) > 0 {
+		if _, err := indexes.BumpGeneration(); err != nil {
+			return nil, err
+		}
+	}
+	return problems, nil
+}
+
+// repairReverse sets the reverse mapping of an ID to its name, or deletes it
+func repairReverse(reverse *ReverseMappingIndexer, id uint64, names map[uint64]string) error {
+	if name, ok := names[id]; ok {
+		return reverse.SetValue(id, name)
+	}
+	return reverse.DeleteKeyValuePair(id)
+}
+
+// rebuildLinks drops the links to unknown documents and writes the child to
+// parent links again from the parent to child ones
+func (indexes *Indexes) rebuildLinks(parentChild, childParent map[uint64][]uint64, documents map[uint64]bool) error {
+	parents := make(map[uint64][]uint64)
+	for _, parent := range sortedKeys(parentChild) {
+		if !documents[parent] {
+			continue
+		}
+		children := make([]uint64, 0, len(parentChild[parent]))
+		for _, child := range parentChild[parent] {
+			if documents[child] {
+				children = append(children, child)
+				if !contains(parents[child], parent) {
+					parents[child] = append(parents[child], parent)
+				}
+			}
+		}
+		if len(children) != len(parentChild[parent]) {
+			if err := indexes.ParentChildDocumentForwardIndexer.AddIdListToKey(parent, children); err != nil {
+				return err
+			}
+		}
+	}
+	for child := range childParent {
+		if _, ok := parents[child]; !ok {
+			if err := indexes.ChildParentDocumentForwardIndexer.DeleteKeyValuePair(child); err != nil {
+				return err
+			}
+		}
+	}
+	for child, list := range parents {
+		if err := indexes.ChildParentDocumentForwardIndexer.AddIdListToKey(child, list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ids(mapping map[string]uint64) map[uint64]bool {
+	result := make(map[uint64]bool, len(mapping))
+	for _, id := range mapping {
+		result[id] = true
+	}
+	return result
+}
+
+func contains(list []uint64, id uint64) bool {
+	for _, v := range list {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func names(mapping map[string]uint64) map[uint64]string {
+	result := make(map[uint64]string, len(mapping))
+	for name, id := range mapping {
+		result[id] = name
+	}
+	return result
+}
+
+// sortedKeys returns the keys of a map keyed by ID in order, so that problems
+// are reported in the same order every time
+func sortedKeys(mapping interface{}) []uint64 {
+	keys := reflect.ValueOf(mapping).MapKeys()
+	result := make([]uint64, len(keys))
+	for i, key := range keys {
+		result[i] = key.Uint()
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// sortedNames returns the keys of a map keyed by database name in order
+func sortedNames(mapping interface{}) []string {
+	keys := reflect.ValueOf(mapping).MapKeys()
+	result := make([]string, len(keys))
+	for i, key := range keys {
+		result[i] = key.String()
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,83 @@
+package Indexer
+
+import "testing"
+
+func consistentSnapshot() *snapshot {
+	return &snapshot{
+		urls:         map[string]uint64{"http://a/": 1, "http://b/": 2},
+		documentURLs: map[uint64]string{1: "http://a/", 2: "http://b/"},
+		words:        map[string]uint64{"cat": 10, "dog": 11},
+		wordNames:    map[uint64]string{10: "cat", 11: "dog"},
+		pages:        map[uint64]bool{1: true, 2: true},
+		parentChild:  map[uint64][]uint64{1: {2}},
+		childParent:  map[uint64][]uint64{2: {1}},
+		forward: map[string]map[uint64][]WordFrequency{
+			"documentWordForwardIndex": {1: {{10, 2}}, 2: {{11, 1}}},
+		},
+		inverted: map[string]map[uint64][]InvertedFile{
+			"contentInvertedIndex": {10: {{1, []uint64{0, 3}}}, 11: {{2, []uint64{0}}}},
+		},
+		ranks: map[string]map[uint64]float64{
+			"pageRankIndex": {1: 0.4, 2: 0.6},
+		},
+	}
+}
+
+func TestCheckConsistent(t *testing.T) {
+	if problems := consistentSnapshot().check(); len(problems) != 0 {
+		t.Errorf("consistent indexes have problems %v", problems)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := consistentSnapshot()
+	delete(s.documentURLs, 2)
+	s.wordNames[12] = "bird"
+	s.wordNames[11] = "fish"
+	s.parentChild[1] = []uint64{2, 3}
+	s.parentChild[2] = []uint64{1}
+	s.forward["documentWordForwardIndex"][2] = []WordFrequency{{11, 1}, {13, 1}}
+	s.forward["documentWordForwardIndex"][4] = []WordFrequency{{10, 1}}
+	s.inverted["contentInvertedIndex"][14] = []InvertedFile{{1, nil}}
+	s.inverted["contentInvertedIndex"][10] = append(s.inverted["contentInvertedIndex"][10], InvertedFile{5, nil})
+	s.ranks["pageRankIndex"][6] = 0.1
+
+	expected := []Problem{
+		{MissingReverse, "reverseDocumentIndexer", 2, 0, ""},
+		{StaleReverse, "reverseWordIndexer", 11, 0, ""},
+		{StaleReverse, "reverseWordIndexer", 12, 0, ""},
+		{UnknownLink, "parentChildDocumentForwardIndex", 1, 3, ""},
+		{OneWayLink, "parentChildDocumentForwardIndex", 2, 1, ""},
+		{UnknownWord, "documentWordForwardIndex", 2, 13, ""},
+		{UnknownDocument, "documentWordForwardIndex", 4, 0, ""},
+		{PostingWithoutPage, "contentInvertedIndex", 10, 5, ""},
+		{UnknownWord, "contentInvertedIndex", 14, 0, ""},
+		{UnknownDocument, "pageRankIndex", 6, 0, ""},
+	}
+	problems := s.check()
+	if len(problems) != len(expected) {
+		t.Fatalf("found problems %v, expected %d", problems, len(expected))
+	}
+	for i, problem := range problems {
+		problem.Message = ""
+		if problem != expected[i] {
+			t.Errorf("problem %d is %+v, expected %+v", i, problem, expected[i])
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	problems := []Problem{
+		{Class: UnknownWord, Key: 1},
+		{Class: OneWayLink, Key: 2},
+		{Class: UnknownWord, Key: 3},
+		{Class: UnknownWord, Key: 4},
+	}
+	classes := Summarize(problems, 2)
+	if len(classes) != 2 || classes[0].Class != UnknownWord || classes[0].Count != 3 || classes[1].Count != 1 {
+		t.Fatalf("classes are %+v", classes)
+	}
+	if len(classes[0].Examples) != 2 || classes[0].Examples[1].Key != 3 {
+		t.Errorf("examples are %+v", classes[0].Examples)
+	}
+}
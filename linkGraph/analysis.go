@@ -0,0 +1,225 @@
+package linkGraph
+
+import (
+	"sort"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// ShortestPath returns the page numbers of a shortest click path from page
+// from to page to, both included, or nil if to cannot be reached
+func (graph *Graph) ShortestPath(from int, to int) []int {
+	previous := make([]int32, graph.Len())
+	for i := range previous {
+		previous[i] = -1
+	}
+	previous[from] = int32(from)
+	queue := []int{from}
+	for len(queue) > 0 && previous[to] < 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, target := range graph.Links(i) {
+			if previous[target] < 0 {
+				previous[target] = int32(i)
+				queue = append(queue, int(target))
+			}
+		}
+	}
+	if previous[to] < 0 {
+		return nil
+	}
+	path := []int{to}
+	for i := to; i != from; i = int(previous[i]) {
+		path = append(path, int(previous[i]))
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Depths returns the number of clicks needed to reach each page from page
+// root, or -1 for pages that cannot be reached
+func (graph *Graph) Depths(root int) []int {
+	depths := make([]int, graph.Len())
+	for i := range depths {
+		depths[i] = -1
+	}
+	depths[root] = 0
+	queue := []int{root}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, target := range graph.Links(i) {
+			if depths[target] < 0 {
+				depths[target] = depths[i] + 1
+				queue = append(queue, int(target))
+			}
+		}
+	}
+	return depths
+}
+
+// DepthDistribution counts the pages at each click depth from page root.
+// counts[d] is the number of pages d clicks away, unreachable the rest.
+func (graph *Graph) DepthDistribution(root int) (counts []int, unreachable int) {
+	counts = make([]int, 0)
+	for _, depth := range graph.Depths(root) {
+		if depth < 0 {
+			unreachable++
+			continue
+		}
+		for len(counts) <= depth {
+			counts = append(counts, 0)
+		}
+		counts[depth]++
+	}
+	return counts, unreachable
+}
+
+// Orphans returns the page numbers of pages no other page links to
+func (graph *Graph) Orphans() []int {
+	linked := make([]bool, graph.Len())
+	for source := 0; source < graph.Len(); source++ {
+		for _, target := range graph.Links(source) {
+			if int(target) != source {
+				linked[target] = true
+			}
+		}
+	}
+	orphans := make([]int, 0)
+	for i := range linked {
+		if !linked[i] {
+			orphans = append(orphans, i)
+		}
+	}
+	return orphans
+}
+
+// StronglyConnectedComponents returns the groups of pages that can all reach
+// each other, largest first. It is Tarjan's algorithm with an explicit stack,
+// so deep crawls do not overflow the goroutine stack.
+func (graph *Graph) StronglyConnectedComponents() [][]int {
+	n := graph.Len()
+	index := make([]int32, n)
+	lowLink := make([]int32, n)
+	onStack := make([]bool, n)
+	for i := range index {
+		index[i] = -1
+	}
+	stack := make([]int, 0)
+	components := make([][]int, 0)
+	next := int32(0)
+
+	// Each frame is a page and the position of the next link to visit
+	type frame struct {
+		page int
+		link int32
+	}
+	for start := 0; start < n; start++ {
+		if index[start] >= 0 {
+			continue
+		}
+		calls := []frame{{page: start}}
+		index[start], lowLink[start] = next, next
+		next++
+		stack = append(stack, start)
+		onStack[start] = true
+		for len(calls) > 0 {
+			top := &calls[len(calls)-1]
+			page := top.page
+			if links := graph.Links(page); int(top.link) < len(links) {
+				target := int(links[top.link])
+				top.link++
+				if index[target] < 0 {
+					index[target], lowLink[target] = next, next
+					next++
+					stack = append(stack, target)
+					onStack[target] = true
+					calls = append(calls, frame{page: target})
+				} else if onStack[target] && index[target] < lowLink[page] {
+					lowLink[page] = index[target]
+				}
+				continue
+			}
+
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				parent := calls[len(calls)-1].page
+				if lowLink[page] < lowLink[parent] {
+					lowLink[parent] = lowLink[page]
+				}
+			}
+			if lowLink[page] == index[page] {
+				component := make([]int, 0)
+				for {
+					member := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[member] = false
+					component = append(component, member)
+					if member == page {
+						break
+					}
+				}
+				sort.Ints(component)
+				components = append(components, component)
+			}
+		}
+	}
+	sort.SliceStable(components, func(i, j int) bool { return len(components[i]) > len(components[j]) })
+	return components
+}
+
+// BrokenLink is a link to a page that could not be fetched or did not return 200
+type BrokenLink struct {
+	Source     string `json:"source"`
+	Target     string `json:"target"`
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BrokenLinks lists the links to the failed URLs of a crawl report. Links
+// rejected by the link check are recorded with the page they were found on;
+// for pages that failed when fetched, the pages linking to them are looked up
+// in the graph.
+func (graph *Graph) BrokenLinks(report Indexer.CrawlReport, documentIndexer *Indexer.MappingIndexer, reverseDocument *Indexer.ReverseMappingIndexer) []BrokenLink {
+	var reversed *Graph
+	links := make([]BrokenLink, 0)
+	seen := make(map[[2]string]bool)
+	add := func(link BrokenLink) {
+		if key := [2]string{link.Source, link.Target}; !seen[key] {
+			seen[key] = true
+			links = append(links, link)
+		}
+	}
+	for _, failure := range report.Failures {
+		link := BrokenLink{Target: failure.URL, StatusCode: failure.StatusCode, Error: failure.Error}
+		if failure.Referrer != "" {
+			link.Source = failure.Referrer
+			add(link)
+			continue
+		}
+		id, err := documentIndexer.GetValueFromKey(failure.URL)
+		if err != nil {
+			continue
+		}
+		i, ok := graph.Index(id)
+		if !ok {
+			continue
+		}
+		if reversed == nil {
+			reversed = graph.Reverse()
+		}
+		for _, parent := range reversed.Links(i) {
+			link.Source, _ = reverseDocument.GetValueFromKey(graph.IDs[parent])
+			add(link)
+		}
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].Source != links[j].Source {
+			return links[i].Source < links[j].Source
+		}
+		return links[i].Target < links[j].Target
+	})
+	return links
+}
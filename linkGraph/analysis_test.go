@@ -0,0 +1,65 @@
+package linkGraph
+
+import (
+	"reflect"
+	"testing"
+)
+
+// 1 -> 2 -> 3 -> 1 is a cycle, 3 -> 4 -> 5 -> 4 another, 6 links in but is never linked to
+func testGraph(t *testing.T) *Graph {
+	links := map[uint64][]uint64{1: {2}, 2: {3}, 3: {1, 4}, 4: {5}, 5: {4}, 6: {1, 6}}
+	graph, err := NewGraph([]uint64{1, 2, 3, 4, 5, 6}, func(id uint64) ([]uint64, error) {
+		return links[id], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return graph
+}
+
+func TestShortestPath(t *testing.T) {
+	graph := testGraph(t)
+	if path := graph.ShortestPath(0, 4); !reflect.DeepEqual(path, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("path from 1 to 5 is %v", path)
+	}
+	if path := graph.ShortestPath(2, 2); !reflect.DeepEqual(path, []int{2}) {
+		t.Errorf("path from 3 to itself is %v", path)
+	}
+	if path := graph.ShortestPath(3, 0); path != nil {
+		t.Errorf("path from 4 to 1 is %v, expected none", path)
+	}
+}
+
+func TestDepthDistribution(t *testing.T) {
+	counts, unreachable := testGraph(t).DepthDistribution(0)
+	if !reflect.DeepEqual(counts, []int{1, 1, 1, 1, 1}) || unreachable != 1 {
+		t.Errorf("depth distribution is %v with %d unreachable", counts, unreachable)
+	}
+}
+
+func TestOrphans(t *testing.T) {
+	// A link to itself does not stop a page being an orphan
+	if orphans := testGraph(t).Orphans(); !reflect.DeepEqual(orphans, []int{5}) {
+		t.Errorf("orphans are %v", orphans)
+	}
+}
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	components := testGraph(t).StronglyConnectedComponents()
+	expected := [][]int{{0, 1, 2}, {3, 4}, {5}}
+	if !reflect.DeepEqual(components, expected) {
+		t.Errorf("components are %v, expected %v", components, expected)
+	}
+
+	// A long chain would overflow a recursive implementation
+	n := 1000000
+	graph := &Graph{IDs: make([]uint64, n), Offsets: make([]int32, n+1), Targets: make([]int32, n)}
+	for i := 0; i < n; i++ {
+		graph.IDs[i] = uint64(i)
+		graph.Offsets[i+1] = int32(i + 1)
+		graph.Targets[i] = int32((i + 1) % n)
+	}
+	if components := graph.StronglyConnectedComponents(); len(components) != 1 || len(components[0]) != n {
+		t.Errorf("a cycle of %d pages gave %d components", n, len(components))
+	}
+}
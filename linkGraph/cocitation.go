@@ -0,0 +1,38 @@
+package linkGraph
+
+import (
+	"math"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// CoCitation scores the documents cited together with a document: two pages
+// linked from the same parent are likely about related things. The score is
+// the number of shared parents divided by the geometric mean of both pages'
+// parent counts, so it is 1 for pages always linked together.
+func CoCitation(documentID uint64, parentChild *Indexer.ForwardIndexer, childParent *Indexer.ForwardIndexer) (map[uint64]float64, error) {
+	scores := make(map[uint64]float64)
+	parents, err := childParent.GetIdListFromKey(documentID)
+	if err != nil || len(parents) == 0 {
+		return scores, err
+	}
+
+	shared := make(map[uint64]int)
+	for _, parentID := range parents {
+		children, _ := parentChild.GetIdListFromKey(parentID)
+		for _, childID := range children {
+			if childID != documentID {
+				shared[childID]++
+			}
+		}
+	}
+
+	for childID, count := range shared {
+		childParents, _ := childParent.GetIdListFromKey(childID)
+		if len(childParents) == 0 {
+			continue
+		}
+		scores[childID] = float64(count) / math.Sqrt(float64(len(parents)*len(childParents)))
+	}
+	return scores, nil
+}
@@ -0,0 +1,306 @@
+package linkGraph
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// Export formats
+const (
+	FormatGraphML  = "graphml"
+	FormatDOT      = "dot"
+	FormatCSV      = "csv"
+	FormatCSVNodes = "csv-nodes"
+	FormatJSONL    = "jsonl"
+)
+
+var Formats = []string{FormatGraphML, FormatDOT, FormatCSV, FormatCSVNodes, FormatJSONL}
+
+// Node is a page of an exported graph. Degrees count the links of the whole crawl.
+type Node struct {
+	ID        uint64  `json:"id"`
+	URL       string  `json:"url"`
+	Title     string  `json:"title"`
+	PageRank  float64 `json:"pagerank"`
+	InDegree  int     `json:"in_degree"`
+	OutDegree int     `json:"out_degree"`
+}
+
+// Filter selects the part of the graph to export
+type Filter struct {
+	// Only pages on these hosts or their subdomains, all pages if empty
+	Hosts []string
+	// Only pages with at least this PageRank
+	MinPageRank float64
+	// Only pages reachable from these pages in at most Depth clicks, all pages if empty
+	Roots []uint64
+	Depth int
+}
+
+// Export is the selected pages with the links between them
+type Export struct {
+	Nodes []Node
+	Edges [][2]uint64
+}
+
+// NodeInfo looks up the title, URL and PageRank of pages in the indexes.
+// Pages only linked to were never fetched, so they only have their URL.
+func NodeInfo(pageProperties *Indexer.PagePropetiesIndexer, reverseDocument *Indexer.ReverseMappingIndexer, pageRank *Indexer.PageRankIndexer) func(id uint64) Node {
+	return func(id uint64) Node {
+		node := Node{ID: id}
+		if page, err := pageProperties.GetPagePropertiesFromKey(id); err == nil {
+			node.URL = page.GetUrl()
+			node.Title = page.GetTitle()
+		}
+		if node.URL == "" {
+			node.URL, _ = reverseDocument.GetValueFromKey(id)
+		}
+		node.PageRank, _ = pageRank.GetValueFromKey(id)
+		return node
+	}
+}
+
+// Build selects the pages of the graph matching the filter
+func Build(graph *Graph, nodeInfo func(id uint64) Node, filter Filter) *Export {
+	selected := make([]bool, graph.Len())
+	if len(filter.Roots) == 0 {
+		for i := range selected {
+			selected[i] = true
+		}
+	} else {
+		for _, i := range graph.Reachable(filter.Roots, filter.Depth) {
+			selected[i] = true
+		}
+	}
+
+	reversed := graph.Reverse()
+	export := &Export{Nodes: make([]Node, 0), Edges: make([][2]uint64, 0)}
+	for i := range selected {
+		if !selected[i] {
+			continue
+		}
+		node := nodeInfo(graph.IDs[i])
+		node.InDegree = reversed.OutDegree(i)
+		node.OutDegree = graph.OutDegree(i)
+		if node.PageRank < filter.MinPageRank || !onHosts(node.URL, filter.Hosts) {
+			selected[i] = false
+			continue
+		}
+		export.Nodes = append(export.Nodes, node)
+	}
+	for source := range selected {
+		if !selected[source] {
+			continue
+		}
+		for _, target := range graph.Links(source) {
+			if selected[target] {
+				export.Edges = append(export.Edges, [2]uint64{graph.IDs[source], graph.IDs[target]})
+			}
+		}
+	}
+	return export
+}
+
+// Reachable returns the page numbers reachable from the root page IDs by
+// following at most depth links, breadth first
+func (graph *Graph) Reachable(roots []uint64, depth int) []int {
+	seen := make(map[int]bool)
+	frontier := make([]int, 0, len(roots))
+	for _, id := range roots {
+		if i, ok := graph.Index(id); ok && !seen[i] {
+			seen[i] = true
+			frontier = append(frontier, i)
+		}
+	}
+	result := append([]int(nil), frontier...)
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		next := make([]int, 0)
+		for _, i := range frontier {
+			for _, target := range graph.Links(i) {
+				if !seen[int(target)] {
+					seen[int(target)] = true
+					next = append(next, int(target))
+				}
+			}
+		}
+		result = append(result, next...)
+		frontier = next
+	}
+	return result
+}
+
+func onHosts(pageURL string, hosts []string) bool {
+	if len(hosts) == 0 {
+		return true
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range hosts {
+		h = strings.ToLower(h)
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatGraphML:
+		return "application/graphml+xml"
+	case FormatDOT:
+		return "text/vnd.graphviz"
+	case FormatCSV, FormatCSVNodes:
+		return "text/csv"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	}
+	return "text/plain"
+}
+
+// Write writes the graph in one of the Formats
+func (export *Export) Write(w io.Writer, format string) error {
+	buffered := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case FormatGraphML:
+		err = export.writeGraphML(buffered)
+	case FormatDOT:
+		err = export.writeDOT(buffered)
+	case FormatCSV:
+		err = export.writeCSVEdges(buffered)
+	case FormatCSVNodes:
+		err = export.writeCSVNodes(buffered)
+	case FormatJSONL:
+		err = export.writeJSONL(buffered)
+	default:
+		return fmt.Errorf("unknown graph format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func (export *Export) writeGraphML(w *bufio.Writer) error {
+	w.WriteString(xml.Header)
+	w.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	for _, key := range []struct{ id, kind string }{{"url", "string"}, {"title", "string"}, {"pagerank", "double"}, {"in_degree", "int"}, {"out_degree", "int"}} {
+		fmt.Fprintf(w, `  <key id="%s" for="node" attr.name="%s" attr.type="%s"/>`+"\n", key.id, key.id, key.kind)
+	}
+	w.WriteString(`  <graph id="crawl" edgedefault="directed">` + "\n")
+	for _, node := range export.Nodes {
+		fmt.Fprintf(w, `    <node id="n%d">`+"\n", node.ID)
+		fmt.Fprintf(w, `      <data key="url">%s</data>`+"\n", xmlEscape(node.URL))
+		fmt.Fprintf(w, `      <data key="title">%s</data>`+"\n", xmlEscape(node.Title))
+		fmt.Fprintf(w, `      <data key="pagerank">%g</data>`+"\n", node.PageRank)
+		fmt.Fprintf(w, `      <data key="in_degree">%d</data>`+"\n", node.InDegree)
+		fmt.Fprintf(w, `      <data key="out_degree">%d</data>`+"\n", node.OutDegree)
+		w.WriteString("    </node>\n")
+	}
+	for _, edge := range export.Edges {
+		fmt.Fprintf(w, `    <edge source="n%d" target="n%d"/>`+"\n", edge[0], edge[1])
+	}
+	_, err := w.WriteString("  </graph>\n</graphml>\n")
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func (export *Export) writeDOT(w *bufio.Writer) error {
+	w.WriteString("digraph crawl {\n")
+	for _, node := range export.Nodes {
+		label := node.Title
+		if label == "" {
+			label = node.URL
+		}
+		fmt.Fprintf(w, "  %d [label=%s, URL=%s, pagerank=%g, in_degree=%d, out_degree=%d];\n",
+			node.ID, dotQuote(label), dotQuote(node.URL), node.PageRank, node.InDegree, node.OutDegree)
+	}
+	for _, edge := range export.Edges {
+		fmt.Fprintf(w, "  %d -> %d;\n", edge[0], edge[1])
+	}
+	_, err := w.WriteString("}\n")
+	return err
+}
+
+// Gephi edge list
+func (export *Export) writeCSVEdges(w *bufio.Writer) error {
+	c := csv.NewWriter(w)
+	c.Write([]string{"Source", "Target", "Type"})
+	for _, edge := range export.Edges {
+		c.Write([]string{strconv.FormatUint(edge[0], 10), strconv.FormatUint(edge[1], 10), "Directed"})
+	}
+	c.Flush()
+	return c.Error()
+}
+
+// Gephi node table, to import with the edge list
+func (export *Export) writeCSVNodes(w *bufio.Writer) error {
+	c := csv.NewWriter(w)
+	c.Write([]string{"Id", "Label", "URL", "PageRank", "InDegree", "OutDegree"})
+	for _, node := range export.Nodes {
+		c.Write([]string{
+			strconv.FormatUint(node.ID, 10), node.Title, node.URL,
+			strconv.FormatFloat(node.PageRank, 'g', -1, 64), strconv.Itoa(node.InDegree), strconv.Itoa(node.OutDegree),
+		})
+	}
+	c.Flush()
+	return c.Error()
+}
+
+type jsonlRecord struct {
+	Type string `json:"type"`
+	*Node
+	Source *uint64 `json:"source,omitempty"`
+	Target *uint64 `json:"target,omitempty"`
+}
+
+// One node or edge per line, nodes first
+func (export *Export) writeJSONL(w *bufio.Writer) error {
+	encoder := json.NewEncoder(w)
+	for i := range export.Nodes {
+		if err := encoder.Encode(jsonlRecord{Type: "node", Node: &export.Nodes[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range export.Edges {
+		if err := encoder.Encode(jsonlRecord{Type: "edge", Source: &export.Edges[i][0], Target: &export.Edges[i][1]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PageID finds a page given by its ID or its URL
+func PageID(documentIndexer *Indexer.MappingIndexer, page string) (uint64, error) {
+	if id, err := strconv.ParseUint(page, 10, 64); err == nil {
+		return id, nil
+	}
+	id, err := documentIndexer.GetValueFromKey(page)
+	if err != nil {
+		return 0, fmt.Errorf("unknown page %s", page)
+	}
+	return id, nil
+}
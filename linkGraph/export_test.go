@@ -0,0 +1,59 @@
+package linkGraph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testExport(t *testing.T, filter Filter) *Export {
+	links := map[uint64][]uint64{1: {2, 3}, 2: {4}, 3: {1}, 4: {}}
+	urls := map[uint64]string{1: "https://www.cse.ust.hk/", 2: "https://www.cse.ust.hk/a", 3: "https://www.ust.hk/", 4: "https://www.cse.ust.hk/b"}
+	graph, err := NewGraph([]uint64{1, 2, 3, 4}, func(id uint64) ([]uint64, error) {
+		return links[id], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Build(graph, func(id uint64) Node {
+		return Node{ID: id, URL: urls[id], Title: "Page & \"title\"", PageRank: float64(id)}
+	}, filter)
+}
+
+func TestBuildFilters(t *testing.T) {
+	export := testExport(t, Filter{Hosts: []string{"cse.ust.hk"}})
+	if len(export.Nodes) != 3 || len(export.Edges) != 2 {
+		t.Errorf("host filter gave %v", export)
+	}
+	export = testExport(t, Filter{MinPageRank: 2})
+	if len(export.Nodes) != 3 || len(export.Edges) != 1 {
+		t.Errorf("pagerank filter gave %v", export)
+	}
+	export = testExport(t, Filter{Roots: []uint64{1}, Depth: 1})
+	if len(export.Nodes) != 3 || export.Nodes[0].OutDegree != 2 || export.Nodes[0].InDegree != 1 {
+		t.Errorf("root filter gave %v", export)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	export := testExport(t, Filter{})
+	expected := map[string]string{
+		FormatGraphML:  `<data key="title">Page &amp; &#34;title&#34;</data>`,
+		FormatDOT:      `1 -> 2;`,
+		FormatCSV:      "1,2,Directed",
+		FormatCSVNodes: `1,"Page & ""title""",https://www.cse.ust.hk/,1,1,2`,
+		FormatJSONL:    `{"type":"edge","source":1,"target":2}`,
+	}
+	for format, contains := range expected {
+		var buffer bytes.Buffer
+		if err := export.Write(&buffer, format); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buffer.String(), contains) {
+			t.Errorf("%s output does not contain %s:\n%s", format, contains, buffer.String())
+		}
+	}
+	if err := export.Write(&bytes.Buffer{}, "svg"); err == nil {
+		t.Error("no error for an unknown format")
+	}
+}
@@ -0,0 +1,124 @@
+package linkGraph
+
+import (
+	"fmt"
+	"sort"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// Graph is the link graph in compressed sparse row form. Pages are numbered
+// 0..Len()-1 in the order of IDs, and the links of page i are the page numbers
+// Targets[Offsets[i]:Offsets[i+1]]. A million links take about 4MB.
+type Graph struct {
+	IDs     []uint64
+	Offsets []int32
+	Targets []int32
+	index   map[uint64]int32
+}
+
+// NewGraph builds a graph of the given pages. links returns the IDs a page
+// links to; links to pages outside the graph and repeated links are dropped.
+func NewGraph(ids []uint64, links func(id uint64) ([]uint64, error)) (*Graph, error) {
+	graph := &Graph{
+		IDs:     append([]uint64(nil), ids...),
+		Offsets: make([]int32, 0, len(ids)+1),
+		index:   make(map[uint64]int32, len(ids)),
+	}
+	sort.Slice(graph.IDs, func(i, j int) bool { return graph.IDs[i] < graph.IDs[j] })
+	for i, id := range graph.IDs {
+		graph.index[id] = int32(i)
+	}
+
+	graph.Offsets = append(graph.Offsets, 0)
+	for _, id := range graph.IDs {
+		children, err := links(id)
+		if err != nil {
+			return nil, fmt.Errorf("Error in building link graph: %s", err)
+		}
+		start := len(graph.Targets)
+		for _, child := range children {
+			target, ok := graph.index[child]
+			if !ok {
+				continue
+			}
+			graph.Targets = append(graph.Targets, target)
+		}
+		// Drop repeated links
+		added := graph.Targets[start:]
+		sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+		unique := start
+		for i := start; i < len(graph.Targets); i++ {
+			if i == start || graph.Targets[i] != graph.Targets[i-1] {
+				graph.Targets[unique] = graph.Targets[i]
+				unique++
+			}
+		}
+		graph.Targets = graph.Targets[:unique]
+		graph.Offsets = append(graph.Offsets, int32(len(graph.Targets)))
+	}
+	return graph, nil
+}
+
+// Load builds the graph of every indexed page from the parent -> child forward index
+func Load(documentIndexer *Indexer.MappingIndexer, parentChild *Indexer.ForwardIndexer) (*Graph, error) {
+	ids, err := documentIndexer.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error in building link graph: %s", err)
+	}
+	return NewGraph(ids, func(id uint64) ([]uint64, error) {
+		children, _ := parentChild.GetIdListFromKey(id)
+		return children, nil
+	})
+}
+
+// Len returns the number of pages
+func (graph *Graph) Len() int {
+	return len(graph.IDs)
+}
+
+// Edges returns the number of links
+func (graph *Graph) Edges() int {
+	return len(graph.Targets)
+}
+
+// Index returns the page number of a page ID
+func (graph *Graph) Index(id uint64) (int, bool) {
+	i, ok := graph.index[id]
+	return int(i), ok
+}
+
+// Links returns the page numbers page i links to
+func (graph *Graph) Links(i int) []int32 {
+	return graph.Targets[graph.Offsets[i]:graph.Offsets[i+1]]
+}
+
+// OutDegree returns the number of links of page i
+func (graph *Graph) OutDegree(i int) int {
+	return int(graph.Offsets[i+1] - graph.Offsets[i])
+}
+
+// Reverse returns the graph with every link turned around, i.e. the links
+// into each page
+func (graph *Graph) Reverse() *Graph {
+	reversed := &Graph{
+		IDs:     graph.IDs,
+		Offsets: make([]int32, graph.Len()+1),
+		Targets: make([]int32, len(graph.Targets)),
+		index:   graph.index,
+	}
+	for _, target := range graph.Targets {
+		reversed.Offsets[target+1]++
+	}
+	for i := 0; i < graph.Len(); i++ {
+		reversed.Offsets[i+1] += reversed.Offsets[i]
+	}
+	next := append([]int32(nil), reversed.Offsets[:graph.Len()]...)
+	for source := 0; source < graph.Len(); source++ {
+		for _, target := range graph.Links(source) {
+			reversed.Targets[next[target]] = int32(source)
+			next[target]++
+		}
+	}
+	return reversed
+}
@@ -0,0 +1,34 @@
+package linkGraph
+
+import (
+	"testing"
+)
+
+func TestNewGraph(t *testing.T) {
+	links := map[uint64][]uint64{10: {20, 20, 30, 99}, 20: {10}, 30: {}}
+	graph, err := NewGraph([]uint64{30, 10, 20}, func(id uint64) ([]uint64, error) {
+		return links[id], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The repeated link and the one to an unknown page are dropped
+	if graph.Len() != 3 || graph.Edges() != 3 {
+		t.Fatalf("got %d pages and %d links", graph.Len(), graph.Edges())
+	}
+	i, _ := graph.Index(10)
+	if graph.OutDegree(i) != 2 {
+		t.Errorf("out degree %d", graph.OutDegree(i))
+	}
+
+	reversed := graph.Reverse()
+	j, _ := reversed.Index(20)
+	in := reversed.Links(j)
+	if len(in) != 1 || reversed.IDs[in[0]] != 10 {
+		t.Errorf("links into 20 %v", in)
+	}
+	k, _ := reversed.Index(30)
+	if reversed.OutDegree(k) != 1 {
+		t.Errorf("links into 30 %v", reversed.Links(k))
+	}
+}
@@ -2,15 +2,15 @@ package pageRank
 
 import (
 	"fmt"
-	"math"
 
 	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+	"github.com/davi1972/comp4321-search-engine/linkGraph"
 )
 
 type PageRank struct {
-	parents                           map[uint64][]uint64
-	pageRanks                         map[uint64]float64
-	numOutlinks                       map[uint64]int
+	// Damping, tolerance and iteration limit, DefaultOptions after Initialize
+	Options                           Options
+	graph                             *linkGraph.Graph
 	documentIndexer                   *Indexer.MappingIndexer
 	reverseDocumentIndexer            *Indexer.ReverseMappingIndexer
 	parentChildDocumentForwardIndexer *Indexer.ForwardIndexer
@@ -18,12 +18,8 @@ type PageRank struct {
 	pageRankIndexer                   *Indexer.PageRankIndexer
 }
 
-var i = 1
-
 func (pageRank *PageRank) Initialize(mapping *Indexer.MappingIndexer, reverseMapping *Indexer.ReverseMappingIndexer, childParent *Indexer.ForwardIndexer, parentChild *Indexer.ForwardIndexer, page *Indexer.PageRankIndexer) {
-	pageRank.parents = make(map[uint64][]uint64)
-	pageRank.pageRanks = make(map[uint64]float64)
-	pageRank.numOutlinks = make(map[uint64]int)
+	pageRank.Options = DefaultOptions
 	pageRank.documentIndexer = mapping
 	pageRank.reverseDocumentIndexer = reverseMapping
 	pageRank.parentChildDocumentForwardIndexer = parentChild
@@ -33,104 +29,119 @@ func (pageRank *PageRank) Initialize(mapping *Indexer.MappingIndexer, reverseMap
 
 func (pageRank *PageRank) ProcessPageRank() {
 
-	pageIds, err := pageRank.documentIndexer.All()
-
-	pageRank.documentIndexer.Iterate()
-
+	graph, err := pageRank.loadGraph()
 	if err != nil {
 		fmt.Println(err)
+		return
 	}
-	fmt.Println("Page IDs")
-	fmt.Println(pageIds)
 
-	for _, id := range pageIds {
+	result := Solve(graph, nil, pageRank.Options)
+	printResult("PageRank", result)
 
-		_, err := pageRank.reverseDocumentIndexer.GetValueFromKey(id)
+	for k, v := range result.Ranks {
+
+		err := pageRank.pageRankIndexer.AddKeyToIndexOrUpdate(k, v)
 
 		if err != nil {
-			fmt.Println(err)
-			continue
+			fmt.Printf("Error inserting pagerank value: %s", err)
 		}
 
-		pageRank.pageRanks[id] = 1
+	}
+
+}
 
-		inlinks, err := pageRank.childParentDocumentForwardIndexer.GetIdListFromKey(id)
+// ProcessTopicPageRank computes a PageRank vector for every topic whose random
+// surfer only jumps to the topic's seed pages, and stores it for the topic.
+// The ranks of topics no longer given are deleted.
+func (pageRank *PageRank) ProcessTopicPageRank(topics []Topic, search func(query string) []uint64, topicPageRankIndexer *Indexer.TopicPageRankIndexer) {
 
-		if len(inlinks) == 0 {
+	given := make(map[string]bool)
+	for _, topic := range topics {
+		given[topic.Name] = true
+	}
+	stored, err := topicPageRankIndexer.Topics()
+	if err != nil {
+		fmt.Println(err)
+	}
+	for _, name := range stored {
+		if given[name] {
 			continue
 		}
-
-		if err != nil {
-			fmt.Printf("Error getting parent from id: %s", err)
+		fmt.Printf("Topic %s was removed, deleting its PageRank\n", name)
+		if err := topicPageRankIndexer.DeleteTopic(name); err != nil {
+			fmt.Println(err)
 		}
+	}
 
-		pageRank.parents[id] = inlinks
+	if len(topics) == 0 {
+		return
+	}
 
-		children, _ := pageRank.parentChildDocumentForwardIndexer.GetIdListFromKey(id)
+	graph, err := pageRank.loadGraph()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
-		if len(children) == 0 {
-			continue
-		} else {
-			pageRank.numOutlinks[id] = len(children)
+	urls := make(map[uint64]string)
+	for _, id := range graph.IDs {
+		if url, err := pageRank.reverseDocumentIndexer.GetValueFromKey(id); err == nil {
+			urls[id] = url
 		}
-
 	}
 
-	pageRank.calculatePageRank(0.85, 0.0001)
-
-	for k, v := range pageRank.pageRanks {
-
-		err := pageRank.pageRankIndexer.AddKeyToIndex(k, v)
+	for _, topic := range topics {
+		seeds := topic.Seeds(urls, search)
+		if len(seeds) == 0 {
+			fmt.Printf("Topic %s matches no pages, skipping\n", topic.Name)
+			if err := topicPageRankIndexer.DeleteTopic(topic.Name); err != nil {
+				fmt.Println(err)
+			}
+			continue
+		}
+		fmt.Printf("Computing PageRank of topic %s from %d pages\n", topic.Name, len(seeds))
 
-		if err != nil {
-			fmt.Printf("Error inserting pagerank value: %s", err)
+		teleport := make(map[uint64]float64)
+		for _, id := range seeds {
+			teleport[id] = 1
 		}
+		result := Solve(graph, teleport, pageRank.Options)
+		printResult("PageRank of topic "+topic.Name, result)
 
+		if err := topicPageRankIndexer.DeleteTopic(topic.Name); err != nil {
+			fmt.Println(err)
+		}
+		for k, v := range result.Ranks {
+			if err := topicPageRankIndexer.AddKeyToIndex(topic.Name, k, v); err != nil {
+				fmt.Printf("Error inserting topic pagerank value: %s", err)
+			}
+		}
 	}
-
 }
 
-func (pageRank *PageRank) calculatePageRank(damping float64, threshold float64) {
-	fmt.Println("Iteration:", i)
-	fmt.Println(pageRank.pageRanks)
-	oldRanks := make(map[uint64]float64)
+// Graph returns the link graph PageRank was computed over
+func (pageRank *PageRank) Graph() (*linkGraph.Graph, error) {
+	return pageRank.loadGraph()
+}
 
-	for key, value := range pageRank.pageRanks {
-		oldRanks[key] = value
+// loadGraph reads the links between the pages from the forward indexes once
+func (pageRank *PageRank) loadGraph() (*linkGraph.Graph, error) {
+	if pageRank.graph != nil {
+		return pageRank.graph, nil
 	}
-
-	stop := true
-
-	for key, value := range oldRanks {
-
-		myParents := pageRank.parents[key]
-
-		var sum = 0.0
-
-		if len(myParents) != 0 {
-
-			for _, id := range myParents {
-				parentsChild := float64(pageRank.numOutlinks[id])
-				sum += oldRanks[id] / parentsChild
-			}
-
-		}
-
-		pr := (1 - damping) + damping*sum
-
-		pageRank.pageRanks[key] = pr
-
-		if math.Abs(pr-value) > threshold {
-			stop = false
-		}
+	graph, err := linkGraph.Load(pageRank.documentIndexer, pageRank.parentChildDocumentForwardIndexer)
+	if err != nil {
+		return nil, err
 	}
+	fmt.Printf("Link graph has %d pages and %d links\n", graph.Len(), graph.Edges())
+	pageRank.graph = graph
+	return graph, nil
+}
 
-	i++
-
-	if !stop {
-		pageRank.calculatePageRank(damping, threshold)
+func printResult(name string, result Result) {
+	if result.Converged {
+		fmt.Printf("%s converged after %d iterations (change %g)\n", name, result.Iterations, result.Delta)
 	} else {
-		return
+		fmt.Printf("%s did not converge after %d iterations (change %g)\n", name, result.Iterations, result.Delta)
 	}
-
 }
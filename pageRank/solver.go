@@ -0,0 +1,115 @@
+package pageRank
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/davi1972/comp4321-search-engine/linkGraph"
+)
+
+// Options of the PageRank power iteration
+type Options struct {
+	// Probability the surfer follows a link instead of jumping
+	Damping float64 `yaml:"damping"`
+	// Iteration stops once the ranks change by less than this in total
+	Tolerance float64 `yaml:"tolerance"`
+	// Iteration stops after this many rounds even if not converged
+	MaxIterations int `yaml:"max_iterations"`
+}
+
+var DefaultOptions = Options{Damping: 0.85, Tolerance: 1e-6, MaxIterations: 100}
+
+func (options Options) Validate() error {
+	if options.Damping <= 0 || options.Damping >= 1 {
+		return fmt.Errorf("PageRank damping must be between 0 and 1, not %g", options.Damping)
+	}
+	if options.Tolerance <= 0 {
+		return fmt.Errorf("PageRank tolerance must be positive, not %g", options.Tolerance)
+	}
+	if options.MaxIterations < 1 {
+		return fmt.Errorf("PageRank needs at least one iteration, not %d", options.MaxIterations)
+	}
+	return nil
+}
+
+// Result of a PageRank computation. Ranks average 1 over all pages.
+type Result struct {
+	Ranks      map[uint64]float64
+	Iterations int
+	// Total change of the ranks in the last iteration, as probabilities
+	Delta     float64
+	Converged bool
+}
+
+// Solve computes PageRank by power iteration. The surfer jumps to a page with
+// a probability proportional to its teleport weight, or to any page if teleport
+// is nil. The rank of pages without links (dangling pages) is spread the same
+// way, so no rank is lost.
+func Solve(graph *linkGraph.Graph, teleport map[uint64]float64, options Options) Result {
+	n := graph.Len()
+	result := Result{Ranks: make(map[uint64]float64, n)}
+	if n == 0 {
+		result.Converged = true
+		return result
+	}
+
+	// Jump probabilities, summing to 1
+	jump := make([]float64, n)
+	total := 0.0
+	for i, id := range graph.IDs {
+		if teleport == nil {
+			jump[i] = 1
+		} else if weight := teleport[id]; weight > 0 {
+			jump[i] = weight
+		}
+		total += jump[i]
+	}
+	if total == 0 {
+		for i := range jump {
+			jump[i] = 1
+		}
+		total = float64(n)
+	}
+	for i := range jump {
+		jump[i] /= total
+	}
+
+	ranks := make([]float64, n)
+	copy(ranks, jump)
+	next := make([]float64, n)
+	for result.Iterations < options.MaxIterations {
+		result.Iterations++
+
+		dangling := 0.0
+		for i := range next {
+			next[i] = 0
+		}
+		for source := 0; source < n; source++ {
+			degree := graph.OutDegree(source)
+			if degree == 0 {
+				dangling += ranks[source]
+				continue
+			}
+			share := ranks[source] / float64(degree)
+			for _, target := range graph.Links(source) {
+				next[target] += share
+			}
+		}
+
+		result.Delta = 0
+		for i := range next {
+			next[i] = options.Damping*(next[i]+dangling*jump[i]) + (1-options.Damping)*jump[i]
+			result.Delta += math.Abs(next[i] - ranks[i])
+		}
+		ranks, next = next, ranks
+		if result.Delta < options.Tolerance {
+			result.Converged = true
+			break
+		}
+	}
+
+	for i, id := range graph.IDs {
+		result.Ranks[id] = ranks[i] * float64(n)
+	}
+	return result
+}
@@ -0,0 +1,93 @@
+package pageRank
+
+import (
+	"math"
+	"testing"
+
+	"github.com/davi1972/comp4321-search-engine/linkGraph"
+)
+
+func testGraph(t *testing.T, links map[uint64][]uint64) *linkGraph.Graph {
+	ids := make([]uint64, 0, len(links))
+	for id := range links {
+		ids = append(ids, id)
+	}
+	graph, err := linkGraph.NewGraph(ids, func(id uint64) ([]uint64, error) {
+		return links[id], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return graph
+}
+
+func TestSolveCycle(t *testing.T) {
+	graph := testGraph(t, map[uint64][]uint64{1: {2}, 2: {3}, 3: {1}})
+	result := Solve(graph, nil, DefaultOptions)
+	if !result.Converged {
+		t.Fatal("not converged")
+	}
+	for id, rank := range result.Ranks {
+		if math.Abs(rank-1) > 1e-6 {
+			t.Errorf("rank of %d is %f", id, rank)
+		}
+	}
+}
+
+func TestSolveDangling(t *testing.T) {
+	// 3 has no links, its rank must not be lost
+	graph := testGraph(t, map[uint64][]uint64{1: {2, 3}, 2: {3}, 3: {}})
+	result := Solve(graph, nil, DefaultOptions)
+	total := 0.0
+	for _, rank := range result.Ranks {
+		total += rank
+	}
+	if math.Abs(total-3) > 1e-6 {
+		t.Errorf("ranks sum to %f", total)
+	}
+	if !(result.Ranks[3] > result.Ranks[2] && result.Ranks[2] > result.Ranks[1]) {
+		t.Errorf("unexpected order %v", result.Ranks)
+	}
+}
+
+func TestSolveTeleport(t *testing.T) {
+	graph := testGraph(t, map[uint64][]uint64{1: {2}, 2: {1}, 3: {4}, 4: {3}})
+	result := Solve(graph, map[uint64]float64{1: 1}, DefaultOptions)
+	if result.Ranks[1]+result.Ranks[2] < 3.99 || result.Ranks[3] > 1e-6 {
+		t.Errorf("rank leaked out of the topic %v", result.Ranks)
+	}
+}
+
+func TestSolveMaxIterations(t *testing.T) {
+	graph := testGraph(t, map[uint64][]uint64{1: {2}, 2: {2, 3}, 3: {1}})
+	result := Solve(graph, nil, Options{Damping: 0.85, Tolerance: 1e-12, MaxIterations: 2})
+	if result.Converged || result.Iterations != 2 {
+		t.Errorf("got %+v", result)
+	}
+}
+
+func TestSolveLargeGraph(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large graph")
+	}
+	// 100000 pages with 10 links each
+	const pages = 100000
+	ids := make([]uint64, pages)
+	for i := range ids {
+		ids[i] = uint64(i)
+	}
+	graph, _ := linkGraph.NewGraph(ids, func(id uint64) ([]uint64, error) {
+		links := make([]uint64, 10)
+		for j := range links {
+			links[j] = (id*7 + uint64(j)*13 + 1) % pages
+		}
+		return links, nil
+	})
+	if graph.Edges() < 900000 {
+		t.Fatalf("only %d links", graph.Edges())
+	}
+	result := Solve(graph, nil, DefaultOptions)
+	if !result.Converged {
+		t.Errorf("not converged after %d iterations", result.Iterations)
+	}
+}
@@ -0,0 +1,106 @@
+package pageRank
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Topic is a set of seed pages the random surfer of topic sensitive PageRank
+// jumps to, instead of jumping to any page. Pages match if they are on one of
+// the hosts (or their subdomains), start with one of the URL prefixes, or
+// contain a word of the query.
+type Topic struct {
+	Name     string   `json:"name"`
+	Hosts    []string `json:"hosts"`
+	Prefixes []string `json:"prefixes"`
+	Query    string   `json:"query"`
+}
+
+type topicsFile struct {
+	Topics []Topic `json:"topics"`
+}
+
+// LoadTopics reads the topics of a JSON file, see topics.example.json
+func LoadTopics(path string) ([]Topic, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error while loading topics: %s", err)
+	}
+	file := topicsFile{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("Error while loading topics: %s", err)
+	}
+	if err := ValidateTopics(file.Topics); err != nil {
+		return nil, fmt.Errorf("Error while loading topics: %s", err)
+	}
+	return file.Topics, nil
+}
+
+// Topic names are stored in index keys and given by ?topic=, so they are
+// limited to letters, digits, dots, dashes and underscores
+var topicName = regexp.MustCompile(`^[\pL\pN._-]+$`)
+
+// ValidateTopics checks every topic has a unique and valid name
+func ValidateTopics(topics []Topic) error {
+	seen := make(map[string]bool)
+	for _, topic := range topics {
+		if !topicName.MatchString(topic.Name) {
+			return fmt.Errorf("invalid topic name %q, use letters, digits, '.', '-' and '_'", topic.Name)
+		}
+		if seen[topic.Name] {
+			return fmt.Errorf("duplicate topic name %q", topic.Name)
+		}
+		seen[topic.Name] = true
+	}
+	return nil
+}
+
+// Seeds returns the pages of a topic. urls maps page IDs to their URL, search
+// returns the pages containing any word of a query.
+func (topic Topic) Seeds(urls map[uint64]string, search func(query string) []uint64) []uint64 {
+	seeds := make(map[uint64]bool)
+	for id, pageURL := range urls {
+		if topic.matchesURL(pageURL) {
+			seeds[id] = true
+		}
+	}
+	if topic.Query != "" && search != nil {
+		for _, id := range search(topic.Query) {
+			if _, ok := urls[id]; ok {
+				seeds[id] = true
+			}
+		}
+	}
+	result := make([]uint64, 0, len(seeds))
+	for id := range seeds {
+		result = append(result, id)
+	}
+	return result
+}
+
+func (topic Topic) matchesURL(pageURL string) bool {
+	for _, prefix := range topic.Prefixes {
+		if strings.HasPrefix(pageURL, prefix) {
+			return true
+		}
+	}
+	if len(topic.Hosts) == 0 {
+		return false
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range topic.Hosts {
+		h = strings.ToLower(h)
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package pageRank
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+func TestValidateTopics(t *testing.T) {
+	if err := ValidateTopics([]Topic{{Name: "ai"}, {Name: "big-data_2.0"}, {Name: "計算"}}); err != nil {
+		t.Errorf("valid names refused: %s", err)
+	}
+	// Names end up in index keys, where a zero byte separates the topic from the page
+	for _, name := range []string{"", "ai\x00", "ai\x01", "machine learning", "ai/ml"} {
+		if err := ValidateTopics([]Topic{{Name: name}}); err == nil {
+			t.Errorf("name %q accepted", name)
+		}
+	}
+	if err := ValidateTopics([]Topic{{Name: "ai"}, {Name: "ai"}}); err == nil {
+		t.Error("duplicate names accepted")
+	}
+}
+
+func TestProcessTopicPageRankDeletesRemovedTopics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "topics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	topicPageRankIndexer := &Indexer.TopicPageRankIndexer{}
+	if err := topicPageRankIndexer.Initialize(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer topicPageRankIndexer.Release()
+	topicPageRankIndexer.AddKeyToIndex("ai", 1, 0.5)
+	topicPageRankIndexer.AddKeyToIndex("theory", 1, 0.5)
+
+	pageRank := &PageRank{}
+	pageRank.ProcessTopicPageRank(nil, nil, topicPageRankIndexer)
+
+	if topics, err := topicPageRankIndexer.Topics(); err != nil || len(topics) != 0 {
+		t.Errorf("topics %v left after they were removed", topics)
+	}
+}
@@ -0,0 +1,146 @@
+// Package queryCache keeps the results of recent queries, so repeated
+// queries are answered without scoring them again
+package queryCache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is a least recently used cache of query results with a size limit and
+// a time to live. Results are kept for one index generation: a new generation
+// drops all of them.
+type Cache struct {
+	mutex      sync.Mutex
+	capacity   int
+	ttl        time.Duration
+	generation uint64
+	// Most recently used first
+	order   *list.List
+	entries map[string]*list.Element
+	stats   Stats
+	now     func() time.Time
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// Stats counts the lookups of a cache and why entries left it
+type Stats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	// Times a new index generation emptied the cache
+	Invalidations uint64 `json:"invalidations"`
+	Entries       int    `json:"entries"`
+	Capacity      int    `json:"capacity"`
+	Generation    uint64 `json:"generation"`
+}
+
+// New creates a cache of at most capacity results, each kept for ttl. A
+// capacity of 0 disables the cache and a ttl of 0 keeps results until evicted.
+func New(capacity int, ttl time.Duration) *Cache {
+	return &Cache{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Key identifies a query and the parameters it was ranked with. Queries
+// differing only in case or spacing share a key.
+func Key(query string, parameters ...string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	return strings.Join(append([]string{normalized}, parameters...), "\x00")
+}
+
+// Get returns the result cached for a key at a generation
+func (cache *Cache) Get(generation uint64, key string) (interface{}, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.invalidate(generation)
+	element, ok := cache.entries[key]
+	if !ok {
+		cache.stats.Misses++
+		return nil, false
+	}
+	e := element.Value.(*entry)
+	if cache.ttl > 0 && !cache.now().Before(e.expires) {
+		cache.remove(element)
+		cache.stats.Expirations++
+		cache.stats.Misses++
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	cache.stats.Hits++
+	return e.value, true
+}
+
+// Add caches the result of a key computed at a generation, evicting the least
+// recently used results over the capacity. Results of an older generation
+// than the cache's are not kept.
+func (cache *Cache) Add(generation uint64, key string, value interface{}) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.capacity <= 0 || generation < cache.generation {
+		return
+	}
+	cache.invalidate(generation)
+	expires := cache.now().Add(cache.ttl)
+	if element, ok := cache.entries[key]; ok {
+		e := element.Value.(*entry)
+		e.value, e.expires = value, expires
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[key] = cache.order.PushFront(&entry{key, value, expires})
+	for cache.order.Len() > cache.capacity {
+		cache.remove(cache.order.Back())
+		cache.stats.Evictions++
+	}
+}
+
+// Purge drops every cached result, e.g. when the synonyms change
+func (cache *Cache) Purge() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.order.Init()
+	cache.entries = make(map[string]*list.Element)
+}
+
+// Stats returns the counters of the cache
+func (cache *Cache) Stats() Stats {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	stats := cache.stats
+	stats.Entries = cache.order.Len()
+	stats.Capacity = cache.capacity
+	stats.Generation = cache.generation
+	return stats
+}
+
+// invalidate drops the results of older generations when a newer one is seen
+func (cache *Cache) invalidate(generation uint64) {
+	if generation <= cache.generation {
+		return
+	}
+	if cache.order.Len() > 0 {
+		cache.order.Init()
+		cache.entries = make(map[string]*list.Element)
+		cache.stats.Invalidations++
+	}
+	cache.generation = generation
+}
+
+func (cache *Cache) remove(element *list.Element) {
+	cache.order.Remove(element)
+	delete(cache.entries, element.Value.(*entry).key)
+}
@@ -0,0 +1,87 @@
+package queryCache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	if Key("  Machine   LEARNING ", "en") != Key("machine learning", "en") {
+		t.Error("case and spacing change the key")
+	}
+	if Key("machine learning", "en") == Key("machine learning", "zh") {
+		t.Error("parameters do not change the key")
+	}
+	if Key("a", "b") == Key("a b") {
+		t.Error("parameters are confused with the query")
+	}
+}
+
+func TestEviction(t *testing.T) {
+	cache := New(2, 0)
+	cache.Add(0, "a", 1)
+	cache.Add(0, "b", 2)
+	// a is now more recently used than b
+	if value, ok := cache.Get(0, "a"); !ok || value != 1 {
+		t.Fatalf("got %v, %t", value, ok)
+	}
+	cache.Add(0, "c", 3)
+	if _, ok := cache.Get(0, "b"); ok {
+		t.Error("least recently used result is kept")
+	}
+	if _, ok := cache.Get(0, "a"); !ok {
+		t.Error("recently used result is evicted")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Evictions != 1 || stats.Entries != 2 || stats.Capacity != 2 {
+		t.Errorf("got %+v", stats)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := New(10, time.Minute)
+	cache.now = func() time.Time { return now }
+	cache.Add(0, "a", 1)
+
+	now = now.Add(59 * time.Second)
+	if _, ok := cache.Get(0, "a"); !ok {
+		t.Error("result expired early")
+	}
+	now = now.Add(time.Second)
+	if _, ok := cache.Get(0, "a"); ok {
+		t.Error("expired result is returned")
+	}
+	if stats := cache.Stats(); stats.Expirations != 1 || stats.Entries != 0 {
+		t.Errorf("got %+v", stats)
+	}
+}
+
+func TestGeneration(t *testing.T) {
+	cache := New(10, 0)
+	cache.Add(1, "a", 1)
+	if _, ok := cache.Get(2, "a"); ok {
+		t.Error("result of an older generation is returned")
+	}
+	// A query started before the new generation does not cache its stale result
+	cache.Add(1, "a", 1)
+	if _, ok := cache.Get(2, "a"); ok {
+		t.Error("stale result is cached")
+	}
+	cache.Add(2, "a", 2)
+	if value, ok := cache.Get(2, "a"); !ok || value != 2 {
+		t.Errorf("got %v, %t", value, ok)
+	}
+	if stats := cache.Stats(); stats.Invalidations != 1 || stats.Generation != 2 {
+		t.Errorf("got %+v", stats)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	cache := New(0, 0)
+	cache.Add(0, "a", 1)
+	if _, ok := cache.Get(0, "a"); ok {
+		t.Error("disabled cache returns a result")
+	}
+}
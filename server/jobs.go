@@ -0,0 +1,163 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	Crawler "github.com/davi1972/comp4321-search-engine/crawler"
+)
+
+// The databases are locked by the process that opened them, so while the
+// server runs the index can only be changed by the jobs it runs itself. One
+// job runs at a time, in the background, and queries keep being answered.
+
+// Job is a crawl, ranking or repair run by the server
+type Job struct {
+	Name     string    `json:"name"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// JobsResponse is the job running, if any, and the last one to finish
+type JobsResponse struct {
+	Running *Job `json:"running"`
+	Last    *Job `json:"last"`
+}
+
+type jobs struct {
+	mutex   sync.Mutex
+	running *Job
+	last    *Job
+}
+
+// start runs a job in the background, then done once it is recorded as
+// finished. If another job is running it returns that one and false.
+func (j *jobs) start(name string, run func() error, done func()) (Job, bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.running != nil {
+		return *j.running, false
+	}
+	job := &Job{Name: name, Started: time.Now()}
+	j.running = job
+	go func() {
+		err := run()
+		j.mutex.Lock()
+		job.Finished = time.Now()
+		if err != nil {
+			log.Printf("Job %s failed: %s", name, err)
+			job.Error = err.Error()
+		}
+		j.running = nil
+		j.last = job
+		j.mutex.Unlock()
+		done()
+	}()
+	return *job, true
+}
+
+func (j *jobs) status() *JobsResponse {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	resp := &JobsResponse{}
+	if j.running != nil {
+		running := *j.running
+		resp.Running = &running
+	}
+	if j.last != nil {
+		last := *j.last
+		resp.Last = &last
+	}
+	return resp
+}
+
+// startJob answers an admin request starting a job with 202, or 409 if
+// another job is running
+func (s *Server) startJob(w http.ResponseWriter, name string, run func() error) {
+	job, ok := s.jobs.start(name, run, s.indexChanged)
+	if !ok {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(fmt.Sprintf("409 - Job already running! Details: %s started at %s", job.Name, job.Started.Format(time.RFC3339))))
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	writeJSON(w, &job)
+}
+
+// jobAllowed reports whether a request may start a job: it must bear the
+// admin token, or come from the loopback interface if there is no token
+func (s *Server) jobAllowed(r *http.Request) bool {
+	if token := s.settings.Server.AdminToken; token != "" {
+		bearer := []byte("Bearer " + token)
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), bearer) == 1
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// jobHandler refuses with 403 the requests not allowed to start jobs
+func (s *Server) jobHandler(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.jobAllowed(r) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("403 - Forbidden! Details: jobs are started with the admin token, or from the server's machine without one"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// crawlJobHandler crawls the configured root and scope into the index
+func (s *Server) crawlJobHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := s.settings.CrawlOptions()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 - Internal Server Error! Details: " + err.Error()))
+		return
+	}
+	s.startJob(w, "crawl", func() error {
+		_, err := Crawler.Crawl(s.indexes, options)
+		return err
+	})
+}
+
+// rankJobHandler computes the link scores again, with the topics of the topics file
+func (s *Server) rankJobHandler(w http.ResponseWriter, r *http.Request) {
+	topics, err := s.settings.LoadTopics()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 - Internal Server Error! Details: " + err.Error()))
+		return
+	}
+	s.startJob(w, "rank", func() error {
+		Crawler.Rank(s.indexes, s.settings.Ranking.PageRank, topics)
+		return nil
+	})
+}
+
+// repairJobHandler repairs the problems found by verifying the indexes
+func (s *Server) repairJobHandler(w http.ResponseWriter, r *http.Request) {
+	s.startJob(w, "repair", func() error {
+		problems, err := s.indexes.Repair()
+		if err == nil {
+			log.Printf("Repaired %d problems", len(problems))
+		}
+		return err
+	})
+}
+
+func (s *Server) jobsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.jobs.status())
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/davi1972/comp4321-search-engine/config"
+)
+
+func TestJobs(t *testing.T) {
+	j := &jobs{}
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	if _, ok := j.start("crawl", func() error { <-release; return errors.New("unreachable root") }, func() { close(finished) }); !ok {
+		t.Fatal("first job refused")
+	}
+	// Only one job changes the index at a time
+	if running, ok := j.start("rank", func() error { return nil }, func() {}); ok || running.Name != "crawl" {
+		t.Errorf("second job started while %s runs", running.Name)
+	}
+	if status := j.status(); status.Running == nil || status.Running.Name != "crawl" || status.Last != nil {
+		t.Errorf("status %+v while crawling", status)
+	}
+
+	close(release)
+	<-finished
+	status := j.status()
+	if status.Last == nil || status.Last.Error != "unreachable root" || status.Last.Finished.IsZero() {
+		t.Errorf("finished job recorded as %+v", status.Last)
+	}
+	if _, ok := j.start("rank", func() error { return nil }, func() {}); !ok {
+		t.Error("job refused after the previous one finished")
+	}
+}
+
+func TestJobHandler(t *testing.T) {
+	s := &Server{settings: config.Default()}
+	started := false
+	handler := s.jobHandler(func(w http.ResponseWriter, r *http.Request) { started = true })
+	request := func(remote, authorization string) int {
+		started = false
+		r := httptest.NewRequest("POST", "/admin/crawl", nil)
+		r.RemoteAddr = remote
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if (w.Code == http.StatusOK) != started {
+			t.Errorf("job started %t with status %d", started, w.Code)
+		}
+		return w.Code
+	}
+
+	// Without a token only the machine of the server starts jobs
+	if code := request("127.0.0.1:5000", ""); code != http.StatusOK {
+		t.Errorf("local request got %d", code)
+	}
+	if code := request("[::1]:5000", ""); code != http.StatusOK {
+		t.Errorf("local IPv6 request got %d", code)
+	}
+	if code := request("192.0.2.1:5000", ""); code != http.StatusForbidden {
+		t.Errorf("remote request got %d", code)
+	}
+
+	// With a token every request must bear it, local ones too
+	s.settings.Server.AdminToken = "secret"
+	if code := request("192.0.2.1:5000", "Bearer secret"); code != http.StatusOK {
+		t.Errorf("request with the token got %d", code)
+	}
+	if code := request("127.0.0.1:5000", ""); code != http.StatusForbidden {
+		t.Errorf("local request without the token got %d", code)
+	}
+	if code := request("192.0.2.1:5000", "Bearer guess"); code != http.StatusForbidden {
+		t.Errorf("request with a wrong token got %d", code)
+	}
+}
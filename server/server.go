@@ -0,0 +1,1241 @@
+// Package server serves the search engine API and the front end data
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/davi1972/comp4321-search-engine/boolsearch"
+	"github.com/davi1972/comp4321-search-engine/config"
+	"github.com/davi1972/comp4321-search-engine/hits"
+	"github.com/davi1972/comp4321-search-engine/linkGraph"
+	"github.com/davi1972/comp4321-search-engine/phrasalSearch"
+	"github.com/davi1972/comp4321-search-engine/queryCache"
+	"github.com/davi1972/comp4321-search-engine/synonyms"
+	"github.com/davi1972/comp4321-search-engine/tokenizer"
+	"github.com/davi1972/comp4321-search-engine/vsm"
+	"github.com/dgraph-io/badger"
+
+	"github.com/gorilla/mux"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+type Server struct {
+	indexes                           *Indexer.Indexes
+	documentIndexer                   *Indexer.MappingIndexer
+	wordIndexer                       *Indexer.MappingIndexer
+	reverseDocumentIndexer            *Indexer.ReverseMappingIndexer
+	reverseWordIndexer                *Indexer.ReverseMappingIndexer
+	pagePropertiesIndexer             *Indexer.PagePropetiesIndexer
+	titleInvertedIndexer              *Indexer.InvertedFileIndexer
+	contentInvertedIndexer            *Indexer.InvertedFileIndexer
+	headingInvertedIndexer            *Indexer.InvertedFileIndexer
+	descriptionInvertedIndexer        *Indexer.InvertedFileIndexer
+	keywordsInvertedIndexer           *Indexer.InvertedFileIndexer
+	altInvertedIndexer                *Indexer.InvertedFileIndexer
+	urlInvertedIndexer                *Indexer.InvertedFileIndexer
+	documentWordForwardIndexer        *Indexer.DocumentWordForwardIndexer
+	titleWordForwardIndexer           *Indexer.DocumentWordForwardIndexer
+	parentChildDocumentForwardIndexer *Indexer.ForwardIndexer
+	childParentDocumentForwardIndexer *Indexer.ForwardIndexer
+	pageRankIndexer                   *Indexer.PageRankIndexer
+	topicPageRankIndexer              *Indexer.TopicPageRankIndexer
+	hubIndexer                        *Indexer.PageRankIndexer
+	authorityIndexer                  *Indexer.PageRankIndexer
+	topicsMutex                       sync.RWMutex
+	topics                            map[string]bool
+	crawlReportIndexer                *Indexer.CrawlReportIndexer
+	metadataIndexer                   *Indexer.MetadataIndexer
+	router                            *mux.Router
+	vsm                               *vsm.VSM
+	bs                                *boolsearch.BoolSearch
+	pls                               *phrasalSearch.PhrasalSearch
+	synonyms                          *synonyms.Store
+	// Results of recent queries, nil when not caching
+	cache *queryCache.Cache
+	// Weights and boosts of the ranking
+	ranking config.Ranking
+	// Links followed from a page by the crawl, the depth of the graphs served
+	maxDepth int
+	// Link graph shared by the requests that need all of it, and the
+	// generation of the index it was loaded at
+	graphMutex      sync.Mutex
+	graph           *linkGraph.Graph
+	graphGeneration uint64
+	// Statistics of the index served by /admin/stats, and the generation
+	// they were computed at
+	statsMutex      sync.Mutex
+	stats           *Indexer.Stats
+	statsGeneration uint64
+	// Settings of the crawls and rankings run by the server
+	settings *config.Config
+	jobs     jobs
+	// Set when the index was built with analyzers that have changed since,
+	// queries would no longer find the terms of those fields
+	analyzersErr error
+}
+
+type Edge struct {
+	From int `json:"source"`
+	To   int `json:"target"`
+}
+
+type EdgeString struct {
+	From string `json:"source"`
+	To   string `json:"target"`
+}
+
+type Node struct {
+	Name string `json:"id"`
+}
+
+type GraphResponse struct {
+	Nodes       []Node `json:"nodes"`
+	Edges       []Edge `json:"links"`
+	EdgesString []EdgeString
+}
+
+type WordListResponse struct {
+	WordList []string `json:"words"`
+}
+
+type WordFrequencyString struct {
+	Word      string `json:"word"`
+	Frequency uint64 `json:"frequency"`
+}
+
+type QueryResponse struct {
+	PageID           uint64                `json:"pageID"`
+	VSMScore         float64               `json:"vsmscore"`
+	PageRankScore    float64               `json:"pagerankscore"`
+	Score            float64               `json:"score"`
+	Title            string                `json:"title"`
+	URL              string                `json:"url"`
+	Snippet          string                `json:"snippet"`
+	LastModifiedDate time.Time             `json:"last_modified"`
+	KeyWord          []WordFrequencyString `json:"keywords"`
+	ParentList       []string              `json:"parent_urls"`
+	ChildList        []string              `json:"child_urls"`
+}
+
+type QueryResponses []QueryResponse
+
+func (s QueryResponses) Len() int {
+	return len(s)
+}
+
+func (s QueryResponses) Less(i, j int) bool {
+	return s[i].Score > s[j].Score
+}
+
+func (s QueryResponses) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// CrawlSummary is a crawl report without its list of failures
+type CrawlSummary struct {
+	ID        uint64    `json:"id"`
+	Root      string    `json:"root"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Finished  bool      `json:"finished"`
+	Fetched   uint64    `json:"fetched"`
+	Indexed   uint64    `json:"indexed"`
+	Skipped   uint64    `json:"skipped"`
+	Unchanged uint64    `json:"unchanged"`
+	Failed    int       `json:"failed"`
+}
+
+type CrawlListResponse struct {
+	Crawls []CrawlSummary `json:"crawls"`
+}
+
+type QueryListResponse struct {
+	Signal        string              `json:"signal"`
+	List          QueryResponses      `json:"documents"`
+	ExpandedTerms []vsm.ExpansionTerm `json:"expanded_terms,omitempty"`
+	QueryTerms    []vsm.ExpansionTerm `json:"query_terms,omitempty"`
+	Explain       *QueryExplanation   `json:"explain,omitempty"`
+}
+
+// QueryExplanation shows how a query was analysed and expanded, with ?explain=true
+type QueryExplanation struct {
+	// Empty when the query was analysed in the language of each page,
+	// its terms are then those of the default language
+	Language string           `json:"language"`
+	Queries  []ExplainedQuery `json:"queries"`
+}
+
+// ExplainedQuery is the original query or one of the synonyms added to it
+type ExplainedQuery struct {
+	Text   string   `json:"text"`
+	Source string   `json:"synonym_of,omitempty"`
+	Weight float64  `json:"weight"`
+	Terms  []string `json:"terms"`
+}
+
+// PathResponse is a shortest click path between two pages
+type PathResponse struct {
+	Clicks int              `json:"clicks"`
+	Path   []linkGraph.Node `json:"path"`
+}
+
+type OrphansResponse struct {
+	Count int              `json:"count"`
+	Pages []linkGraph.Node `json:"pages"`
+}
+
+type BrokenLinksResponse struct {
+	CrawlID uint64                 `json:"crawl_id"`
+	Links   []linkGraph.BrokenLink `json:"links"`
+}
+
+// Component is a strongly connected component, the pages that can all reach each other
+type Component struct {
+	Size  int      `json:"size"`
+	Pages []string `json:"pages"`
+}
+
+type ComponentsResponse struct {
+	Count      int         `json:"count"`
+	Largest    int         `json:"largest"`
+	Sizes      map[int]int `json:"sizes"`
+	Components []Component `json:"components"`
+}
+
+// DepthResponse counts the pages at each click depth from a root page
+type DepthResponse struct {
+	Root        string `json:"root"`
+	Pages       []int  `json:"pages"`
+	Unreachable int    `json:"unreachable"`
+}
+
+// HealthResponse tells the server is up, and the generation of its index
+type HealthResponse struct {
+	Status     string `json:"status"`
+	Generation uint64 `json:"generation"`
+}
+
+// VerifyResponse tells whether the indexes are consistent, with the first problems found
+type VerifyResponse struct {
+	Status   string   `json:"status"`
+	Problems int      `json:"problems"`
+	Details  []string `json:"details,omitempty"`
+}
+
+type SynonymsResponse struct {
+	Phrases int `json:"phrases"`
+}
+
+// MetricsResponse counts how the server answered queries
+type MetricsResponse struct {
+	QueryCache  queryCache.Stats     `json:"query_cache"`
+	IndexCaches []Indexer.CacheStats `json:"index_caches"`
+	// Snapshots taken of the collection statistics
+	CollectionSnapshots uint64 `json:"collection_snapshots"`
+}
+
+// Link based ranking signals a query can be blended with, given by ?signal=.
+// The query_ ones compute HITS over the base set of the query's results.
+const (
+	signalPageRank       = "pagerank"
+	signalHub            = "hub"
+	signalAuthority      = "authority"
+	signalQueryHub       = "query_hub"
+	signalQueryAuthority = "query_authority"
+)
+
+var signals = map[string]bool{signalPageRank: true, signalHub: true, signalAuthority: true, signalQueryHub: true, signalQueryAuthority: true}
+
+// Terms taken from a page to find similar pages, and how many are returned
+var similarTerms = 20
+var similarResults = 10
+
+// Most frequent words listed by /admin/stats by default and at most, and
+// problems listed by /admin/verify
+var statsTopTerms = 20
+var statsMaxTopTerms = 100
+var verifyProblems = 20
+
+// New sets up the server over the indexes with the ranking and synonyms of the config
+func New(indexes *Indexer.Indexes, settings *config.Config) *Server {
+	s := &Server{
+		ranking:  settings.Ranking,
+		maxDepth: settings.Crawl.MaxDepth,
+		settings: settings,
+		cache:    queryCache.New(settings.Server.CacheSize, settings.Server.CacheTTL),
+	}
+	indexes.EnableCaches(settings.Server.IndexCache.Budgets())
+	s.Initialize(indexes)
+	s.loadSynonyms(settings.Server.Synonyms)
+	s.routes()
+	return s
+}
+
+// ListenAndServe serves the API on an address, reloading the synonyms on SIGHUP
+func (s *Server) ListenAndServe(addr string) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := s.synonyms.Reload(); err != nil {
+				log.Println(err)
+			} else {
+				// Cached results were expanded with the old synonyms
+				s.cache.Purge()
+				log.Printf("Reloaded %d synonym phrases", s.synonyms.Len())
+			}
+		}
+	}()
+	return http.ListenAndServe(addr, s.router)
+}
+
+func (s *Server) loadSynonyms(path string) {
+	store, err := synonyms.NewStore(path)
+	if err != nil {
+		fmt.Println(err)
+	}
+	s.synonyms = store
+}
+
+func (s *Server) Initialize(indexes *Indexer.Indexes) {
+	s.indexes = indexes
+	s.documentIndexer = indexes.DocumentIndexer
+	s.reverseDocumentIndexer = indexes.ReverseDocumentIndexer
+	s.wordIndexer = indexes.WordIndexer
+	s.reverseWordIndexer = indexes.ReverseWordIndexer
+	s.pagePropertiesIndexer = indexes.PagePropertiesIndexer
+	s.titleInvertedIndexer = indexes.TitleInvertedIndexer
+	s.contentInvertedIndexer = indexes.ContentInvertedIndexer
+	s.headingInvertedIndexer = indexes.HeadingInvertedIndexer
+	s.descriptionInvertedIndexer = indexes.DescriptionInvertedIndexer
+	s.keywordsInvertedIndexer = indexes.KeywordsInvertedIndexer
+	s.altInvertedIndexer = indexes.AltInvertedIndexer
+	s.urlInvertedIndexer = indexes.URLInvertedIndexer
+	s.documentWordForwardIndexer = indexes.DocumentWordForwardIndexer
+	s.titleWordForwardIndexer = indexes.TitleWordForwardIndexer
+	s.parentChildDocumentForwardIndexer = indexes.ParentChildDocumentForwardIndexer
+	s.childParentDocumentForwardIndexer = indexes.ChildParentDocumentForwardIndexer
+	s.pageRankIndexer = indexes.PageRankIndexer
+	s.topicPageRankIndexer = indexes.TopicPageRankIndexer
+	s.hubIndexer = indexes.HubIndexer
+	s.authorityIndexer = indexes.AuthorityIndexer
+	s.crawlReportIndexer = indexes.CrawlReportIndexer
+	s.metadataIndexer = indexes.MetadataIndexer
+
+	s.loadTopics()
+
+	// Analyse queries with the analyzers the index was built with
+	recordedAnalyzers, analyzersErr := s.metadataIndexer.GetFieldAnalyzers()
+	if analyzersErr != nil {
+		fmt.Printf("error when reading field analyzers: %s\n", analyzersErr)
+	}
+	analyzers, analyzersErr := tokenizer.RecordedAnalyzers(recordedAnalyzers)
+	if analyzersErr != nil {
+		fmt.Println(analyzersErr)
+		s.analyzersErr = analyzersErr
+	}
+
+	s.router = mux.NewRouter()
+	s.vsm = &vsm.VSM{
+		DocumentIndexer:                   s.documentIndexer,
+		WordIndexer:                       s.wordIndexer,
+		ReverseDocumentIndexer:            s.reverseDocumentIndexer,
+		ReverseWordIndexer:                s.reverseWordIndexer,
+		PagePropertiesIndexer:             s.pagePropertiesIndexer,
+		TitleInvertedIndexer:              s.titleInvertedIndexer,
+		ContentInvertedIndexer:            s.contentInvertedIndexer,
+		DocumentWordForwardIndexer:        s.documentWordForwardIndexer,
+		ParentChildDocumentForwardIndexer: s.parentChildDocumentForwardIndexer,
+		ChildParentDocumentForwardIndexer: s.childParentDocumentForwardIndexer,
+		TitleWordForwardIndexer:           s.titleWordForwardIndexer,
+		Collection:                        indexes.Collection,
+		TitleBoost:                        s.ranking.TitleBoost,
+		Fields: []vsm.Field{
+			{Name: tokenizer.FieldHeading, InvertedIndexer: s.headingInvertedIndexer, Boost: s.ranking.HeadingBoost},
+			{Name: tokenizer.FieldDescription, InvertedIndexer: s.descriptionInvertedIndexer, Boost: s.ranking.DescriptionBoost},
+			{Name: tokenizer.FieldKeywords, InvertedIndexer: s.keywordsInvertedIndexer, Boost: s.ranking.KeywordsBoost},
+			{Name: tokenizer.FieldAlt, InvertedIndexer: s.altInvertedIndexer, Boost: s.ranking.AltBoost},
+			{Name: tokenizer.FieldURL, InvertedIndexer: s.urlInvertedIndexer, Boost: s.ranking.URLBoost},
+		},
+		Analyzers: analyzers,
+	}
+
+	s.bs = &boolsearch.BoolSearch{
+		ContentInvertedIndexer: s.contentInvertedIndexer,
+		Vsm:                    s.vsm,
+	}
+
+	s.pls = &phrasalSearch.PhrasalSearch{
+		TitleInvertedIndexer:    s.titleInvertedIndexer,
+		ContentInvertedIndexer:  s.contentInvertedIndexer,
+		TitleWordForwardIndexer: s.titleWordForwardIndexer,
+		V:                       s.vsm,
+		Bs:                      s.bs,
+	}
+
+}
+
+// loadTopics reads the topics with a PageRank
+func (s *Server) loadTopics() {
+	topics, err := s.topicPageRankIndexer.Topics()
+	if err != nil {
+		fmt.Println(err)
+	}
+	set := make(map[string]bool)
+	for _, topic := range topics {
+		set[topic] = true
+	}
+	s.topicsMutex.Lock()
+	defer s.topicsMutex.Unlock()
+	s.topics = set
+}
+
+func (s *Server) hasTopic(topic string) bool {
+	s.topicsMutex.RLock()
+	defer s.topicsMutex.RUnlock()
+	return s.topics[topic]
+}
+
+// indexChanged reads again what the server keeps from the index once a job changed it
+func (s *Server) indexChanged() {
+	s.loadTopics()
+	if _, err := s.statsSnapshot(); err != nil {
+		log.Printf("Error while reading stats: %s", err)
+	}
+}
+
+func (g *GraphResponse) AppendNodesAndEdgesStringFromIDList(s *Server, docIDs []uint64) ([]uint64, error) {
+	resultIDs := []uint64{}
+	for _, docID := range docIDs {
+		curStr, curErr := s.reverseDocumentIndexer.GetValueFromKey(docID)
+		if curErr != nil {
+			continue
+		}
+		idList, _ := s.parentChildDocumentForwardIndexer.GetIdListFromKey(uint64(docID))
+		for _, i := range idList {
+			str, valErr := s.reverseDocumentIndexer.GetValueFromKey(i)
+			if valErr == badger.ErrKeyNotFound {
+				continue
+			} else if valErr != nil {
+				return nil, valErr
+			}
+
+			g.EdgesString = append(g.EdgesString, EdgeString{From: curStr, To: str})
+			exist := false
+			for _, node := range g.Nodes {
+				if node.Name == str {
+					exist = true
+				}
+			}
+			if !exist {
+				g.Nodes = append(g.Nodes, Node{Name: str})
+			}
+
+		}
+		resultIDs = append(resultIDs, idList...)
+	}
+	return resultIDs, nil
+}
+
+// CreateEdgesID ...
+func (g *GraphResponse) CreateEdgesID() {
+	idMap := make(map[string]int)
+	for i, val := range g.Nodes {
+		if _, ok := idMap[val.Name]; !ok {
+			idMap[val.Name] = i
+		}
+	}
+	for _, val := range g.EdgesString {
+		g.Edges = append(g.Edges, Edge{From: idMap[val.From], To: idMap[val.To]})
+	}
+}
+
+func (s *Server) graphHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, convertErr := strconv.Atoi(vars["documentID"])
+	if convertErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("400 - Invalid parameter value! Details: " + convertErr.Error()))
+	}
+	resp := &GraphResponse{}
+	curIDList := []uint64{}
+	var iterErr error
+	// Append first id to curIDList
+	curIDList = append(curIDList, uint64(id))
+	for iterations := 0; iterations < s.maxDepth; iterations++ {
+		curIDList, iterErr = resp.AppendNodesAndEdgesStringFromIDList(s, curIDList)
+		if iterErr != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("500 - Internal Server Error! Details: " + iterErr.Error()))
+			break
+		}
+	}
+	resp.CreateEdgesID()
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	jsonResult, _ := json.Marshal(resp)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResult)
+}
+
+func (s *Server) wordListHandler(w http.ResponseWriter, r *http.Request) {
+	resp := &WordListResponse{}
+	resp.WordList = s.wordIndexer.AllValue()
+	jsonResult, _ := json.Marshal(resp)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResult)
+}
+
+// SearchOptions are the query parameters of a search
+type SearchOptions struct {
+	// Language of the query, detected if empty
+	Language string
+	// Link signal blended with the text score, pagerank if empty
+	Signal string
+	// Topic whose PageRank is used, only with the pagerank signal
+	Topic string
+	// Expand the query with pseudo relevance feedback
+	Expand bool
+	// Explain how the query was analysed and expanded
+	Explain bool
+}
+
+// Search scores every page for a query. Phrases in double quotes boost the
+// pages containing them. Results are cached for the generation of the index
+// they were computed at, so a crawl or a new PageRank invalidates them.
+func (s *Server) Search(query string, options SearchOptions) (*QueryListResponse, error) {
+	if s.cache == nil {
+		return s.search(query, options)
+	}
+	generation := s.indexes.Generation()
+	key := queryCache.Key(query, options.Language, options.Signal, options.Topic, strconv.FormatBool(options.Expand), strconv.FormatBool(options.Explain))
+	if resp, ok := s.cache.Get(generation, key); ok {
+		return resp.(*QueryListResponse), nil
+	}
+	resp, err := s.search(query, options)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Add(generation, key, resp)
+	return resp, nil
+}
+
+func (s *Server) search(query string, options SearchOptions) (*QueryListResponse, error) {
+	if s.analyzersErr != nil {
+		return nil, s.analyzersErr
+	}
+	// Queries are analysed in the language given by ?lang=, or the detected one.
+	// Those too short to detect are analysed in the language of each page.
+	language := tokenizer.QueryLanguage(options.Language, query)
+
+	// ?topic= blends in the PageRank biased toward that topic's pages
+	topic := options.Topic
+	if topic != "" && !s.hasTopic(topic) {
+		return nil, fmt.Errorf("unknown topic %s", topic)
+	}
+	signal := options.Signal
+	if signal == "" {
+		signal = signalPageRank
+	}
+	if !signals[signal] || (topic != "" && signal != signalPageRank) {
+		return nil, fmt.Errorf("unknown signal %s, or a topic with a signal other than pagerank", signal)
+	}
+
+	// Extract phrases first before doing everything else
+	regex, _ := regexp.Compile(`("([^"]|"")*")`)
+	phraseList := regex.FindAllString(query, -1)
+	boostedDocsIDList := make(map[uint64]int)
+	for _, phrase := range phraseList {
+		for _, doc := range s.phraseDocuments(strings.Trim(phrase, "\""), language) {
+			boostedDocsIDList[doc]++
+		}
+	}
+
+	resp := &QueryListResponse{}
+
+	responses := QueryResponses{}
+
+	// Synonyms are added as extra, down weighted queries
+	queries := []vsm.WeightedQuery{{Text: query, Weight: 1}}
+	explanation := &QueryExplanation{Language: language}
+	explanation.Queries = append(explanation.Queries, ExplainedQuery{Text: query, Weight: 1, Terms: s.vsm.Analyze(tokenizer.FieldBody, language, query)})
+	for _, expansion := range s.synonyms.Expand(query) {
+		terms := s.vsm.Analyze(tokenizer.FieldBody, language, expansion.Synonym)
+		if len(terms) == 0 {
+			continue
+		}
+		// Spread the weight so long synonyms do not outweigh the query
+		weight := s.ranking.SynonymWeight / float64(len(terms))
+		queries = append(queries, vsm.WeightedQuery{Text: expansion.Synonym, Weight: weight})
+		explanation.Queries = append(explanation.Queries, ExplainedQuery{Text: expansion.Synonym, Source: expansion.Source, Weight: weight, Terms: terms})
+	}
+
+	start := time.Now()
+	cosScore, err := s.vsm.ComputeWeightedCosineScore(queries, language)
+	// Pseudo relevance feedback scores again with terms of the top documents
+	if err == nil && options.Expand {
+		cosScore, resp.ExpandedTerms, err = s.vsm.ComputeRocchioCosineScore(queries, language, cosScore, vsm.DefaultRocchioOptions)
+	}
+	elapsed := time.Since(start)
+	log.Printf("Cosine took %s", elapsed)
+	start = time.Now()
+
+	if err != nil {
+		return nil, err
+	}
+	linkScore := s.linkSignal(signal, topic, cosScore)
+	resp.Signal = signal
+	for i, score := range cosScore {
+		if score == 0 {
+			continue
+		}
+
+		pageRankScore, err := linkScore(i)
+
+		if math.IsInf(pageRankScore, 1) {
+			pageRankScore = 0
+		}
+
+		if err != nil {
+			fmt.Println("error retrieving page rank value", err)
+		}
+
+		doc := &QueryResponse{}
+		doc.PageRankScore = pageRankScore
+		doc.PageID = i
+
+		doc.VSMScore = score
+		doc.Score = s.ranking.LinkWeight*pageRankScore + (1-s.ranking.LinkWeight)*score
+		// add boost to phrases!
+		if _, ok := boostedDocsIDList[i]; ok {
+			fmt.Println("Applying boost as phrase search")
+			doc.Score *= s.ranking.PhraseBoost
+		}
+		s.addPageDetails(doc)
+
+		responses = append(responses, *doc)
+	}
+	sort.Sort(responses)
+	resp.List = responses
+	if options.Explain {
+		resp.Explain = explanation
+	}
+	elapsed = time.Since(start)
+	log.Printf("Forming response took %s", elapsed)
+	return resp, nil
+}
+
+// phraseDocuments returns the pages containing a phrase analysed in a
+// language, or in the language of each page if the language is ""
+func (s *Server) phraseDocuments(phrase string, language string) []uint64 {
+	if language != "" {
+		return s.pls.GetPhraseDocuments(s.vsm.Analyze(tokenizer.FieldBody, language, phrase))
+	}
+	documents := make([]uint64, 0)
+	for _, pageLanguage := range tokenizer.Languages() {
+		for _, doc := range s.pls.GetPhraseDocuments(s.vsm.Analyze(tokenizer.FieldBody, pageLanguage, phrase)) {
+			if s.vsm.PageLanguage(doc) == pageLanguage {
+				documents = append(documents, doc)
+			}
+		}
+	}
+	return documents
+}
+
+func (s *Server) queryHandler(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	params := r.URL.Query()
+	resp, err := s.Search(vars["queryString"], SearchOptions{
+		Language: params.Get("lang"),
+		Signal:   params.Get("signal"),
+		Topic:    params.Get("topic"),
+		Expand:   params.Get("expand") == "true",
+		Explain:  params.Get("explain") == "true",
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("400 - Invalid parameter value! Details: " + err.Error()))
+		return
+	}
+	jsonResult, jsonErr := json.Marshal(resp)
+	if jsonErr != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 - Internal Server Error! Details: " + jsonErr.Error()))
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResult)
+}
+
+// graphExportHandler exports the link graph in the format given by ?format=,
+// filtered like the export command with ?host=, ?min_pagerank=, ?root= and ?depth=
+func (s *Server) graphExportHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	format := params.Get("format")
+	if format == "" {
+		format = linkGraph.FormatGraphML
+	}
+	filter := linkGraph.Filter{Hosts: params["host"], Depth: s.maxDepth}
+	if minPageRank := params.Get("min_pagerank"); minPageRank != "" {
+		value, err := strconv.ParseFloat(minPageRank, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("400 - Invalid parameter value! Details: " + err.Error()))
+			return
+		}
+		filter.MinPageRank = value
+	}
+	if depth := params.Get("depth"); depth != "" {
+		value, err := strconv.Atoi(depth)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("400 - Invalid parameter value! Details: " + err.Error()))
+			return
+		}
+		filter.Depth = value
+	}
+	for _, root := range params["root"] {
+		id, err := linkGraph.PageID(s.documentIndexer, root)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("400 - Invalid parameter value! Details: " + err.Error()))
+			return
+		}
+		filter.Roots = append(filter.Roots, id)
+	}
+
+	graph, err := s.sharedGraph()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 - Internal Server Error! Details: " + err.Error()))
+		return
+	}
+	export := linkGraph.Build(graph, linkGraph.NodeInfo(s.pagePropertiesIndexer, s.reverseDocumentIndexer, s.pageRankIndexer), filter)
+	var buffer bytes.Buffer
+	if err := export.Write(&buffer, format); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("400 - Invalid parameter value! Details: " + err.Error()))
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", linkGraph.ContentType(format))
+	w.Write(buffer.Bytes())
+}
+
+// sharedGraph returns the link graph of the index, loaded once per generation.
+// It is shared by concurrent requests, which must not modify it.
+func (s *Server) sharedGraph() (*linkGraph.Graph, error) {
+	generation := s.indexes.Generation()
+	s.graphMutex.Lock()
+	defer s.graphMutex.Unlock()
+	if s.graph != nil && s.graphGeneration == generation {
+		return s.graph, nil
+	}
+	graph, err := linkGraph.Load(s.documentIndexer, s.parentChildDocumentForwardIndexer)
+	if err != nil {
+		return nil, err
+	}
+	s.graph = graph
+	s.graphGeneration = generation
+	return graph, nil
+}
+
+// linkSignal returns the link based score of a page for a query's ranking
+func (s *Server) linkSignal(signal string, topic string, scores map[uint64]float64) func(uint64) (float64, error) {
+	switch signal {
+	case signalHub:
+		return s.hubIndexer.GetValueFromKey
+	case signalAuthority:
+		return s.authorityIndexer.GetValueFromKey
+	case signalQueryHub, signalQueryAuthority:
+		root := make([]uint64, 0, len(scores))
+		for id, score := range scores {
+			if score > 0 {
+				root = append(root, id)
+			}
+		}
+		sort.Slice(root, func(i, j int) bool { return scores[root[i]] > scores[root[j]] })
+		if len(root) > hits.RootSetSize {
+			root = root[:hits.RootSetSize]
+		}
+		values := map[uint64]float64{}
+		if graph, err := hits.BaseSet(root, s.parentChildDocumentForwardIndexer, s.childParentDocumentForwardIndexer); err == nil {
+			result := hits.Solve(graph, hits.DefaultOptions)
+			values = result.Authorities
+			if signal == signalQueryHub {
+				values = result.Hubs
+			}
+		}
+		return func(id uint64) (float64, error) {
+			return values[id], nil
+		}
+	}
+	if topic != "" {
+		return func(id uint64) (float64, error) {
+			return s.topicPageRankIndexer.GetValueFromKey(topic, id)
+		}
+	}
+	return s.pageRankIndexer.GetValueFromKey
+}
+
+// addPageDetails fills in the properties, links and top keywords of a result page
+func (s *Server) addPageDetails(doc *QueryResponse) {
+	pageProps, _ := s.pagePropertiesIndexer.GetPagePropertiesFromKey(doc.PageID)
+	doc.Title = pageProps.GetTitle()
+	doc.URL = pageProps.GetUrl()
+	doc.Snippet = pageProps.GetDescription()
+	doc.LastModifiedDate = pageProps.GetDate()
+	childList, _ := s.parentChildDocumentForwardIndexer.GetIdListFromKey(doc.PageID)
+	for _, childID := range childList {
+		str, err := s.reverseDocumentIndexer.GetValueFromKey(childID)
+		if err == nil {
+			doc.ChildList = append(doc.ChildList, str)
+		}
+	}
+	parentList, _ := s.childParentDocumentForwardIndexer.GetIdListFromKey(doc.PageID)
+	for _, parentID := range parentList {
+		str, err := s.reverseDocumentIndexer.GetValueFromKey(parentID)
+		if err == nil {
+			doc.ParentList = append(doc.ParentList, str)
+		}
+	}
+
+	wordFreq, _ := s.documentWordForwardIndexer.GetWordFrequencyListFromKey(doc.PageID)
+	sort.Sort(Indexer.WordFrequencySorter(wordFreq))
+	if len(wordFreq) > 5 {
+		wordFreq = wordFreq[:5]
+	}
+	for _, wordF := range wordFreq {
+		wordStr, wordErr := s.reverseWordIndexer.GetValueFromKey(wordF.GetID())
+		if wordErr == nil {
+			doc.KeyWord = append(doc.KeyWord, WordFrequencyString{Word: wordStr, Frequency: wordF.GetFrequency()})
+		}
+	}
+}
+
+func (s *Server) similarHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, convertErr := strconv.ParseUint(vars["documentID"], 10, 64)
+	if convertErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("400 - Invalid parameter value! Details: " + convertErr.Error()))
+		return
+	}
+
+	scores, terms, err := s.vsm.ComputeSimilarScore(id, similarTerms)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("404 - Document not found! Details: " + err.Error()))
+		return
+	}
+
+	// Pages linked from the same parents add to the content similarity
+	cocitation := map[uint64]float64{}
+	if r.URL.Query().Get("cocitation") == "true" {
+		cocitation, _ = linkGraph.CoCitation(id, s.parentChildDocumentForwardIndexer, s.childParentDocumentForwardIndexer)
+		for i := range cocitation {
+			if _, ok := scores[i]; !ok {
+				scores[i] = 0
+			}
+		}
+	}
+
+	responses := QueryResponses{}
+	for i, score := range scores {
+		doc := &QueryResponse{PageID: i, VSMScore: score}
+		doc.Score = (1-s.ranking.CocitationWeight)*score + s.ranking.CocitationWeight*cocitation[i]
+		if doc.Score == 0 {
+			continue
+		}
+		responses = append(responses, *doc)
+	}
+	sort.Sort(responses)
+	if len(responses) > similarResults {
+		responses = responses[:similarResults]
+	}
+	for i := range responses {
+		s.addPageDetails(&responses[i])
+	}
+
+	resp := &QueryListResponse{List: responses, QueryTerms: terms}
+	jsonResult, _ := json.Marshal(resp)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResult)
+}
+
+func (s *Server) crawlListHandler(w http.ResponseWriter, r *http.Request) {
+	reports, err := s.crawlReportIndexer.All()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 - Internal Server Error! Details: " + err.Error()))
+		return
+	}
+	resp := &CrawlListResponse{Crawls: []CrawlSummary{}}
+	for _, report := range reports {
+		resp.Crawls = append(resp.Crawls, CrawlSummary{
+			ID:        report.ID,
+			Root:      report.Root,
+			Start:     report.Start,
+			End:       report.End,
+			Finished:  report.Finished,
+			Fetched:   report.Fetched,
+			Indexed:   report.Indexed,
+			Skipped:   report.Skipped,
+			Unchanged: report.Unchanged,
+			Failed:    len(report.Failures),
+		})
+	}
+	jsonResult, _ := json.Marshal(resp)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResult)
+}
+
+func (s *Server) crawlHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, convertErr := strconv.ParseUint(vars["crawlID"], 10, 64)
+	if convertErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("400 - Invalid parameter value! Details: " + convertErr.Error()))
+		return
+	}
+	report, err := s.crawlReportIndexer.GetReportFromKey(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("404 - Crawl not found! Details: " + err.Error()))
+		return
+	}
+	jsonResult, _ := json.Marshal(report)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResult)
+}
+
+// loadGraph returns the shared link graph and looks up the page number of each
+// page parameter, an ID or a URL. It writes the error response if one fails.
+func (s *Server) loadGraph(w http.ResponseWriter, pages ...string) (*linkGraph.Graph, []int, bool) {
+	graph, err := s.sharedGraph()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 - Internal Server Error! Details: " + err.Error()))
+		return nil, nil, false
+	}
+	indexes := make([]int, 0, len(pages))
+	for _, page := range pages {
+		id, err := linkGraph.PageID(s.documentIndexer, page)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("400 - Invalid parameter value! Details: " + err.Error()))
+			return nil, nil, false
+		}
+		i, ok := graph.Index(id)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - Page not found! Details: " + page + " is not in the link graph"))
+			return nil, nil, false
+		}
+		indexes = append(indexes, i)
+	}
+	return graph, indexes, true
+}
+
+func writeJSON(w http.ResponseWriter, resp interface{}) {
+	jsonResult, _ := json.Marshal(resp)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResult)
+}
+
+// pathHandler finds a shortest click path from ?from= to ?to=
+func (s *Server) pathHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	if params.Get("from") == "" || params.Get("to") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("400 - Invalid parameter value! Details: from and to pages are required"))
+		return
+	}
+	graph, pages, ok := s.loadGraph(w, params.Get("from"), params.Get("to"))
+	if !ok {
+		return
+	}
+	path := graph.ShortestPath(pages[0], pages[1])
+	if path == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("404 - No path found! Details: " + params.Get("to") + " cannot be reached from " + params.Get("from")))
+		return
+	}
+	nodeInfo := linkGraph.NodeInfo(s.pagePropertiesIndexer, s.reverseDocumentIndexer, s.pageRankIndexer)
+	resp := &PathResponse{Clicks: len(path) - 1, Path: []linkGraph.Node{}}
+	for _, i := range path {
+		resp.Path = append(resp.Path, nodeInfo(graph.IDs[i]))
+	}
+	writeJSON(w, resp)
+}
+
+// orphansHandler lists the pages no other page links to
+func (s *Server) orphansHandler(w http.ResponseWriter, r *http.Request) {
+	graph, _, ok := s.loadGraph(w)
+	if !ok {
+		return
+	}
+	nodeInfo := linkGraph.NodeInfo(s.pagePropertiesIndexer, s.reverseDocumentIndexer, s.pageRankIndexer)
+	resp := &OrphansResponse{Pages: []linkGraph.Node{}}
+	for _, i := range graph.Orphans() {
+		node := nodeInfo(graph.IDs[i])
+		node.OutDegree = graph.OutDegree(i)
+		resp.Pages = append(resp.Pages, node)
+	}
+	resp.Count = len(resp.Pages)
+	writeJSON(w, resp)
+}
+
+// brokenLinksHandler lists the links to pages that failed in the latest crawl,
+// or the crawl given by ?crawl=
+func (s *Server) brokenLinksHandler(w http.ResponseWriter, r *http.Request) {
+	var report Indexer.CrawlReport
+	var err error
+	if crawl := r.URL.Query().Get("crawl"); crawl != "" {
+		id, convertErr := strconv.ParseUint(crawl, 10, 64)
+		if convertErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("400 - Invalid parameter value! Details: " + convertErr.Error()))
+			return
+		}
+		report, err = s.crawlReportIndexer.GetReportFromKey(id)
+	} else {
+		report, err = s.crawlReportIndexer.Latest()
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("404 - Crawl not found! Details: " + err.Error()))
+		return
+	}
+	graph, _, ok := s.loadGraph(w)
+	if !ok {
+		return
+	}
+	writeJSON(w, &BrokenLinksResponse{
+		CrawlID: report.ID,
+		Links:   graph.BrokenLinks(report, s.documentIndexer, s.reverseDocumentIndexer),
+	})
+}
+
+// componentsHandler reports the strongly connected components, listing the
+// pages of those with at least ?min_size= pages (default 2)
+func (s *Server) componentsHandler(w http.ResponseWriter, r *http.Request) {
+	minSize := 2
+	if param := r.URL.Query().Get("min_size"); param != "" {
+		value, convertErr := strconv.Atoi(param)
+		if convertErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("400 - Invalid parameter value! Details: " + convertErr.Error()))
+			return
+		}
+		minSize = value
+	}
+	graph, _, ok := s.loadGraph(w)
+	if !ok {
+		return
+	}
+	components := graph.StronglyConnectedComponents()
+	resp := &ComponentsResponse{Count: len(components), Sizes: make(map[int]int), Components: []Component{}}
+	for _, component := range components {
+		resp.Sizes[len(component)]++
+		if len(component) > resp.Largest {
+			resp.Largest = len(component)
+		}
+		if len(component) < minSize {
+			continue
+		}
+		pages := make([]string, 0, len(component))
+		for _, i := range component {
+			url, _ := s.reverseDocumentIndexer.GetValueFromKey(graph.IDs[i])
+			pages = append(pages, url)
+		}
+		resp.Components = append(resp.Components, Component{Size: len(component), Pages: pages})
+	}
+	writeJSON(w, resp)
+}
+
+// depthHandler counts the pages at each click depth from ?root=, by default
+// the root of the latest crawl
+func (s *Server) depthHandler(w http.ResponseWriter, r *http.Request) {
+	root := r.URL.Query().Get("root")
+	if root == "" {
+		report, err := s.crawlReportIndexer.Latest()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("400 - Invalid parameter value! Details: root page is required when there is no crawl"))
+			return
+		}
+		root = report.Root
+	}
+	graph, pages, ok := s.loadGraph(w, root)
+	if !ok {
+		return
+	}
+	resp := &DepthResponse{Root: root}
+	resp.Pages, resp.Unreachable = graph.DepthDistribution(pages[0])
+	writeJSON(w, resp)
+}
+
+// statsSnapshot returns the statistics of the index with the statsMaxTopTerms
+// most frequent words, computed once per generation: they read every database.
+func (s *Server) statsSnapshot() (*Indexer.Stats, error) {
+	generation := s.indexes.Generation()
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	if s.stats != nil && s.statsGeneration == generation {
+		return s.stats, nil
+	}
+	stats, err := s.indexes.Stats(statsMaxTopTerms)
+	if err != nil {
+		return nil, err
+	}
+	s.stats = stats
+	s.statsGeneration = generation
+	return stats, nil
+}
+
+// statsHandler describes the size of the index, with the ?top= most frequent words
+func (s *Server) statsHandler(w http.ResponseWriter, r *http.Request) {
+	top := statsTopTerms
+	if param := r.URL.Query().Get("top"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 0 || n > statsMaxTopTerms {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("400 - Invalid parameter value! Details: top must be a number from 0 to %d", statsMaxTopTerms)))
+			return
+		}
+		top = n
+	}
+	snapshot, err := s.statsSnapshot()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 - Internal Server Error! Details: " + err.Error()))
+		return
+	}
+	// The snapshot is shared, answer with a copy keeping the top words asked for
+	stats := *snapshot
+	if top < len(stats.TopTerms) {
+		stats.TopTerms = stats.TopTerms[:top]
+	}
+	writeJSON(w, &stats)
+}
+
+// healthHandler tells the server is up without reading the index, for
+// liveness checks
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, &HealthResponse{Status: "ok", Generation: s.indexes.Generation()})
+}
+
+// verifyHandler checks the indexes agree with each other, answering 503 if not.
+// It reads every database.
+func (s *Server) verifyHandler(w http.ResponseWriter, r *http.Request) {
+	problems, err := s.indexes.Verify()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 - Internal Server Error! Details: " + err.Error()))
+		return
+	}
+	resp := &VerifyResponse{Status: "ok", Problems: len(problems)}
+	if len(problems) > 0 {
+		resp.Status = "inconsistent"
+		for i := 0; i < len(problems) && i < verifyProblems; i++ {
+			resp.Details = append(resp.Details, problems[i].String())
+		}
+		// Headers set after the status are ignored
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, resp)
+}
+
+// metricsHandler reports the hits and misses of the query cache
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	resp := &MetricsResponse{IndexCaches: s.indexes.CacheStats()}
+	if s.cache != nil {
+		resp.QueryCache = s.cache.Stats()
+	}
+	if s.indexes.Collection != nil {
+		resp.CollectionSnapshots = s.indexes.Collection.Builds()
+	}
+	writeJSON(w, resp)
+}
+
+// backupHandler streams a backup archive of the index while it keeps serving
+func (s *Server) backupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", "attachment; filename=searchengine-"+time.Now().Format("20060102-150405")+".tar.gz")
+	if _, err := s.indexes.Backup(w); err != nil {
+		if err == Indexer.ErrWriting {
+			// Nothing is written yet, ask again once the job is done
+			w.Header().Del("Content-Disposition")
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte("409 - Index being changed! Details: " + err.Error()))
+			return
+		}
+		// Once the archive has started the status is already sent, restore
+		// then rejects the truncated archive
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 - Internal Server Error! Details: " + err.Error()))
+	}
+}
+
+func (s *Server) synonymsReloadHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.synonyms.Reload(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 - Internal Server Error! Details: " + err.Error()))
+		return
+	}
+	s.cache.Purge()
+	jsonResult, _ := json.Marshal(&SynonymsResponse{Phrases: s.synonyms.Len()})
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResult)
+}
+
+func (s *Server) routes() {
+	s.router.HandleFunc("/graph/{documentID}", s.graphHandler)
+	s.router.HandleFunc("/export/graph", s.graphExportHandler)
+	s.router.HandleFunc("/analytics/path", s.pathHandler)
+	s.router.HandleFunc("/analytics/orphans", s.orphansHandler)
+	s.router.HandleFunc("/analytics/broken-links", s.brokenLinksHandler)
+	s.router.HandleFunc("/analytics/components", s.componentsHandler)
+	s.router.HandleFunc("/analytics/depth", s.depthHandler)
+	s.router.HandleFunc("/wordList", s.wordListHandler)
+	s.router.HandleFunc("/query/{queryString}", s.queryHandler)
+	s.router.HandleFunc("/similar/{documentID}", s.similarHandler)
+	s.router.HandleFunc("/admin/stats", s.statsHandler)
+	s.router.HandleFunc("/admin/health", s.healthHandler)
+	s.router.HandleFunc("/admin/verify", s.verifyHandler)
+	s.router.HandleFunc("/admin/metrics", s.metricsHandler)
+	s.router.HandleFunc("/admin/backup", s.backupHandler)
+	s.router.HandleFunc("/admin/crawls", s.crawlListHandler)
+	s.router.HandleFunc("/admin/crawls/{crawlID}", s.crawlHandler)
+	s.router.HandleFunc("/admin/synonyms/reload", s.synonymsReloadHandler).Methods("POST")
+	s.router.HandleFunc("/admin/jobs", s.jobsHandler)
+	s.router.HandleFunc("/admin/crawl", s.jobHandler(s.crawlJobHandler)).Methods("POST")
+	s.router.HandleFunc("/admin/rank", s.jobHandler(s.rankJobHandler)).Methods("POST")
+	s.router.HandleFunc("/admin/repair", s.jobHandler(s.repairJobHandler)).Methods("POST")
+}
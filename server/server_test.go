@@ -0,0 +1,165 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
+)
+
+// openIndexes opens indexes in a temporary directory with pages a and b, a linking to b
+func openIndexes(t *testing.T) (*Indexer.Indexes, func()) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexes, err := Indexer.Open(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	a, _ := indexes.DocumentIndexer.AddKeyToIndex("https://www.cse.ust.hk/a")
+	b, _ := indexes.DocumentIndexer.AddKeyToIndex("https://www.cse.ust.hk/b")
+	indexes.ParentChildDocumentForwardIndexer.AddIdListToKey(a, []uint64{b})
+	return indexes, func() {
+		indexes.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSharedGraph(t *testing.T) {
+	indexes, closeIndexes := openIndexes(t)
+	defer closeIndexes()
+	s := &Server{}
+	s.Initialize(indexes)
+
+	graph, err := s.sharedGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.Len() != 2 || graph.Edges() != 1 {
+		t.Errorf("graph of %d pages and %d links, want 2 and 1", graph.Len(), graph.Edges())
+	}
+	if again, _ := s.sharedGraph(); again != graph {
+		t.Error("graph loaded again for the same generation")
+	}
+
+	// A crawl adds pages, then bumps the generation
+	c, _ := indexes.DocumentIndexer.AddKeyToIndex("https://www.cse.ust.hk/c")
+	a, _ := indexes.DocumentIndexer.GetValueFromKey("https://www.cse.ust.hk/a")
+	indexes.ParentChildDocumentForwardIndexer.AddIdListToKey(c, []uint64{a})
+	if again, _ := s.sharedGraph(); again != graph {
+		t.Error("graph loaded again before the generation changed")
+	}
+	indexes.BumpGeneration()
+	if reloaded, _ := s.sharedGraph(); reloaded == graph || reloaded.Len() != 3 || reloaded.Edges() != 2 {
+		t.Error("graph not loaded again for the new generation")
+	}
+}
+
+func TestLoadGraphShared(t *testing.T) {
+	indexes, closeIndexes := openIndexes(t)
+	defer closeIndexes()
+	s := &Server{}
+	s.Initialize(indexes)
+
+	shared, err := s.sharedGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	graph, pages, ok := s.loadGraph(w, "https://www.cse.ust.hk/b")
+	if !ok {
+		t.Fatalf("loadGraph failed: %s", w.Body.String())
+	}
+	if graph != shared {
+		t.Error("analytics loaded their own graph")
+	}
+	b, _ := indexes.DocumentIndexer.GetValueFromKey("https://www.cse.ust.hk/b")
+	if len(pages) != 1 || graph.IDs[pages[0]] != b {
+		t.Errorf("pages %v, want the page number of %d", pages, b)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	indexes, closeIndexes := openIndexes(t)
+	defer closeIndexes()
+	s := &Server{}
+	s.Initialize(indexes)
+	indexes.BumpGeneration()
+
+	w := httptest.NewRecorder()
+	s.healthHandler(w, httptest.NewRequest("GET", "/admin/health", nil))
+	var resp HealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || resp.Status != "ok" || resp.Generation != 1 {
+		t.Errorf("health answered %d %+v", w.Code, resp)
+	}
+}
+
+func TestStatsSnapshot(t *testing.T) {
+	indexes, closeIndexes := openIndexes(t)
+	defer closeIndexes()
+	s := &Server{}
+	s.Initialize(indexes)
+
+	stats := func(query string) (int, *Indexer.Stats) {
+		w := httptest.NewRecorder()
+		s.statsHandler(w, httptest.NewRequest("GET", "/admin/stats"+query, nil))
+		if w.Code != http.StatusOK {
+			return w.Code, nil
+		}
+		resp := &Indexer.Stats{}
+		if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, resp
+	}
+	_, first := stats("")
+	snapshot := s.stats
+	if first.Documents != 2 {
+		t.Errorf("stats of %d documents, want 2", first.Documents)
+	}
+
+	// Pages added without a new generation are not counted until there is one
+	indexes.DocumentIndexer.AddKeyToIndex("https://www.cse.ust.hk/c")
+	if _, again := stats(""); again.Documents != first.Documents || s.stats != snapshot {
+		t.Error("stats computed again for the same generation")
+	}
+	indexes.BumpGeneration()
+	if _, again := stats(""); again.Documents != first.Documents+1 {
+		t.Errorf("stats of %d documents after the new generation, want %d", again.Documents, first.Documents+1)
+	}
+
+	if code, _ := stats(fmt.Sprintf("?top=%d", statsMaxTopTerms+1)); code != http.StatusBadRequest {
+		t.Errorf("top above the maximum answered %d", code)
+	}
+}
+
+func TestBackupDuringJob(t *testing.T) {
+	indexes, closeIndexes := openIndexes(t)
+	defer closeIndexes()
+	s := &Server{}
+	s.Initialize(indexes)
+
+	indexes.BeginWrite()
+	w := httptest.NewRecorder()
+	s.backupHandler(w, httptest.NewRequest("GET", "/admin/backup", nil))
+	indexes.EndWrite()
+	if w.Code != http.StatusConflict || w.Header().Get("Content-Disposition") != "" {
+		t.Errorf("backup during a job answered %d with %v", w.Code, w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	s.backupHandler(w, httptest.NewRequest("GET", "/admin/backup", nil))
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Errorf("backup answered %d with %d bytes", w.Code, w.Body.Len())
+	}
+}
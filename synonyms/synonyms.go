@@ -0,0 +1,204 @@
+package synonyms
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Rule expands any of its source phrases into its synonyms. A two way rule
+// like "hkust, hong kong university of science and technology" gives one rule
+// per phrase, a one way rule like "ml => machine learning" only expands "ml".
+type Rule struct {
+	From     string
+	Synonyms []string
+}
+
+// Expansion is a synonym found for a phrase of a query
+type Expansion struct {
+	Source  string `json:"source"`
+	Synonym string `json:"synonym"`
+}
+
+// Dictionary holds the rules indexed by their source phrase
+type Dictionary struct {
+	rules map[string][]string
+	// Longest source phrase in words, so matching can stop early
+	maxWords int
+}
+
+func NewDictionary() *Dictionary {
+	return &Dictionary{rules: make(map[string][]string)}
+}
+
+// Parse reads rules, one per line. Blank lines and lines starting with # are ignored.
+//
+//	hkust, hong kong university of science and technology
+//	ml => machine learning
+func Parse(r io.Reader) (*Dictionary, error) {
+	dictionary := NewDictionary()
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, "=>")
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("Error in synonyms line %d: more than one => in %q", lineNumber, line)
+		}
+		if len(parts) == 2 {
+			sources, synonyms := splitPhrases(parts[0]), splitPhrases(parts[1])
+			if len(sources) == 0 || len(synonyms) == 0 {
+				return nil, fmt.Errorf("Error in synonyms line %d: empty side in %q", lineNumber, line)
+			}
+			for _, source := range sources {
+				dictionary.Add(Rule{From: source, Synonyms: synonyms})
+			}
+			continue
+		}
+		phrases := splitPhrases(line)
+		if len(phrases) < 2 {
+			return nil, fmt.Errorf("Error in synonyms line %d: a two way rule needs at least two phrases", lineNumber)
+		}
+		for i, source := range phrases {
+			synonyms := make([]string, 0, len(phrases)-1)
+			synonyms = append(synonyms, phrases[:i]...)
+			synonyms = append(synonyms, phrases[i+1:]...)
+			dictionary.Add(Rule{From: source, Synonyms: synonyms})
+		}
+	}
+	return dictionary, scanner.Err()
+}
+
+// Load reads the rules of a file, a missing file gives an empty dictionary
+func Load(path string) (*Dictionary, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return NewDictionary(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+func splitPhrases(list string) []string {
+	phrases := make([]string, 0)
+	for _, phrase := range strings.Split(list, ",") {
+		if phrase = normalize(phrase); phrase != "" {
+			phrases = append(phrases, phrase)
+		}
+	}
+	return phrases
+}
+
+// normalize lowercases a phrase and joins its words with single spaces
+func normalize(phrase string) string {
+	return strings.Join(words(phrase), " ")
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Add adds a rule, keeping the synonyms of earlier rules for the same phrase
+func (dictionary *Dictionary) Add(rule Rule) {
+	from := normalize(rule.From)
+	for _, synonym := range rule.Synonyms {
+		if synonym = normalize(synonym); synonym != "" && synonym != from && !contains(dictionary.rules[from], synonym) {
+			dictionary.rules[from] = append(dictionary.rules[from], synonym)
+		}
+	}
+	if n := len(strings.Fields(from)); n > dictionary.maxWords {
+		dictionary.maxWords = n
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of phrases that have synonyms
+func (dictionary *Dictionary) Len() int {
+	return len(dictionary.rules)
+}
+
+// Expand finds the synonyms of the phrases in a query. At each word the longest
+// matching phrase wins, so "machine learning" is not also expanded as "machine".
+func (dictionary *Dictionary) Expand(query string) []Expansion {
+	expansions := make([]Expansion, 0)
+	queryWords := words(query)
+	for i := 0; i < len(queryWords); {
+		matched := 0
+		for n := dictionary.maxWords; n > 0; n-- {
+			if i+n > len(queryWords) {
+				continue
+			}
+			source := strings.Join(queryWords[i:i+n], " ")
+			if synonyms, ok := dictionary.rules[source]; ok {
+				for _, synonym := range synonyms {
+					expansions = append(expansions, Expansion{Source: source, Synonym: synonym})
+				}
+				matched = n
+				break
+			}
+		}
+		if matched == 0 {
+			matched = 1
+		}
+		i += matched
+	}
+	return expansions
+}
+
+// Store holds the dictionary of a file and can reload it while queries use it
+type Store struct {
+	mutex      sync.RWMutex
+	path       string
+	dictionary *Dictionary
+}
+
+// NewStore loads the rules of a file into a new Store
+func NewStore(path string) (*Store, error) {
+	store := &Store{path: path, dictionary: NewDictionary()}
+	return store, store.Reload()
+}
+
+// Reload reads the file again, keeping the current rules if it cannot be read
+func (store *Store) Reload() error {
+	dictionary, err := Load(store.path)
+	if err != nil {
+		return fmt.Errorf("Error while loading synonyms: %s", err)
+	}
+	store.mutex.Lock()
+	store.dictionary = dictionary
+	store.mutex.Unlock()
+	return nil
+}
+
+func (store *Store) Expand(query string) []Expansion {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.dictionary.Expand(query)
+}
+
+func (store *Store) Len() int {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.dictionary.Len()
+}
@@ -0,0 +1,44 @@
+package synonyms
+
+import (
+	"strings"
+	"testing"
+)
+
+const testRules = `
+# comment
+hkust, hong kong university of science and technology
+ml => machine learning
+machine learning => statistical learning
+`
+
+func TestExpand(t *testing.T) {
+	dictionary, err := Parse(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expansions := dictionary.Expand("HKUST ML courses")
+	if len(expansions) != 2 || expansions[0].Synonym != "hong kong university of science and technology" || expansions[1].Synonym != "machine learning" {
+		t.Fatalf("got %v", expansions)
+	}
+
+	// Two way rules expand both sides, the longest phrase is matched
+	expansions = dictionary.Expand("Hong Kong University of Science and Technology machine learning")
+	if len(expansions) != 2 || expansions[0].Synonym != "hkust" || expansions[1].Synonym != "statistical learning" {
+		t.Fatalf("got %v", expansions)
+	}
+
+	// One way rules do not expand back
+	if expansions = dictionary.Expand("statistical learning"); len(expansions) != 0 {
+		t.Fatalf("got %v", expansions)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, rules := range []string{"hkust", "a => b => c", " => b"} {
+		if _, err := Parse(strings.NewReader(rules)); err == nil {
+			t.Errorf("no error for %q", rules)
+		}
+	}
+}
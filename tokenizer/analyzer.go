@@ -0,0 +1,500 @@
+package tokenizer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/reiver/go-porterstemmer"
+	"golang.org/x/text/unicode/norm"
+)
+
+// CharFilter rewrites the raw text before it is split into tokens
+type CharFilter interface {
+	FilterText(text string) string
+}
+
+// Tokenizer splits text into tokens
+type Tokenizer interface {
+	Tokenize(text string) []string
+}
+
+// TokenFilter transforms, removes or adds tokens
+type TokenFilter interface {
+	FilterTokens(tokens []string) []string
+}
+
+// LanguageFilter is a TokenFilter that depends on the language of the text
+type LanguageFilter interface {
+	TokenFilter
+	ForLanguage(code string) TokenFilter
+}
+
+// Analyzer turns text into index terms by running its stages in order.
+// The same analyzer must be used at index and query time for a field, so its
+// version is bumped whenever the terms it produces change.
+type Analyzer struct {
+	Name         string
+	Version      int
+	CharFilters  []CharFilter
+	Tokenizer    Tokenizer
+	TokenFilters []TokenFilter
+}
+
+// ForLanguage returns a copy of the analyzer whose stopword and stemming
+// filters use the given language
+func (analyzer *Analyzer) ForLanguage(code string) *Analyzer {
+	copied := *analyzer
+	copied.TokenFilters = make([]TokenFilter, len(analyzer.TokenFilters))
+	for i, tokenFilter := range analyzer.TokenFilters {
+		if languageFilter, ok := tokenFilter.(LanguageFilter); ok {
+			tokenFilter = languageFilter.ForLanguage(code)
+		}
+		copied.TokenFilters[i] = tokenFilter
+	}
+	return &copied
+}
+
+// ID is the name and version of the analyzer, like "standard/2", as recorded in an index
+func (analyzer *Analyzer) ID() string {
+	return fmt.Sprintf("%s/%d", analyzer.Name, analyzer.Version)
+}
+
+func (analyzer *Analyzer) Analyze(text string) []string {
+	for _, charFilter := range analyzer.CharFilters {
+		text = charFilter.FilterText(text)
+	}
+	tokens := analyzer.Tokenizer.Tokenize(text)
+	for _, tokenFilter := range analyzer.TokenFilters {
+		tokens = tokenFilter.FilterTokens(tokens)
+	}
+	return tokens
+}
+
+// RegexpCharFilter replaces every match of a pattern
+type RegexpCharFilter struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+func (filter RegexpCharFilter) FilterText(text string) string {
+	return filter.Pattern.ReplaceAllString(text, filter.Replacement)
+}
+
+// WhitespaceTokenizer splits text on white space
+type WhitespaceTokenizer struct{}
+
+func (WhitespaceTokenizer) Tokenize(text string) []string {
+	return strings.Fields(text)
+}
+
+// URLTokenizer splits a URL into the words of its host and path, dropping the scheme
+type URLTokenizer struct{}
+
+var urlSeparator = regexp.MustCompile(`[^\pL\pN]+`)
+
+func (URLTokenizer) Tokenize(text string) []string {
+	if i := strings.Index(text, "://"); i >= 0 {
+		text = text[i+3:]
+	}
+	tokens := make([]string, 0)
+	for _, token := range urlSeparator.Split(text, -1) {
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// UnicodeTokenizer splits text into runs of letters and digits of any script.
+// A run of CJK characters is kept as its own token, to be split further by
+// CJKBigramFilter, since those scripts do not separate words with spaces.
+type UnicodeTokenizer struct{}
+
+func (UnicodeTokenizer) Tokenize(text string) []string {
+	tokens := make([]string, 0)
+	start := -1
+	startCJK := false
+	for i, r := range text {
+		if start >= 0 && unicode.Is(unicode.Mn, r) {
+			// Combining marks belong to the letter before them
+			continue
+		}
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if start >= 0 && (!word || isCJK(r) != startCJK) {
+			tokens = append(tokens, text[start:i])
+			start = -1
+		}
+		if word && start < 0 {
+			start = i
+			startCJK = isCJK(r)
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// isCJK reports whether a rune belongs to a script written without spaces between words
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// LowercaseFilter lowercases every token
+type LowercaseFilter struct{}
+
+func (LowercaseFilter) FilterTokens(tokens []string) []string {
+	for i, token := range tokens {
+		tokens[i] = strings.ToLower(token)
+	}
+	return tokens
+}
+
+// Letters that do not decompose into a base letter and a combining mark
+var foldedLetters = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "đ", "d", "ð", "d", "þ", "th", "ł", "l", "ı", "i",
+)
+
+// AccentFoldFilter removes diacritics so that "café" and "cafe" are the same term
+type AccentFoldFilter struct{}
+
+func (AccentFoldFilter) FilterTokens(tokens []string) []string {
+	for i, token := range tokens {
+		tokens[i] = foldAccents(token)
+	}
+	return tokens
+}
+
+func foldAccents(token string) string {
+	ascii := true
+	for _, r := range token {
+		if r >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return token
+	}
+	var folded strings.Builder
+	for _, r := range norm.NFD.String(token) {
+		if !unicode.Is(unicode.Mn, r) {
+			folded.WriteRune(r)
+		}
+	}
+	return norm.NFC.String(foldedLetters.Replace(folded.String()))
+}
+
+// CJKBigramFilter replaces every CJK token with its overlapping character bigrams,
+// so "香港科技" becomes "香港", "港科", "科技". A single character is kept as it is.
+type CJKBigramFilter struct{}
+
+func (CJKBigramFilter) FilterTokens(tokens []string) []string {
+	result := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		runes := []rune(token)
+		if len(runes) < 2 || !isCJK(runes[0]) {
+			result = append(result, token)
+			continue
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			result = append(result, string(runes[i:i+2]))
+		}
+	}
+	return result
+}
+
+// StopFilter removes the tokens found in a stopword set
+type StopFilter struct {
+	Words map[string]bool
+}
+
+func (filter StopFilter) FilterTokens(tokens []string) []string {
+	result := tokens[:0]
+	for _, token := range tokens {
+		if !filter.Words[token] {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+// StopwordFilter removes the stopwords of a language, the default language if none is set
+type StopwordFilter struct {
+	Language string
+}
+
+func (filter StopwordFilter) FilterTokens(tokens []string) []string {
+	return StopFilter{Words: GetLanguage(filter.Language).Stopwords}.FilterTokens(tokens)
+}
+
+func (filter StopwordFilter) ForLanguage(code string) TokenFilter {
+	return StopwordFilter{Language: code}
+}
+
+// StemFilter reduces every word to its stem with the stemmer of a language,
+// the default language if none is set
+type StemFilter struct {
+	Language string
+}
+
+func (filter StemFilter) FilterTokens(tokens []string) []string {
+	language := GetLanguage(filter.Language)
+	for i, token := range tokens {
+		tokens[i] = language.Stem(token)
+	}
+	return tokens
+}
+
+func (filter StemFilter) ForLanguage(code string) TokenFilter {
+	return StemFilter{Language: code}
+}
+
+// PorterStemFilter reduces every English word to its Porter stem. Tokens with
+// digits or non ASCII letters, like course codes and CJK bigrams, are kept as they are.
+// Use StemFilter to stem text in other languages.
+type PorterStemFilter struct{}
+
+func (PorterStemFilter) FilterTokens(tokens []string) []string {
+	for i, token := range tokens {
+		if isASCIIWord(token) {
+			tokens[i] = porterstemmer.StemString(token)
+		}
+	}
+	return tokens
+}
+
+func isASCIIWord(token string) bool {
+	for _, r := range token {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// LengthFilter removes tokens shorter or longer than the limits, 0 means no limit
+type LengthFilter struct {
+	Min int
+	Max int
+}
+
+func (filter LengthFilter) FilterTokens(tokens []string) []string {
+	result := tokens[:0]
+	for _, token := range tokens {
+		length := utf8.RuneCountInString(token)
+		if length < filter.Min || (filter.Max > 0 && length > filter.Max) {
+			continue
+		}
+		result = append(result, token)
+	}
+	return result
+}
+
+// Names of the built in analyzers
+const (
+	StandardAnalyzer = "standard"
+	SimpleAnalyzer   = "simple"
+	URLAnalyzer      = "url"
+)
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]func() *Analyzer{
+		// Words of any script, lowercased, CJK split into bigrams, without stopwords,
+		// stemmed and with accents folded. The stemmers need the accents so they
+		// are folded last.
+		StandardAnalyzer: func() *Analyzer {
+			return &Analyzer{
+				Name:         StandardAnalyzer,
+				Version:      3,
+				Tokenizer:    UnicodeTokenizer{},
+				TokenFilters: []TokenFilter{LowercaseFilter{}, CJKBigramFilter{}, StopwordFilter{}, StemFilter{}, AccentFoldFilter{}},
+			}
+		},
+		// Words of any script, lowercased, accents folded, CJK split into bigrams,
+		// nothing removed or stemmed
+		SimpleAnalyzer: func() *Analyzer {
+			return &Analyzer{
+				Name:         SimpleAnalyzer,
+				Version:      2,
+				Tokenizer:    UnicodeTokenizer{},
+				TokenFilters: []TokenFilter{LowercaseFilter{}, AccentFoldFilter{}, CJKBigramFilter{}},
+			}
+		},
+		// Host and path words of a URL, without stopwords and one letter fragments
+		URLAnalyzer: func() *Analyzer {
+			return &Analyzer{
+				Name:         URLAnalyzer,
+				Version:      3,
+				Tokenizer:    URLTokenizer{},
+				TokenFilters: []TokenFilter{LowercaseFilter{}, LengthFilter{Min: 2}, StopwordFilter{}, StemFilter{}, AccentFoldFilter{}},
+			}
+		},
+	}
+)
+
+// Register makes an analyzer available by name
+func Register(name string, factory func() *Analyzer) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[name] = factory
+}
+
+// Get builds a new instance of the named analyzer. A name with a version, like
+// "standard/2", is refused if the analyzer is now at another version.
+func Get(name string) (*Analyzer, error) {
+	version := 0
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		var err error
+		if version, err = strconv.Atoi(name[i+1:]); err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid analyzer version %q", name)
+		}
+		name = name[:i]
+	}
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	factory, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown analyzer %q", name)
+	}
+	analyzer := factory()
+	if version != 0 && version != analyzer.Version {
+		return nil, fmt.Errorf("analyzer %s is at version %d, not %d", name, analyzer.Version, version)
+	}
+	return analyzer, nil
+}
+
+// Names lists the registered analyzers
+func Names() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Names of the indexed page fields
+const (
+	FieldTitle       = "title"
+	FieldBody        = "body"
+	FieldURL         = "url"
+	FieldHeading     = "heading"
+	FieldDescription = "description"
+	FieldKeywords    = "keywords"
+	FieldAlt         = "alt"
+)
+
+// FieldAnalyzers maps a field name to the name of its analyzer
+type FieldAnalyzers map[string]string
+
+func DefaultFieldAnalyzers() FieldAnalyzers {
+	return FieldAnalyzers{
+		FieldTitle:       StandardAnalyzer,
+		FieldBody:        StandardAnalyzer,
+		FieldURL:         URLAnalyzer,
+		FieldHeading:     StandardAnalyzer,
+		FieldDescription: StandardAnalyzer,
+		FieldKeywords:    StandardAnalyzer,
+		FieldAlt:         StandardAnalyzer,
+	}
+}
+
+// ParseFieldAnalyzers overrides the defaults with a list like "title=simple,url=url"
+func ParseFieldAnalyzers(spec string) (FieldAnalyzers, error) {
+	fields := DefaultFieldAnalyzers()
+	if err := fields.Set(spec); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Set overrides the analyzers of the fields in a list like "title=simple,url=url"
+func (fields FieldAnalyzers) Set(spec string) error {
+	known := DefaultFieldAnalyzers()
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid field analyzer %q, expected field=analyzer", pair)
+		}
+		field, name := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if _, ok := known[field]; !ok {
+			return fmt.Errorf("unknown field %q", field)
+		}
+		if _, err := Get(name); err != nil {
+			return err
+		}
+		fields[field] = name
+	}
+	return nil
+}
+
+// Validate checks every field and analyzer is known
+func (fields FieldAnalyzers) Validate() error {
+	known := DefaultFieldAnalyzers()
+	for field, name := range fields {
+		if _, ok := known[field]; !ok {
+			return fmt.Errorf("unknown field %q", field)
+		}
+		if _, err := Get(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IDs returns the name and version of the analyzer of every field, to be recorded in an index
+func (fields FieldAnalyzers) IDs() FieldAnalyzers {
+	ids := make(FieldAnalyzers, len(fields))
+	for field := range fields {
+		ids[field] = fields.Analyzer(field).ID()
+	}
+	return ids
+}
+
+// RecordedAnalyzers returns the analyzers an index was built with, the
+// defaults for the fields it has no record of. Names recorded without a
+// version predate the versions and are version 1. The error lists the
+// fields whose analyzer has changed since, those fields must be reindexed.
+func RecordedAnalyzers(recorded map[string]string) (FieldAnalyzers, error) {
+	fields := DefaultFieldAnalyzers()
+	changed := make([]string, 0)
+	for field, name := range recorded {
+		if !strings.Contains(name, "/") {
+			name += "/1"
+		}
+		fields[field] = name
+		if _, err := Get(name); err != nil {
+			changed = append(changed, fmt.Sprintf("%s (%s)", field, err))
+		}
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return fields, fmt.Errorf("the index must be rebuilt, the analyzers of these fields changed: %s", strings.Join(changed, ", "))
+	}
+	return fields, nil
+}
+
+// Analyzer builds the analyzer of a field, fields without one use the standard analyzer
+func (fields FieldAnalyzers) Analyzer(field string) *Analyzer {
+	if name, ok := fields[field]; ok {
+		if analyzer, err := Get(name); err == nil {
+			return analyzer
+		}
+	}
+	analyzer, _ := Get(StandardAnalyzer)
+	return analyzer
+}
@@ -0,0 +1,125 @@
+package tokenizer
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Only the start of a document is needed to tell its language
+const detectionLimit = 4096
+
+// Fewer trigrams than this are too little text to tell languages apart
+const minTrigrams = 20
+
+// Trigram counts of every language written in Latin script. Stopwords are
+// the most frequent words of a language, so the profiles are built from them.
+var (
+	profiles      = map[string]map[string]float64{}
+	profileTotals = map[string]float64{}
+	trigramCount  float64
+)
+
+func buildProfiles() {
+	seen := make(map[string]bool)
+	for code, language := range languages {
+		if code == "ru" || language.stem == nil {
+			// Recognised by their script instead
+			continue
+		}
+		profile := make(map[string]float64)
+		for word := range language.Stopwords {
+			for _, trigram := range wordTrigrams(word) {
+				profile[trigram]++
+				profileTotals[code]++
+				seen[trigram] = true
+			}
+		}
+		profiles[code] = profile
+	}
+	trigramCount = float64(len(seen))
+}
+
+// wordTrigrams returns the trigrams of a word padded with spaces, so the
+// start and end of the word count too
+func wordTrigrams(word string) []string {
+	runes := []rune(" " + word + " ")
+	trigrams := make([]string, 0, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		trigrams = append(trigrams, string(runes[i:i+3]))
+	}
+	return trigrams
+}
+
+// DetectLanguage guesses the language of a text. Chinese, Japanese, Korean and
+// Russian are told by their script, other languages by comparing the text's
+// trigrams with each language profile. It returns "" if there is too little
+// text to be sure.
+func DetectLanguage(text string) string {
+	if len(text) > detectionLimit {
+		text = text[:detectionLimit]
+	}
+
+	var han, kana, hangul, cyrillic, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	// A CJK character carries about as much as a few Latin letters
+	cjk := 3 * (han + kana + hangul)
+	switch {
+	case cjk > latin && cjk >= cyrillic:
+		if kana > 0 {
+			return "ja"
+		}
+		if hangul > han {
+			return "ko"
+		}
+		return "zh"
+	case cyrillic > latin:
+		return "ru"
+	}
+
+	counts := make(map[string]float64)
+	total := 0.0
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for _, trigram := range wordTrigrams(word) {
+			counts[trigram]++
+			total++
+		}
+	}
+	if total < minTrigrams {
+		return ""
+	}
+
+	// Naive Bayes with add one smoothing
+	best, bestScore, secondScore := "", math.Inf(-1), math.Inf(-1)
+	for code, profile := range profiles {
+		score := 0.0
+		for trigram, count := range counts {
+			score += count * math.Log((profile[trigram]+1)/(profileTotals[code]+trigramCount))
+		}
+		if score > bestScore {
+			best, bestScore, secondScore = code, score, bestScore
+		} else if score > secondScore {
+			secondScore = score
+		}
+	}
+	// Too close to call
+	if bestScore-secondScore < math.Log(2) {
+		return ""
+	}
+	return best
+}
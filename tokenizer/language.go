@@ -0,0 +1,153 @@
+package tokenizer
+
+import (
+	"bufio"
+	"embed"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kljensen/snowball"
+	"github.com/reiver/go-porterstemmer"
+)
+
+// Stopword lists, one word per line, named after the language code
+//
+//go:embed stopwords/*.txt
+var stopwordFiles embed.FS
+
+// Language used for pages and queries without a known language
+const DefaultLanguage = "en"
+
+// Language holds the stopwords and stemmer used to analyse text of one language
+type Language struct {
+	Code      string
+	Name      string
+	Stopwords map[string]bool
+	stem      func(string) string
+}
+
+// Stem reduces a word to its stem, words with digits are kept as they are
+func (language *Language) Stem(word string) string {
+	if language.stem == nil {
+		return word
+	}
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			return word
+		}
+	}
+	return language.stem(word)
+}
+
+func snowballStemmer(language string) func(string) string {
+	return func(word string) string {
+		stemmed, err := snowball.Stem(word, language, true)
+		if err != nil {
+			return word
+		}
+		return stemmed
+	}
+}
+
+// Porter only knows English letters, so accents are folded first
+func porterStem(word string) string {
+	folded := foldAccents(word)
+	if !isASCIIWord(folded) {
+		return word
+	}
+	return porterstemmer.StemString(folded)
+}
+
+var languages = map[string]*Language{}
+
+func init() {
+	for _, language := range []*Language{
+		{Code: "en", Name: "English", stem: porterStem},
+		{Code: "fr", Name: "French", stem: snowballStemmer("french")},
+		{Code: "es", Name: "Spanish", stem: snowballStemmer("spanish")},
+		{Code: "ru", Name: "Russian", stem: snowballStemmer("russian")},
+		{Code: "sv", Name: "Swedish", stem: snowballStemmer("swedish")},
+		{Code: "no", Name: "Norwegian", stem: snowballStemmer("norwegian")},
+		{Code: "hu", Name: "Hungarian", stem: snowballStemmer("hungarian")},
+		// Split into bigrams instead of stemmed
+		{Code: "zh", Name: "Chinese"},
+		{Code: "ja", Name: "Japanese"},
+		{Code: "ko", Name: "Korean"},
+	} {
+		language.Stopwords = loadStopwords(language.Code)
+		languages[language.Code] = language
+	}
+	buildProfiles()
+}
+
+// loadStopwords reads the embedded list of a language. Folded forms are
+// added too, so the words match whether or not accents were removed.
+func loadStopwords(code string) map[string]bool {
+	words := make(map[string]bool)
+	file, err := stopwordFiles.Open("stopwords/" + code + ".txt")
+	if err != nil {
+		return words
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			words[word] = true
+			words[foldAccents(word)] = true
+		}
+	}
+	return words
+}
+
+// GetLanguage returns a language from its code, which may carry a region
+// like "en-US". Unknown codes give the default language.
+func GetLanguage(code string) *Language {
+	if language, ok := languages[normalizeLanguage(code)]; ok {
+		return language
+	}
+	return languages[DefaultLanguage]
+}
+
+// Languages lists the codes of the supported languages
+func Languages() []string {
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func normalizeLanguage(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	// Old code for Norwegian Bokmål and Nynorsk
+	if code == "nb" || code == "nn" {
+		code = "no"
+	}
+	return code
+}
+
+// ResolveLanguage picks the language of a text, preferring the declared one
+// (e.g. from <html lang>) when it is supported, then the detected one
+func ResolveLanguage(declared string, text string) string {
+	if _, ok := languages[normalizeLanguage(declared)]; ok {
+		return normalizeLanguage(declared)
+	}
+	if detected := DetectLanguage(text); detected != "" {
+		return detected
+	}
+	return DefaultLanguage
+}
+
+// QueryLanguage picks the language of a query like ResolveLanguage, but
+// returns "" when none is given and the query is too short to detect it
+func QueryLanguage(declared string, query string) string {
+	if _, ok := languages[normalizeLanguage(declared)]; !ok && DetectLanguage(query) == "" {
+		return ""
+	}
+	return ResolveLanguage(declared, query)
+}
@@ -4,15 +4,143 @@ import (
 	"testing"
 )
 
-func TestLoadStopwords(t *testing.T) {
-	LoadStopWords()
+func TestLanguageStopwords(t *testing.T) {
+	if !GetLanguage("en").Stopwords["the"] || !GetLanguage("fr-CA").Stopwords["été"] || !GetLanguage("fr").Stopwords["ete"] {
+		t.Fail()
+	}
+	if GetLanguage("xx").Code != DefaultLanguage {
+		t.Fail()
+	}
 }
 
 func TestTokenize(t *testing.T) {
-	LoadStopWords()
 	testStr := "Test tEST arrival"
 	result := Tokenize(testStr)
 	if result[0] != "test" || result[2] != "arriv" {
 		t.Fail()
 	}
 }
+
+func TestURLAnalyzer(t *testing.T) {
+	analyzer, err := Get(URLAnalyzer)
+	if err != nil {
+		t.FailNow()
+	}
+	result := analyzer.Analyze("https://www.cse.ust.hk/a/index.html")
+	expected := []string{"www", "cse", "ust", "hk", "index", "html"}
+	if len(result) != len(expected) {
+		t.Fatalf("got %v", result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Fatalf("got %v", result)
+		}
+	}
+}
+
+func TestParseFieldAnalyzers(t *testing.T) {
+	fields, err := ParseFieldAnalyzers("title=simple, url=url")
+	if err != nil {
+		t.FailNow()
+	}
+	if fields[FieldTitle] != SimpleAnalyzer || fields[FieldBody] != StandardAnalyzer || fields.Analyzer(FieldTitle).Name != SimpleAnalyzer {
+		t.Fail()
+	}
+	if _, err := ParseFieldAnalyzers("title=missing"); err == nil {
+		t.Fail()
+	}
+	if _, err := ParseFieldAnalyzers("nofield=simple"); err == nil {
+		t.Fail()
+	}
+}
+
+func TestUnicodeAnalyzer(t *testing.T) {
+	analyzer, err := Get(SimpleAnalyzer)
+	if err != nil {
+		t.FailNow()
+	}
+	result := analyzer.Analyze("COMP4321 Café, 香港科技大學 and 香")
+	expected := []string{"comp4321", "cafe", "香港", "港科", "科技", "技大", "大學", "and", "香"}
+	if len(result) != len(expected) {
+		t.Fatalf("got %v", result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Fatalf("got %v", result)
+		}
+	}
+}
+
+func TestTokenizeCourseCode(t *testing.T) {
+	result := Tokenize("Courses: COMP4321, naïve résumé")
+	if len(result) != 4 || result[0] != "cours" || result[1] != "comp4321" || result[2] != "naiv" || result[3] != "resum" {
+		t.Fatalf("got %v", result)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	texts := map[string]string{
+		"en": "The department offers courses for students who are interested in the theory and the practice of computing, and all of them are taught in English.",
+		"fr": "Le département propose des cours pour les étudiants qui sont intéressés par la théorie et la pratique de l'informatique, et ils sont tous en français.",
+		"es": "El departamento ofrece cursos para los estudiantes que están interesados en la teoría y la práctica de la computación, y todos son en español.",
+		"zh": "香港科技大學計算機科學及工程學系",
+		"ru": "Кафедра предлагает курсы для студентов",
+	}
+	for expected, text := range texts {
+		if detected := DetectLanguage(text); detected != expected {
+			t.Errorf("detected %q instead of %q", detected, expected)
+		}
+	}
+	if DetectLanguage("search") != "" {
+		t.Fail()
+	}
+	if ResolveLanguage("es-MX", "the english text") != "es" || ResolveLanguage("", "search") != DefaultLanguage {
+		t.Fail()
+	}
+}
+
+func TestQueryLanguage(t *testing.T) {
+	// A short query is analysed in the language of each page
+	if language := QueryLanguage("", "recherche"); language != "" {
+		t.Errorf("short query resolved to %q", language)
+	}
+	if language := QueryLanguage("xx", "recherche"); language != "" {
+		t.Errorf("short query with an unknown language resolved to %q", language)
+	}
+	if language := QueryLanguage("fr-CA", "recherche"); language != "fr" {
+		t.Errorf("given language resolved to %q", language)
+	}
+	if language := QueryLanguage("", "香港科技大學"); language != "zh" {
+		t.Errorf("detected language resolved to %q", language)
+	}
+}
+
+func TestAnalyzerVersion(t *testing.T) {
+	standard, err := Get(StandardAnalyzer)
+	if err != nil {
+		t.FailNow()
+	}
+	if _, err := Get(standard.ID()); err != nil {
+		t.Errorf("current version refused: %s", err)
+	}
+	if _, err := Get(StandardAnalyzer + "/1"); err == nil {
+		t.Error("old version accepted")
+	}
+	if _, err := Get(StandardAnalyzer + "/x"); err == nil {
+		t.Error("invalid version accepted")
+	}
+	if ids := DefaultFieldAnalyzers().IDs(); ids[FieldBody] != standard.ID() {
+		t.Errorf("body recorded as %s", ids[FieldBody])
+	}
+}
+
+func TestRecordedAnalyzers(t *testing.T) {
+	fields, err := RecordedAnalyzers(DefaultFieldAnalyzers().IDs())
+	if err != nil || fields.Analyzer(FieldTitle).Name != StandardAnalyzer {
+		t.Errorf("current analyzers refused: %v", err)
+	}
+	// Indexes built before analyzers had versions recorded the bare names
+	if _, err := RecordedAnalyzers(map[string]string{FieldTitle: StandardAnalyzer}); err == nil {
+		t.Error("analyzer changed since the index was built accepted")
+	}
+}
@@ -0,0 +1,142 @@
+package vsm
+
+import (
+	"math"
+	"sort"
+
+	"github.com/davi1972/comp4321-search-engine/tokenizer"
+)
+
+// RocchioOptions sets how a query is expanded with the documents it ranks highest
+type RocchioOptions struct {
+	// Top ranked documents taken as relevant
+	Documents int
+	// Highest weighted new terms added to the query
+	Terms int
+	// Weights of the original query and of the relevant documents
+	Alpha float64
+	Beta  float64
+}
+
+var DefaultRocchioOptions = RocchioOptions{Documents: 5, Terms: 10, Alpha: 1, Beta: 0.75}
+
+// ExpansionTerm is a term added to a query by relevance feedback
+type ExpansionTerm struct {
+	Term   string  `json:"term"`
+	Weight float64 `json:"weight"`
+}
+
+// ComputeRocchioCosineScore expands a query with pseudo relevance feedback. The
+// documents ranked highest by scores are taken as relevant, the query vector is
+// moved toward their centroid with Rocchio's formula and scored again. It
+// returns the new scores and the terms added to the query.
+func (vsm *VSM) ComputeRocchioCosineScore(queries []WeightedQuery, language string, scores map[uint64]float64, options RocchioOptions) (map[uint64]float64, []ExpansionTerm, error) {
+	relevant := topDocuments(scores, options.Documents)
+	if len(relevant) == 0 {
+		return scores, nil, nil
+	}
+
+	// Terms already in the query keep their own weight
+	queryTerms := make(map[string]bool)
+	for _, query := range queries {
+		for _, queryLanguage := range queryLanguages(language) {
+			for _, term := range vsm.Analyze(tokenizer.FieldBody, queryLanguage, query.Text) {
+				queryTerms[term] = true
+			}
+		}
+	}
+
+	N, _ := vsm.documentCounts()
+	centroid := make(map[uint64]float64)
+	for _, documentID := range relevant {
+		for wordID, weight := range vsm.documentVector(documentID, N) {
+			centroid[wordID] += weight / float64(len(relevant))
+		}
+	}
+
+	added := make([]ExpansionTerm, 0, len(centroid))
+	for wordID, weight := range centroid {
+		term, err := vsm.ReverseWordIndexer.GetValueFromKey(wordID)
+		if err != nil || queryTerms[term] {
+			continue
+		}
+		added = append(added, ExpansionTerm{Term: term, Weight: weight})
+	}
+	sort.Slice(added, func(i, j int) bool {
+		if added[i].Weight != added[j].Weight {
+			return added[i].Weight > added[j].Weight
+		}
+		return added[i].Term < added[j].Term
+	})
+	if len(added) > options.Terms {
+		added = added[:options.Terms]
+	}
+	if len(added) == 0 {
+		return scores, added, nil
+	}
+
+	// A centroid of unit length vectors is spread over many terms, so it is
+	// scaled for the strongest added term to weigh Beta against a query term's 1
+	maxWeight := added[0].Weight
+	bodyTerms := make(map[string]float64)
+	for i := range added {
+		added[i].Weight = options.Beta * added[i].Weight / maxWeight
+		bodyTerms[added[i].Term] = added[i].Weight
+	}
+	reweighted := make([]WeightedQuery, len(queries))
+	for i, query := range queries {
+		reweighted[i] = WeightedQuery{Text: query.Text, Weight: options.Alpha * query.Weight}
+	}
+	expandedScores, err := vsm.computeCosineScore(reweighted, language, bodyTerms)
+	return expandedScores, added, err
+}
+
+// documentVector returns the unit length tf-idf vector of a document's body words
+func (vsm *VSM) documentVector(documentID uint64, N uint64) map[uint64]float64 {
+	vector := make(map[uint64]float64)
+	words, _ := vsm.DocumentWordForwardIndexer.GetWordFrequencyListFromKey(documentID)
+	maxtf := uint64(1)
+	for _, word := range words {
+		if word.GetFrequency() > maxtf {
+			maxtf = word.GetFrequency()
+		}
+	}
+	length := 0.0
+	for _, word := range words {
+		invFileList, _ := vsm.ContentInvertedIndexer.GetInvertedFileFromKey(word.GetID())
+		if len(invFileList) == 0 {
+			continue
+		}
+		weight := float64(word.GetFrequency()) / float64(maxtf) * math.Log2(float64(N)/float64(len(invFileList)))
+		if weight <= 0 {
+			continue
+		}
+		vector[word.GetID()] = weight
+		length += weight * weight
+	}
+	length = math.Sqrt(length)
+	for wordID := range vector {
+		vector[wordID] /= length
+	}
+	return vector
+}
+
+// topDocuments returns the IDs of the k highest scored documents
+func topDocuments(scores map[uint64]float64, k int) []uint64 {
+	documents := make([]uint64, 0, len(scores))
+	for documentID, score := range scores {
+		if score > 0 {
+			documents = append(documents, documentID)
+		}
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		if scores[documents[i]] != scores[documents[j]] {
+			return scores[documents[i]] > scores[documents[j]]
+		}
+		return documents[i] < documents[j]
+	})
+	if len(documents) > k {
+		documents = documents[:k]
+	}
+	return documents
+}
@@ -0,0 +1,43 @@
+package vsm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ComputeSimilarScore scores the documents similar to a document, using its
+// highest tf-idf body terms as a weighted query. The document itself is left
+// out. It returns the scores and the terms the query was made of.
+func (vsm *VSM) ComputeSimilarScore(documentID uint64, terms int) (map[uint64]float64, []ExpansionTerm, error) {
+	N, _ := vsm.documentCounts()
+	vector := vsm.documentVector(documentID, N)
+	if len(vector) == 0 {
+		return nil, nil, fmt.Errorf("Error in finding similar documents: document %d has no indexed words", documentID)
+	}
+
+	query := make([]ExpansionTerm, 0, len(vector))
+	for wordID, weight := range vector {
+		term, err := vsm.ReverseWordIndexer.GetValueFromKey(wordID)
+		if err != nil {
+			continue
+		}
+		query = append(query, ExpansionTerm{Term: term, Weight: weight})
+	}
+	sort.Slice(query, func(i, j int) bool {
+		if query[i].Weight != query[j].Weight {
+			return query[i].Weight > query[j].Weight
+		}
+		return query[i].Term < query[j].Term
+	})
+	if len(query) > terms {
+		query = query[:terms]
+	}
+
+	bodyTerms := make(map[string]float64)
+	for _, term := range query {
+		bodyTerms[term.Term] = term.Weight
+	}
+	scores, err := vsm.computeCosineScore(nil, "", bodyTerms)
+	delete(scores, documentID)
+	return scores, query, err
+}
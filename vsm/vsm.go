@@ -1,7 +1,9 @@
 package vsm
 
 import (
+	"fmt"
 	"math"
+	"strings"
 
 	//Indexer "github.com/davi1972/comp4321-search-engine/indexer"
 	Indexer "github.com/davi1972/comp4321-search-engine/indexer"
@@ -20,6 +22,20 @@ type VSM struct {
 	ParentChildDocumentForwardIndexer *Indexer.ForwardIndexer
 	ChildParentDocumentForwardIndexer *Indexer.ForwardIndexer
 	TitleWordForwardIndexer           *Indexer.DocumentWordForwardIndexer
+	// Shared statistics of the collection, read from the word lists if nil
+	Collection *Indexer.Collection
+	// Boost of the title relative to the body
+	TitleBoost float64
+	Fields     []Field
+	Analyzers  tokenizer.FieldAnalyzers
+}
+
+// Field is an extra page field (headings, meta description, ...) scored
+// like the title, with its own inverted index and boost.
+type Field struct {
+	Name            string
+	InvertedIndexer *Indexer.InvertedFileIndexer
+	Boost           float64
 }
 
 // Returns a wordid given a (tokenized) term.
@@ -30,65 +46,146 @@ func (vsm *VSM) StringToWordID(qterm string) (uint64, error) {
 
 // Returns the maximum term frequency of a term in a document ID.
 func (vsm *VSM) MaxTermFreq(documentID uint64) uint64 {
+	if stats := vsm.collectionStats(); stats != nil {
+		return stats.MaxTermFrequency[documentID]
+	}
 	words, _ := vsm.DocumentWordForwardIndexer.GetWordFrequencyListFromKey(documentID)
+	return Indexer.MaxFrequency(words)
+}
 
-	if len(words) > 0 {
-		wf := words[0]
-		for i := range words[1:] {
-			if words[i].GetFrequency() > wf.GetFrequency() {
-				wf = words[i]
-			}
+// collectionStats returns the shared snapshot of the collection, nil without one
+func (vsm *VSM) collectionStats() *Indexer.CollectionStats {
+	if vsm.Collection == nil {
+		return nil
+	}
+	stats, err := vsm.Collection.Stats()
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	return stats
+}
+
+// documentCounts returns N, the number of documents with a body, and the
+// number of documents with a title
+func (vsm *VSM) documentCounts() (uint64, uint64) {
+	if stats := vsm.collectionStats(); stats != nil {
+		return stats.Documents, stats.TitleDocuments
+	}
+	return vsm.DocumentWordForwardIndexer.GetSize(), vsm.TitleWordForwardIndexer.GetSize()
+}
+
+// Analyze runs a query through the analyzer of a field in the given language,
+// so it is split into the same terms the field was indexed with
+func (vsm *VSM) Analyze(field string, language string, query string) []string {
+	return vsm.Analyzers.Analyzer(field).ForLanguage(language).Analyze(query)
+}
+
+// scoreTerm adds the weight of a query term in one field to the scores of the
+// documents containing it. It returns the term's contribution to the document
+// length, and false if the term is not in the index at all.
+func (vsm *VSM) scoreTerm(term string, invertedIndexer *Indexer.InvertedFileIndexer, N uint64, boost float64, scores map[uint64]float64) (float64, bool) {
+	wordID, wordIDErr := vsm.WordIndexer.GetValueFromKey(term)
+	if wordIDErr != nil {
+		return 0, false
+	}
+	docLength := 0.0
+	invFileList, _ := invertedIndexer.GetInvertedFileFromKey(wordID)
+	for _, invFile := range invFileList {
+		tf := len(invFile.GetWordPositions())
+
+		maxtf := vsm.MaxTermFreq(invFile.GetPageID())
+		if maxtf == 0 {
+			// Page has no body words, e.g. an image-only page
+			maxtf = 1
 		}
-		return wf.GetFrequency()
+		df := len(invFileList)
+		infreq := math.Log2(float64(N) / float64(df))
+		scores[invFile.GetPageID()] += (float64(tf) / float64(maxtf) * float64(infreq)) * boost
+		docLength += (float64(tf) * float64(infreq) * float64(tf) * float64(infreq)) * boost * boost
 	}
-	return 0
+	return docLength, true
 }
 
-// Returns a float array with scores starting with doc 0 as index
+// Returns a float array with scores starting with doc 0 as index, for a query
+// in the language it is detected to be in
 func (vsm *VSM) ComputeCosineScore(query string) (map[uint64]float64, error) {
-	//fmt.Printf("N = %d\n", 0)
+	return vsm.ComputeLanguageCosineScore(query, tokenizer.QueryLanguage("", query))
+}
+
+// ComputeLanguageCosineScore scores a query written in the given language, or
+// in the language of each page if the language is ""
+func (vsm *VSM) ComputeLanguageCosineScore(query string, language string) (map[uint64]float64, error) {
+	return vsm.ComputeWeightedCosineScore([]WeightedQuery{{Text: query, Weight: 1}}, language)
+}
+
+// WeightedQuery is a part of a query whose terms count Weight times as much
+// as those of the original query, e.g. a synonym added to it
+type WeightedQuery struct {
+	Text   string
+	Weight float64
+}
+
+// ComputeWeightedCosineScore scores the documents matching any of the queries,
+// every term weighted by the query it comes from
+func (vsm *VSM) ComputeWeightedCosineScore(queries []WeightedQuery, language string) (map[uint64]float64, error) {
+	return vsm.computeCosineScore(queries, language, nil)
+}
+
+// computeCosineScore scores the queries together with already analysed body
+// terms and their weights, such as those added by relevance feedback
+func (vsm *VSM) computeCosineScore(queries []WeightedQuery, language string, bodyTerms map[string]float64) (map[uint64]float64, error) {
+	if language == "" {
+		return vsm.computePageLanguageCosineScore(queries, bodyTerms)
+	}
 	scores := make(map[uint64]float64)
-	queryFreq := make(map[string]int)
+	queryFreq := make(map[string]float64)
 
-	terms := tokenizer.Tokenize(query)
 	queryLength := 0.0
 	docLength := 0.0
-	for _, term := range terms {
-		wordID, wordIDErr := vsm.WordIndexer.GetValueFromKey(term)
-		if wordIDErr != nil {
-			continue
+
+	N, titleN := vsm.documentCounts()
+	for _, query := range queries {
+		for _, term := range vsm.Analyze(tokenizer.FieldBody, language, query.Text) {
+			length, found := vsm.scoreTerm(term, vsm.ContentInvertedIndexer, N, query.Weight, scores)
+			if !found {
+				continue
+			}
+			docLength += length
+			queryFreq[term] += query.Weight
 		}
-		invFileListContent, _ := vsm.ContentInvertedIndexer.GetInvertedFileFromKey(wordID)
-		for _, invFile := range invFileListContent {
-			tf := len(invFile.GetWordPositions())
-
-			maxtf := vsm.MaxTermFreq(invFile.GetPageID())
-			N := vsm.DocumentWordForwardIndexer.GetSize()
-			df := len(invFileListContent)
-			infreq := math.Log2(float64(N) / float64(df))
-			scores[invFile.GetPageID()] += (float64(tf) / float64(maxtf) * float64(infreq))
-			docLength += (float64(tf) * float64(infreq) * float64(tf) * float64(infreq))
+
+		for _, term := range vsm.Analyze(tokenizer.FieldTitle, language, query.Text) {
+			length, _ := vsm.scoreTerm(term, vsm.TitleInvertedIndexer, titleN, vsm.TitleBoost*query.Weight, scores) // Special consideration
+			docLength += length
 		}
 
-		invFileListTitle, _ := vsm.TitleInvertedIndexer.GetInvertedFileFromKey(wordID)
-		for _, invFile := range invFileListTitle {
-			tf := len(invFile.GetWordPositions())
+		for _, field := range vsm.Fields {
+			for _, term := range vsm.Analyze(field.Name, language, query.Text) {
+				length, _ := vsm.scoreTerm(term, field.InvertedIndexer, N, field.Boost*query.Weight, scores)
+				docLength += length
+			}
+		}
+	}
 
-			maxtf := vsm.MaxTermFreq(invFile.GetPageID())
-			N := vsm.TitleWordForwardIndexer.GetSize()
-			df := len(invFileListTitle)
-			infreq := math.Log2(float64(N) / float64(df))
-			scores[invFile.GetPageID()] += (float64(tf) / float64(maxtf) * float64(infreq)) * 1.5 // Special consideration
-			docLength += (float64(tf) * float64(infreq) * float64(tf) * float64(infreq)) * 1.5 * 1.5
+	for term, weight := range bodyTerms {
+		length, found := vsm.scoreTerm(term, vsm.ContentInvertedIndexer, N, weight, scores)
+		if !found {
+			continue
 		}
-		queryFreq[term]++
+		docLength += length
+		queryFreq[term] += weight
 	}
 
 	// Compute query weight
 	for k := range queryFreq {
-		queryLength += float64(queryFreq[k] * queryFreq[k])
+		queryLength += queryFreq[k] * queryFreq[k]
 	}
 	queryLength = math.Sqrt(queryLength)
+	if queryLength == 0 {
+		// Only fields analysed differently from the body matched
+		queryLength = 1
+	}
 
 	docLength = math.Sqrt(docLength)
 
@@ -100,3 +197,71 @@ func (vsm *VSM) ComputeCosineScore(query string) (map[uint64]float64, error) {
 
 	return scores, nil
 }
+
+// computePageLanguageCosineScore scores queries of unknown language: every
+// page gets the score of the queries analysed in the page's language, so the
+// stopwords and stems match those the page was indexed with. Languages that
+// analyse the queries alike are scored once.
+func (vsm *VSM) computePageLanguageCosineScore(queries []WeightedQuery, bodyTerms map[string]float64) (map[uint64]float64, error) {
+	analyses := make(map[string]string)
+	passes := make(map[string]map[uint64]float64)
+	for _, language := range queryLanguages("") {
+		analysis := vsm.analysis(queries, language)
+		analyses[language] = analysis
+		if _, scored := passes[analysis]; scored {
+			continue
+		}
+		scores, err := vsm.computeCosineScore(queries, language, bodyTerms)
+		if err != nil {
+			return nil, err
+		}
+		passes[analysis] = scores
+	}
+
+	result := make(map[uint64]float64)
+	for _, scores := range passes {
+		for documentID := range scores {
+			if _, done := result[documentID]; done {
+				continue
+			}
+			if score := passes[analyses[vsm.PageLanguage(documentID)]][documentID]; score != 0 {
+				result[documentID] = score
+			}
+		}
+	}
+	return result, nil
+}
+
+// queryLanguages returns the languages a query is analysed in, all of them if it is ""
+func queryLanguages(language string) []string {
+	if language == "" {
+		return tokenizer.Languages()
+	}
+	return []string{language}
+}
+
+// analysis returns the terms of every field of the queries analysed in a language, as one string
+func (vsm *VSM) analysis(queries []WeightedQuery, language string) string {
+	fields := []string{tokenizer.FieldBody, tokenizer.FieldTitle}
+	for _, field := range vsm.Fields {
+		fields = append(fields, field.Name)
+	}
+	var analysis strings.Builder
+	for _, query := range queries {
+		for _, field := range fields {
+			analysis.WriteString(strings.Join(vsm.Analyze(field, language, query.Text), "\x00"))
+			analysis.WriteString("\x01")
+		}
+	}
+	return analysis.String()
+}
+
+// PageLanguage returns the language a page was indexed in. Pages indexed
+// before languages were recorded are in the default language.
+func (vsm *VSM) PageLanguage(documentID uint64) string {
+	page, err := vsm.PagePropertiesIndexer.GetPagePropertiesFromKey(documentID)
+	if err != nil || page.GetLanguage() == "" {
+		return tokenizer.DefaultLanguage
+	}
+	return tokenizer.ResolveLanguage(page.GetLanguage(), "")
+}